@@ -0,0 +1,448 @@
+// Package apidiff reports the differences between two versions of an API
+// and classifies each as breaking or compatible for existing clients.
+//
+// Routes are matched by method and path, where path parameters match
+// regardless of their names, and else by service and handler name; a
+// route matched by its handler has changed its method or path. For each
+// pair of routes the requests and responses are compared field by
+// field, following the fields of struct type into nested structs, slices
+// and maps. Fields are matched by the names under which they are encoded
+// and else by their Go names; a field matched by its Go name has been
+// renamed on the wire. Fields bound to path parameters are compared as
+// part of the path.
+//
+// Breaking changes are those that may make a request of an existing
+// client fail or a response unreadable to it: removed routes, changed
+// methods or paths, new required request fields, removed, retyped or
+// now optional response fields, renamed fields and new jwt requirements.
+// Additions elsewhere, removed request fields and relaxed requirements
+// are compatible.
+package apidiff
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+
+	"github.com/zeromicro/api-ast/ast"
+	"github.com/zeromicro/api-ast/loader"
+	"github.com/zeromicro/api-ast/tag"
+	"github.com/zeromicro/api-ast/token"
+	"github.com/zeromicro/api-ast/types"
+)
+
+// A Change is a difference between the old and the new version.
+type Change struct {
+	Breaking bool   `json:"breaking"`
+	Route    string `json:"route"`         // the affected route, such as "GET /users/:id"
+	Message  string `json:"message"`       // description of the change
+	Old      string `json:"old,omitempty"` // position in the old version, if any
+	New      string `json:"new,omitempty"` // position in the new version, if any
+}
+
+// String returns the change as a line of a report: the position, the
+// classification, the route and the message.
+func (c *Change) String() string {
+	kind := "compatible"
+	if c.Breaking {
+		kind = "breaking"
+	}
+	pos := c.New
+	if pos == "" {
+		pos = c.Old
+	}
+	if pos != "" {
+		pos += ": "
+	}
+	return fmt.Sprintf("%s%s: %s: %s", pos, kind, c.Route, c.Message)
+}
+
+// A Report is the list of changes between two versions, ordered by the
+// routes of the old version; changes of added routes come last.
+type Report struct {
+	Changes []*Change `json:"changes"`
+}
+
+// Breaking reports whether r contains a breaking change.
+func (r *Report) Breaking() bool {
+	for _, c := range r.Changes {
+		if c.Breaking {
+			return true
+		}
+	}
+	return false
+}
+
+// WriteText writes the changes to w, one per line.
+func (r *Report) WriteText(w io.Writer) error {
+	for _, c := range r.Changes {
+		if _, err := fmt.Fprintln(w, c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DiffFiles loads the api files old and new with their imports and
+// compares them.
+func DiffFiles(old, new string) (*Report, error) {
+	a, err := loader.Load(old)
+	if err != nil {
+		return nil, err
+	}
+	b, err := loader.Load(new)
+	if err != nil {
+		return nil, err
+	}
+	return Diff(a, b)
+}
+
+// Diff compares the old and the new version of an API.
+func Diff(old, new *loader.API) (*Report, error) {
+	a, err := newVersion(old)
+	if err != nil {
+		return nil, err
+	}
+	b, err := newVersion(new)
+	if err != nil {
+		return nil, err
+	}
+	d := &differ{old: a, new: b, report: &Report{}}
+	d.diff()
+	return d.report, nil
+}
+
+// A version is one side of the comparison.
+type version struct {
+	fset   *token.FileSet
+	info   *types.Info
+	routes []*route
+}
+
+// A route is a route of a version with the settings of its service.
+type route struct {
+	service string
+	handler string
+	method  string // upper case
+	path    string // full path, including the prefix
+	jwt     string
+	req     ast.Expr // request type, or nil
+	resp    ast.Expr // response type, or nil
+	pos     token.Pos
+}
+
+func (r *route) String() string { return r.method + " " + r.path }
+
+// paramRx matches the path parameters of a path.
+var paramRx = regexp.MustCompile(`:[^/]+`)
+
+// key returns the method and path of r, with path parameters unnamed.
+func (r *route) key() string { return r.method + " " + paramRx.ReplaceAllString(r.path, ":") }
+
+func newVersion(api *loader.API) (*version, error) {
+	info, err := types.NewInfo(api.Files...)
+	if err != nil {
+		return nil, fmt.Errorf("apidiff: %v", err)
+	}
+	v := &version{fset: api.Fset, info: info}
+	for _, rt := range types.Routes(api.Files...) {
+		v.routes = append(v.routes, &route{
+			service: rt.Service.ServiceApi.Name.Name,
+			handler: rt.Handler,
+			method:  rt.Method,
+			path:    rt.Path,
+			jwt:     rt.Service.AtServer.Value("jwt"),
+			req:     rt.Req,
+			resp:    rt.Resp,
+			pos:     rt.Decl.Route.Method.Pos(),
+		})
+	}
+	return v, nil
+}
+
+// A differ compares two versions.
+type differ struct {
+	old, new *version
+	report   *Report
+	route    string                      // the route being compared
+	seen     map[[2]*ast.StructType]bool // struct pairs being compared, against recursion
+}
+
+func (d *differ) diff() {
+	byKey := make(map[string]*route)
+	byHandler := make(map[string]*route)
+	for _, r := range d.new.routes {
+		if byKey[r.key()] == nil {
+			byKey[r.key()] = r
+		}
+		if r.handler != "" && byHandler[r.service+" "+r.handler] == nil {
+			byHandler[r.service+" "+r.handler] = r
+		}
+	}
+	matched := make(map[*route]bool)
+
+	// first match all routes with unchanged method and path, so that
+	// handler matching only pairs the remaining ones
+	pairs := make(map[*route]*route)
+	for _, a := range d.old.routes {
+		if b := byKey[a.key()]; b != nil && !matched[b] {
+			pairs[a], matched[b] = b, true
+		}
+	}
+	for _, a := range d.old.routes {
+		b := pairs[a]
+		if b == nil && a.handler != "" {
+			if b = byHandler[a.service+" "+a.handler]; b != nil && !matched[b] {
+				pairs[a], matched[b] = b, true
+			} else {
+				b = nil
+			}
+		}
+		d.route = a.String()
+		if b == nil {
+			d.add(true, "route removed", d.old.pos(a.pos), "")
+			continue
+		}
+		d.routes(a, b)
+	}
+	for _, b := range d.new.routes {
+		if !matched[b] {
+			d.route = b.String()
+			d.add(false, "route added", "", d.new.pos(b.pos))
+		}
+	}
+}
+
+// routes compares the matched routes a and b.
+func (d *differ) routes(a, b *route) {
+	oldPos, newPos := d.old.pos(a.pos), d.new.pos(b.pos)
+	switch {
+	case a.method != b.method && a.path != b.path:
+		d.add(true, fmt.Sprintf("changed to %s", b), oldPos, newPos)
+	case a.method != b.method:
+		d.add(true, fmt.Sprintf("method changed to %s", b.method), oldPos, newPos)
+	case a.path != b.path && a.key() != b.key():
+		d.add(true, fmt.Sprintf("path changed to %s", b.path), oldPos, newPos)
+	case a.path != b.path:
+		d.add(false, fmt.Sprintf("path parameters renamed: %s", b.path), oldPos, newPos)
+	}
+	switch {
+	case a.jwt == "" && b.jwt != "":
+		d.add(true, "jwt authentication required", oldPos, newPos)
+	case a.jwt != "" && b.jwt == "":
+		d.add(false, "jwt authentication no longer required", oldPos, newPos)
+	}
+
+	d.seen = make(map[[2]*ast.StructType]bool)
+	switch {
+	case a.req == nil && b.req != nil:
+		d.fields(request, "", nil, b.req)
+	case a.req != nil && b.req == nil:
+		d.add(false, "request removed", d.old.pos(a.req.Pos()), newPos)
+	case a.req != nil:
+		d.types(request, "", a.req, b.req)
+	}
+
+	d.seen = make(map[[2]*ast.StructType]bool)
+	switch {
+	case a.resp == nil && b.resp != nil:
+		d.add(false, "response added", oldPos, d.new.pos(b.resp.Pos()))
+	case a.resp != nil && b.resp == nil:
+		d.add(true, "response removed", d.old.pos(a.resp.Pos()), newPos)
+	case a.resp != nil:
+		d.types(response, "", a.resp, b.resp)
+	}
+}
+
+// A direction tells whether a type is sent by clients or received by them.
+type direction int
+
+const (
+	request direction = iota
+	response
+)
+
+func (dir direction) String() string {
+	if dir == request {
+		return "request"
+	}
+	return "response"
+}
+
+// types compares the old type x with the new type y of the request or
+// response part named path; path is empty for the whole body.
+func (d *differ) types(dir direction, path string, x, y ast.Expr) {
+	subject := dir.String()
+	if path != "" {
+		subject += " field " + path
+	}
+	ux, uy := d.old.info.Indirect(x), d.new.info.Indirect(y)
+
+	if sx, ok := ux.(*ast.StructType); ok {
+		if sy, ok := uy.(*ast.StructType); ok {
+			pair := [2]*ast.StructType{sx, sy}
+			if d.seen[pair] {
+				return
+			}
+			d.seen[pair] = true
+			d.fields(dir, path, x, y)
+			delete(d.seen, pair)
+			return
+		}
+	}
+	switch tx := ux.(type) {
+	case *ast.ArrayType:
+		if ty, ok := uy.(*ast.ArrayType); ok {
+			d.types(dir, path+"[]", tx.Elt, ty.Elt)
+			return
+		}
+	case *ast.MapType:
+		if ty, ok := uy.(*ast.MapType); ok && typeString(d.old.info.Indirect(tx.Key)) == typeString(d.new.info.Indirect(ty.Key)) {
+			d.types(dir, path+"[]", tx.Value, ty.Value)
+			return
+		}
+	}
+	if typeString(ux) != typeString(uy) {
+		d.add(true, fmt.Sprintf("%s type changed from %s to %s", subject, typeString(x), typeString(y)),
+			d.old.pos(x.Pos()), d.new.pos(y.Pos()))
+	}
+}
+
+// fields compares the fields of the old struct type x, which is nil for
+// a request added to a route, with those of the new struct type y.
+func (d *differ) fields(dir direction, path string, x, y ast.Expr) {
+	var oldFields []*types.Field
+	if x != nil {
+		var err error
+		if oldFields, err = d.old.info.Fields(x); err != nil {
+			d.add(true, fmt.Sprintf("invalid old %s: %v", dir, err), d.old.pos(x.Pos()), "")
+			return
+		}
+	}
+	newFields, err := d.new.info.Fields(y)
+	if err != nil {
+		d.add(true, fmt.Sprintf("invalid new %s: %v", dir, err), "", d.new.pos(y.Pos()))
+		return
+	}
+
+	byKey := make(map[string]*types.Field)
+	byName := make(map[string]*types.Field)
+	for _, f := range newFields {
+		if k := key(f); k != "" && byKey[k] == nil {
+			byKey[k] = f
+		}
+		byName[f.Name] = f
+	}
+	matched := make(map[*types.Field]bool)
+	for _, f := range oldFields {
+		k := key(f)
+		if k == "" {
+			continue
+		}
+		oldPos := d.old.pos(f.Field.Pos())
+		g := byKey[k]
+		if g == nil || matched[g] {
+			g = byName[f.Name]
+			if g == nil || matched[g] || key(g) == "" || byKey[key(g)] != g {
+				g = nil
+			}
+		}
+		if g == nil {
+			d.add(dir == response, fmt.Sprintf("%s field %s removed", dir, join(path, k)), oldPos, "")
+			continue
+		}
+		matched[g] = true
+		newPos := d.new.pos(g.Field.Pos())
+		name := join(path, key(g))
+		if key(g) != k {
+			d.add(true, fmt.Sprintf("%s field %s renamed to %s", dir, join(path, k), key(g)), oldPos, newPos)
+		}
+		switch opt, nopt := f.Tags.Optional(), g.Tags.Optional(); {
+		case opt && !nopt:
+			d.add(dir == request, fmt.Sprintf("%s field %s now required", dir, name), oldPos, newPos)
+		case !opt && nopt:
+			d.add(dir == response, fmt.Sprintf("%s field %s now optional", dir, name), oldPos, newPos)
+		}
+		d.types(dir, name, f.Type, g.Type)
+	}
+	for _, g := range newFields {
+		if matched[g] || key(g) == "" {
+			continue
+		}
+		name := join(path, key(g))
+		if dir == request && !g.Tags.Optional() {
+			d.add(true, fmt.Sprintf("required request field %s added", name), "", d.new.pos(g.Field.Pos()))
+		} else {
+			d.add(false, fmt.Sprintf("%s field %s added", dir, name), "", d.new.pos(g.Field.Pos()))
+		}
+	}
+}
+
+// key returns the name of field f in its encoding, qualified by the part
+// of the request it is bound to unless that is the body, or "" if f is
+// not encoded or is a path parameter, which is compared as part of the
+// path.
+func key(f *types.Field) string {
+	for _, k := range []string{tag.JSON, tag.Form, tag.Path, tag.Header} {
+		if t := f.Tags.Get(k); t != nil {
+			name := t.Name
+			switch {
+			case name == "-" || k == tag.Path:
+				return ""
+			case name == "":
+				name = f.Name
+			}
+			if k != tag.JSON {
+				name = k + ":" + name
+			}
+			return name
+		}
+	}
+	return f.Name
+}
+
+func join(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func (d *differ) add(breaking bool, msg, oldPos, newPos string) {
+	d.report.Changes = append(d.report.Changes, &Change{
+		Breaking: breaking,
+		Route:    d.route,
+		Message:  msg,
+		Old:      oldPos,
+		New:      newPos,
+	})
+}
+
+// pos returns the position pos of v as a string, or "" if it is unknown.
+func (v *version) pos(pos token.Pos) string {
+	if !pos.IsValid() {
+		return ""
+	}
+	return v.fset.Position(pos).String()
+}
+
+// typeString returns the source form of the type x.
+func typeString(x ast.Expr) string {
+	switch t := x.(type) {
+	case *ast.Ident:
+		return t.Name
+	case *ast.SelectorExpr:
+		return typeString(t.X) + "." + t.Sel.Name
+	case *ast.StarExpr:
+		return "*" + typeString(t.X)
+	case *ast.ParenExpr:
+		return typeString(t.X)
+	case *ast.ArrayType:
+		return "[]" + typeString(t.Elt)
+	case *ast.MapType:
+		return "map[" + typeString(t.Key) + "]" + typeString(t.Value)
+	case *ast.StructType:
+		return "struct{...}"
+	}
+	return fmt.Sprintf("%T", x)
+}
@@ -0,0 +1,76 @@
+package apidiff_test
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/zeromicro/api-ast/apidiff"
+	"github.com/zeromicro/api-ast/ast"
+	"github.com/zeromicro/api-ast/loader"
+	"github.com/zeromicro/api-ast/parser"
+	"github.com/zeromicro/api-ast/token"
+)
+
+func parse(filename, src string) *loader.API {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, filename, src, 0)
+	if err != nil {
+		panic(err)
+	}
+	return &loader.API{Fset: fset, Files: []*ast.File{f}, Filenames: []string{filename}}
+}
+
+func ExampleDiff() {
+	v1 := parse("old.api", `
+type LoginReq {
+	Name     string `+"`json:\"name\"`"+`
+	Password string `+"`json:\"password\"`"+`
+}
+
+type LoginReply {
+	Token  string `+"`json:\"token\"`"+`
+	Expire int64  `+"`json:\"expire\"`"+`
+}
+
+service user-api {
+	@handler login
+	post /login (LoginReq) returns (LoginReply)
+
+	@handler logout
+	post /logout
+}
+`)
+	v2 := parse("new.api", `
+type LoginReq {
+	Name     string `+"`json:\"name\"`"+`
+	Password string `+"`json:\"password\"`"+`
+	Code     string `+"`json:\"code,optional\"`"+`
+}
+
+type LoginReply {
+	Token  string `+"`json:\"accessToken\"`"+`
+	Expire string `+"`json:\"expire\"`"+`
+}
+
+service user-api {
+	@handler login
+	post /user/login (LoginReq) returns (LoginReply)
+}
+`)
+
+	report, err := apidiff.Diff(v1, v2)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	report.WriteText(os.Stdout)
+	fmt.Println("breaking:", report.Breaking())
+
+	// Output:
+	// new.api:15:2: breaking: POST /login: path changed to /user/login
+	// new.api:5:2: compatible: POST /login: request field code added
+	// new.api:9:2: breaking: POST /login: response field token renamed to accessToken
+	// new.api:10:9: breaking: POST /login: response field expire type changed from int64 to string
+	// old.api:17:2: breaking: POST /logout: route removed
+	// breaking: true
+}
@@ -1,8 +1,11 @@
 package ast
 
 import (
-	"github.com/zeromicro/api-ast/token"
 	"go/ast"
+	"strconv"
+	"strings"
+
+	"github.com/zeromicro/api-ast/token"
 )
 
 type (
@@ -24,14 +27,20 @@ type (
 
 type (
 	SyntaxSpec struct {
-		TokPos token.Pos
-		Assign token.Pos // position of '='
-		Name   *BasicLit
+		TokPos   token.Pos
+		Assign   token.Pos // position of '='
+		Name     *BasicLit
+		Implicit bool // set if the source has no syntax declaration; Name is the default version
 	}
 )
 
 func (x *SyntaxSpec) Pos() token.Pos { return x.TokPos }
-func (x *SyntaxSpec) End() token.Pos { return x.Name.End() }
+func (x *SyntaxSpec) End() token.Pos {
+	if x.Implicit {
+		return token.NoPos // see parser.parseFile
+	}
+	return x.Name.End()
+}
 
 // ----------------------------------------------------------------------------
 // Comment and CommentGroup
@@ -109,9 +118,13 @@ func (x *StarExpr) End() token.Pos { return x.X.End() }
 func (x *StarExpr) exprNode()      {}
 
 func (x *ParenExpr) Pos() token.Pos { return x.Lparen }
-func (x *ParenExpr) End() token.Pos { return x.Rparen }
+func (x *ParenExpr) End() token.Pos { return x.Rparen + 1 }
 func (x *ParenExpr) exprNode()      {}
 
+// NewIdent creates a new Ident without position.
+// Useful for ASTs generated by code other than the parser.
+func NewIdent(name string) *Ident { return &Ident{token.NoPos, name} }
+
 // ----------------------------------------------------------------------------
 // Declarations
 
@@ -142,11 +155,16 @@ type (
 )
 
 func (x *ImportSpec) Pos() token.Pos { return x.Path.Pos() }
-func (x *ImportSpec) End() token.Pos { return x.EndPos }
+func (x *ImportSpec) End() token.Pos {
+	if x.EndPos != 0 {
+		return x.EndPos
+	}
+	return x.Path.End()
+}
 func (x *ImportSpec) specNode()      {}
 
 func (x *TypeSpec) Pos() token.Pos { return x.Name.Pos() }
-func (x *TypeSpec) End() token.Pos { return x.Type.Pos() }
+func (x *TypeSpec) End() token.Pos { return x.Type.End() }
 func (x *TypeSpec) specNode()      {}
 
 type (
@@ -321,7 +339,13 @@ type (
 	KeyValueExpr struct {
 		Key   *Ident
 		Colon token.Pos // position of ":"
-		Value Expr      // *BasicLit for info or *Ident for server
+		Value Expr      // *BasicLit for info, *Ident or *IdentList for server
+	}
+
+	// An IdentList node represents a comma-separated list of names, such
+	// as the value of "middleware: Auth, Log".
+	IdentList struct {
+		List []*Ident // at least two names
 	}
 
 	InfoType struct {
@@ -332,13 +356,56 @@ type (
 )
 
 func (x *InfoType) Pos() token.Pos { return x.TokPos }
-func (x *InfoType) End() token.Pos { return x.RParen }
+func (x *InfoType) End() token.Pos { return x.RParen + 1 }
 func (x *InfoType) declNode()      {}
 
+func (x *IdentList) Pos() token.Pos { return x.List[0].Pos() }
+func (x *IdentList) End() token.Pos { return x.List[len(x.List)-1].End() }
+func (x *IdentList) exprNode()      {}
+
 func (x *KeyValueExpr) Pos() token.Pos { return x.Key.Pos() }
 func (x *KeyValueExpr) End() token.Pos { return x.Value.End() }
 func (x *KeyValueExpr) exprNode()      {}
 
+// Text returns the value of x as plain text: the name of an identifier,
+// the names of a list separated by commas, such as "Auth,Log", or the
+// unquoted value of a string literal. It returns "" if x is nil.
+func (x *KeyValueExpr) Text() string {
+	if x == nil {
+		return ""
+	}
+	switch v := x.Value.(type) {
+	case *Ident:
+		return v.Name
+	case *IdentList:
+		names := make([]string, len(v.List))
+		for i, id := range v.List {
+			names[i] = id.Name
+		}
+		return strings.Join(names, ",")
+	case *BasicLit:
+		if s, err := strconv.Unquote(v.Value); err == nil {
+			return s
+		}
+		return v.Value
+	}
+	return ""
+}
+
+// lookup returns the text of the value for key in kvs, or "".
+func lookup(kvs []*KeyValueExpr, key string) string {
+	for _, kv := range kvs {
+		if kv.Key.Name == key {
+			return kv.Text()
+		}
+	}
+	return ""
+}
+
+// Value returns the text of the value for key, such as "author",
+// or "" if there is none.
+func (x *InfoType) Value(key string) string { return lookup(x.Kvs, key) }
+
 // Server
 type (
 	Service struct {
@@ -381,7 +448,7 @@ type (
 		Req       *ParenExpr
 		ReturnPos token.Pos
 		Resp      *ParenExpr
-		RPos      token.Pos // because Resp Req is optional, need this for EndPos
+		RPos      token.Pos // position immediately after the route; Req and Resp are optional, so End needs it
 	}
 )
 
@@ -395,10 +462,19 @@ func (x *Service) End() token.Pos { return x.ServiceApi.End() }
 func (x *Service) declNode()      {}
 
 func (x *AtServer) Pos() token.Pos { return x.TokPos }
-func (x *AtServer) End() token.Pos { return x.RParen }
+func (x *AtServer) End() token.Pos { return x.RParen + 1 }
+
+// Value returns the text of the value for key, such as "group" or
+// "jwt", or "" if there is none. A nil AtServer has no values.
+func (x *AtServer) Value(key string) string {
+	if x == nil {
+		return ""
+	}
+	return lookup(x.Kvs, key)
+}
 
 func (x *ServiceApi) Pos() token.Pos { return x.TokPos }
-func (x *ServiceApi) End() token.Pos { return x.RBrace }
+func (x *ServiceApi) End() token.Pos { return x.RBrace + 1 }
 
 func (x *ServiceRoute) Pos() token.Pos { return x.TokPos }
 func (x *ServiceRoute) End() token.Pos { return x.Route.End() }
@@ -448,3 +524,94 @@ func (f *File) End() token.Pos {
 	}
 	return f.Syntax.End()
 }
+
+// TypeSpecs returns the type declarations of f, in source order.
+func (f *File) TypeSpecs() []*TypeSpec {
+	var list []*TypeSpec
+	for _, d := range f.Decls {
+		if d, ok := d.(*GenDecl); ok && d.Tok == token.TYPE {
+			for _, s := range d.Specs {
+				if s, ok := s.(*TypeSpec); ok {
+					list = append(list, s)
+				}
+			}
+		}
+	}
+	return list
+}
+
+// Services returns the service declarations of f, in source order.
+func (f *File) Services() []*Service {
+	var list []*Service
+	for _, d := range f.Decls {
+		if d, ok := d.(*Service); ok {
+			list = append(list, d)
+		}
+	}
+	return list
+}
+
+// TypeRefs calls fn for every identifier of f that refers to a type:
+// in type declarations and in the requests and responses of routes.
+func (f *File) TypeRefs(fn func(*Ident)) {
+	for _, s := range f.TypeSpecs() {
+		TypeRefs(s.Type, fn)
+	}
+	for _, svc := range f.Services() {
+		for _, sr := range svc.ServiceApi.ServiceRoute {
+			if rt := sr.Route; rt != nil {
+				if rt.Req != nil {
+					TypeRefs(rt.Req, fn)
+				}
+				if rt.Resp != nil {
+					TypeRefs(rt.Resp, fn)
+				}
+			}
+		}
+	}
+}
+
+// TypeRefs calls fn for every type name in the type expression x,
+// including those in the fields of inline structs.
+func TypeRefs(x Expr, fn func(*Ident)) {
+	switch t := x.(type) {
+	case *Ident:
+		fn(t)
+	case *StarExpr:
+		TypeRefs(t.X, fn)
+	case *ParenExpr:
+		TypeRefs(t.X, fn)
+	case *ArrayType:
+		TypeRefs(t.Elt, fn)
+	case *MapType:
+		TypeRefs(t.Key, fn)
+		TypeRefs(t.Value, fn)
+	case *StructType:
+		if t.Fields != nil {
+			for _, f := range t.Fields.List {
+				TypeRefs(f.Type, fn)
+			}
+		}
+	}
+}
+
+// InlineStruct returns the struct type literal x, or the one its
+// elements have if x is a pointer, slice or map type; or nil.
+func InlineStruct(x Expr) *StructType {
+	for {
+		switch t := x.(type) {
+		case *StructType:
+			return t
+		case *StarExpr:
+			x = t.X
+		case *ParenExpr:
+			x = t.X
+		case *ArrayType:
+			x = t.Elt
+		case *MapType:
+			x = t.Value
+		default:
+			return nil
+		}
+	}
+}
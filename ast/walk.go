@@ -2,27 +2,214 @@ package ast
 
 import "fmt"
 
+// A Visitor's Visit method is invoked for each node encountered by Walk.
+// If the result visitor w is not nil, Walk visits each of the children
+// of node with the visitor w, followed by a call of w.Visit(nil).
 type Visitor interface {
 	Visit(Node) Visitor
 }
 
+// Helper functions for common node lists. They may be empty.
+
+func walkIdentList(v Visitor, list []*Ident) {
+	for _, x := range list {
+		Walk(v, x)
+	}
+}
+
+func walkKeyValueList(v Visitor, list []*KeyValueExpr) {
+	for _, x := range list {
+		Walk(v, x)
+	}
+}
+
+// Walk traverses an AST in depth-first order: It starts by calling
+// v.Visit(node); node must not be nil. If the visitor w returned by
+// v.Visit(node) is not nil, Walk is invoked recursively with visitor
+// w for each of the non-nil children of node, followed by a call of
+// w.Visit(nil).
 func Walk(v Visitor, node Node) {
 	if v = v.Visit(node); v == nil {
 		return
 	}
+
+	// walk children
+	// (the order of the cases matches the order
+	// of the corresponding node types in ast.go)
 	switch n := node.(type) {
+	case *SyntaxSpec:
+		if n.Name != nil {
+			Walk(v, n.Name)
+		}
+
+	// Comments and fields
+	case *Comment:
+		// nothing to do
+
+	case *CommentGroup:
+		for _, c := range n.List {
+			Walk(v, c)
+		}
+
+	case *Field:
+		if n.Doc != nil {
+			Walk(v, n.Doc)
+		}
+		walkIdentList(v, n.Names)
+		if n.Type != nil {
+			Walk(v, n.Type)
+		}
+		if n.Tag != nil {
+			Walk(v, n.Tag)
+		}
+		if n.Comment != nil {
+			Walk(v, n.Comment)
+		}
+
+	case *FieldList:
+		for _, f := range n.List {
+			Walk(v, f)
+		}
+
+	// Expressions
+	case *BadExpr, *Ident, *BasicLit:
+		// nothing to do
+
+	case *SelectorExpr:
+		Walk(v, n.X)
+		Walk(v, n.Sel)
+
+	case *StarExpr:
+		Walk(v, n.X)
+
+	case *ParenExpr:
+		Walk(v, n.X)
+
+	case *IdentList:
+		walkIdentList(v, n.List)
+
+	case *KeyValueExpr:
+		Walk(v, n.Key)
+		Walk(v, n.Value)
+
+	// Types
+	case *ArrayType:
+		if n.Len != nil {
+			Walk(v, n.Len)
+		}
+		Walk(v, n.Elt)
+
+	case *StructType:
+		Walk(v, n.Fields)
+
+	case *MapType:
+		Walk(v, n.Key)
+		Walk(v, n.Value)
+
+	// Declarations
+	case *ImportSpec:
+		if n.Doc != nil {
+			Walk(v, n.Doc)
+		}
+		Walk(v, n.Path)
+		if n.Comment != nil {
+			Walk(v, n.Comment)
+		}
+
+	case *TypeSpec:
+		if n.Doc != nil {
+			Walk(v, n.Doc)
+		}
+		Walk(v, n.Name)
+		Walk(v, n.Type)
+		if n.Comment != nil {
+			Walk(v, n.Comment)
+		}
+
+	case *BadDecl:
+		// nothing to do
+
+	case *GenDecl:
+		if n.Doc != nil {
+			Walk(v, n.Doc)
+		}
+		for _, s := range n.Specs {
+			Walk(v, s)
+		}
+
+	// API declarations
+	case *InfoType:
+		walkKeyValueList(v, n.Kvs)
+
+	case *Service:
+		if n.AtServer != nil {
+			Walk(v, n.AtServer)
+		}
+		Walk(v, n.ServiceApi)
+
+	case *AtServer:
+		walkKeyValueList(v, n.Kvs)
+
+	case *ServiceApi:
+		Walk(v, n.Name)
+		for _, r := range n.ServiceRoute {
+			Walk(v, r)
+		}
+
+	case *ServiceRoute:
+		if n.AtDoc != nil {
+			Walk(v, n.AtDoc)
+		}
+		if n.AtHandler != nil {
+			Walk(v, n.AtHandler)
+		}
+		Walk(v, n.Route)
+
+	case *Route:
+		Walk(v, n.Method)
+		Walk(v, n.Path)
+		if n.Req != nil {
+			Walk(v, n.Req)
+		}
+		if n.Resp != nil {
+			Walk(v, n.Resp)
+		}
+
+	// Files
 	case *File:
 		if n.Doc != nil {
 			Walk(v, n.Doc)
 		}
-		//Walk(v, n.Syntax)
+		if n.Syntax != nil && !n.Syntax.Implicit {
+			Walk(v, n.Syntax)
+		}
 		for _, x := range n.Decls {
 			Walk(v, x)
 		}
-	// TODO: finish me
+		// don't walk n.Comments - they have been
+		// visited already through the individual
+		// nodes
+
 	default:
 		panic(fmt.Sprintf("ast.Walk: unexpected node type %T", n))
 	}
 
 	v.Visit(nil)
 }
+
+type inspector func(Node) bool
+
+func (f inspector) Visit(node Node) Visitor {
+	if f(node) {
+		return f
+	}
+	return nil
+}
+
+// Inspect traverses an AST in depth-first order: It starts by calling
+// f(node); node must not be nil. If f returns true, Inspect invokes f
+// recursively for each of the non-nil children of node, followed by a
+// call of f(nil).
+func Inspect(node Node, f func(Node) bool) {
+	Walk(inspector(f), node)
+}
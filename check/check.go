@@ -0,0 +1,97 @@
+// Package check reports the semantic errors of api files that the parser
+// cannot find: type names declared twice or not at all, and handlers
+// and routes declared twice.
+//
+// The files are checked together, as the files of one api: a root file
+// and the files it imports, directly or indirectly. Of the declarations
+// of a name, the first one in the order of the files is taken as the
+// declaration and the others are reported, with a related span at the
+// first one. An undeclared type name is reported with the closest
+// declared or predeclared names as suggested fixes.
+package check
+
+import (
+	"fmt"
+
+	"github.com/zeromicro/api-ast/ast"
+	"github.com/zeromicro/api-ast/diag"
+	"github.com/zeromicro/api-ast/token"
+	"github.com/zeromicro/api-ast/types"
+)
+
+// Files checks the files, whose positions are recorded in fset, and
+// returns the diagnostics sorted by position.
+func Files(fset *token.FileSet, files []*ast.File) diag.List {
+	c := &checker{fset: fset, decls: make(map[string]*ast.TypeSpec)}
+	for _, f := range files {
+		for _, s := range f.TypeSpecs() {
+			if d := c.decls[s.Name.Name]; d != nil {
+				c.error(diag.TypeRedeclared, s.Name.Pos(), s.Name.End(), d.Name,
+					"%s redeclared; other declaration at %s", s.Name.Name, fset.Position(d.Name.Pos()))
+				continue
+			}
+			c.decls[s.Name.Name] = s
+		}
+	}
+	var names []string // type names, declared ones first, for suggestions
+	for _, f := range files {
+		for _, s := range f.TypeSpecs() {
+			if c.decls[s.Name.Name] == s {
+				names = append(names, s.Name.Name)
+			}
+		}
+	}
+	names = append(names, types.PredeclaredNames()...)
+	for _, f := range files {
+		f.TypeRefs(func(id *ast.Ident) {
+			if !types.Predeclared(id.Name) && c.decls[id.Name] == nil && id.Name != "_" {
+				d := c.error(diag.UndeclaredType, id.Pos(), id.End(), nil, "undeclared type %s", id.Name)
+				if hint, fixes := diag.Suggest(d.Span, diag.Closest(id.Name, names)); hint != "" {
+					d.Message += "; " + hint
+					d.Fixes = fixes
+				}
+			}
+		})
+	}
+
+	handlers := make(map[string]ast.Node)
+	routes := make(map[string]ast.Node)
+	for _, r := range types.Routes(files...) {
+		if h := r.Decl.AtHandler; h != nil {
+			name := r.Service.ServiceApi.Name.Name + " " + r.Handler
+			if other, ok := handlers[name]; ok {
+				c.error(diag.DuplicateHandler, h.Value.Pos(), h.Value.End(), other,
+					"duplicate handler %s; other declaration at %s", r.Handler, fset.Position(other.Pos()))
+			} else {
+				handlers[name] = h.Value
+			}
+		}
+		rt := r.Decl.Route
+		if other, ok := routes[r.String()]; ok {
+			c.error(diag.DuplicateRoute, rt.Method.Pos(), rt.Path.End(), other,
+				"duplicate route %s; other declaration at %s", r, fset.Position(other.Pos()))
+		} else {
+			routes[r.String()] = rt.Method
+		}
+	}
+	c.diags.Sort()
+	return c.diags
+}
+
+type checker struct {
+	fset  *token.FileSet
+	decls map[string]*ast.TypeSpec // type declarations by name; the first one wins
+	diags diag.List
+}
+
+// error reports an error of the code at the source from pos to end and
+// returns it. If other is not nil, it is the node of the other
+// declaration.
+func (c *checker) error(code diag.Code, pos, end token.Pos, other ast.Node, format string, args ...interface{}) *diag.Diagnostic {
+	d := diag.New(code, diag.SpanOf(c.fset, pos, end), fmt.Sprintf(format, args...))
+	if other != nil {
+		d.Related = []diag.Related{{Span: diag.SpanOf(c.fset, other.Pos(), other.End()), Message: "other declaration"}}
+	}
+	c.diags.Add(d)
+	return d
+}
@@ -0,0 +1,54 @@
+package check_test
+
+import (
+	"fmt"
+	"testing/fstest"
+
+	"github.com/zeromicro/api-ast/check"
+	"github.com/zeromicro/api-ast/loader"
+	"github.com/zeromicro/api-ast/parser"
+)
+
+func ExampleFiles() {
+	fsys := fstest.MapFS{
+		"user.api": {Data: []byte(`syntax = "v1"
+
+import "team.api"
+
+type User {
+	Team Team
+	Owner Usr
+}
+
+service user-api {
+	@handler getUser
+	get /users/:id returns (User)
+	@handler getUser
+	get /users/:name returns (User)
+}
+`)},
+		"team.api": {Data: []byte(`syntax = "v1"
+
+type User {}
+type Team {}
+`)},
+	}
+	api, err := (&loader.Config{FS: fsys, Mode: parser.ParseComments}).Load("user.api")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	for _, d := range check.Files(api.Fset, api.Files) {
+		fmt.Println(d)
+		for _, r := range d.Related {
+			fmt.Printf("\t%s: %s\n", r.Span, r.Message)
+		}
+	}
+
+	// Output:
+	// team.api:3:6: error E0202: User redeclared; other declaration at user.api:5:6
+	// 	user.api:5:6: other declaration
+	// user.api:7:8: error E0203: undeclared type Usr; did you mean User?
+	// user.api:13:11: error E0204: duplicate handler getUser; other declaration at user.api:11:11
+	// 	user.api:11:11: other declaration
+}
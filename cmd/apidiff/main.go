@@ -0,0 +1,73 @@
+// Apidiff reports the changes between two versions of an api file and
+// whether they break existing clients.
+//
+// Usage:
+//
+//	apidiff [-json] [-breaking] old.api new.api
+//
+// Each file is loaded together with the files it imports. The exit
+// status is 1 if there are breaking changes, 2 if the files cannot be
+// loaded, and 0 otherwise, so that apidiff can serve as a release gate.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/zeromicro/api-ast/apidiff"
+	"github.com/zeromicro/api-ast/scanner"
+)
+
+var (
+	jsonFlag     = flag.Bool("json", false, "write the report as JSON")
+	breakingFlag = flag.Bool("breaking", false, "report breaking changes only")
+)
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: apidiff [flags] old.api new.api\n")
+	flag.PrintDefaults()
+	os.Exit(2)
+}
+
+func main() {
+	flag.Usage = usage
+	flag.Parse()
+	if flag.NArg() != 2 {
+		usage()
+	}
+
+	report, err := apidiff.DiffFiles(flag.Arg(0), flag.Arg(1))
+	if err != nil {
+		scanner.PrintError(os.Stderr, err)
+		os.Exit(2)
+	}
+	if *breakingFlag {
+		var list []*apidiff.Change
+		for _, c := range report.Changes {
+			if c.Breaking {
+				list = append(list, c)
+			}
+		}
+		report.Changes = list
+	}
+
+	if *jsonFlag {
+		if report.Changes == nil {
+			report.Changes = []*apidiff.Change{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(report)
+	} else {
+		err = report.WriteText(os.Stdout)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "apidiff: %v\n", err)
+		os.Exit(2)
+	}
+	if report.Breaking() {
+		os.Exit(1)
+	}
+}
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
@@ -14,6 +16,7 @@ import (
 	"strings"
 
 	"github.com/zeromicro/api-ast/cmd/apifmt/internal"
+	"github.com/zeromicro/api-ast/diag"
 	"github.com/zeromicro/api-ast/parser"
 	"github.com/zeromicro/api-ast/token"
 )
@@ -26,6 +29,8 @@ var (
 	simplifyAST = flag.Bool("s", false, "simple code")
 	doDiff      = flag.Bool("d", false, "display diffs instead of rewriting files")
 	allErrors   = flag.Bool("e", false, "report all errors (not just the first 10 on different lines)")
+	diagJSON    = flag.Bool("json", false, "report syntax errors and unformatted files as JSON diagnostics, one per line")
+	explain     = flag.String("explain", "", "print the documentation of the diagnostic `code`, such as E0102, and exit")
 
 	cpuprofile = flag.String("cpuprofile", "", "write cpu profile to this file")
 )
@@ -52,6 +57,16 @@ func apiftmMain(s *internal.Sequencer) {
 	flag.Usage = usage
 	flag.Parse()
 
+	if *explain != "" {
+		text := diag.Explain(diag.Code(strings.ToUpper(*explain)))
+		if text == "" {
+			s.AddReport(fmt.Errorf("unknown diagnostic code %q", *explain))
+			return
+		}
+		_, _ = os.Stdout.WriteString(text)
+		return
+	}
+
 	if *cpuprofile != "" {
 		f, err := os.Create(*cpuprofile)
 		if err != nil {
@@ -121,7 +136,7 @@ func usage() {
 
 func isApiFile(f fs.DirEntry) bool {
 	name := f.Name()
-	return !strings.HasPrefix(name, ".") && strings.HasSuffix(name, ".go") && !f.IsDir()
+	return !strings.HasPrefix(name, ".") && strings.HasSuffix(name, ".api") && !f.IsDir()
 }
 
 func initParserMode() {
@@ -195,8 +210,15 @@ func processFile(filename string, info fs.FileInfo, in io.Reader, r *internal.Re
 	}
 
 	fileSet := token.NewFileSet()
-	file, err := internal.Parse(fileSet, filename, src, parserMode)
+	var diags diag.List
+	file, err := internal.Parse(fileSet, filename, src, parserMode, diags.Add)
 	if err != nil {
+		if *diagJSON {
+			if err := writeDiagnostics(r, diags); err != nil {
+				return err
+			}
+			return fmt.Errorf("%s: %d syntax errors", filename, len(diags))
+		}
 		return err
 	}
 
@@ -205,4 +227,58 @@ func processFile(filename string, info fs.FileInfo, in io.Reader, r *internal.Re
 	if *simplifyAST {
 		internal.Simplify(file)
 	}
+
+	res, err := internal.Format(fileSet, file, src)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(src, res) {
+		if *diagJSON {
+			whole := diag.Span{Filename: filename, Start: diag.Position{Line: 1, Column: 1}, End: endPosition(src)}
+			d := diag.New(diag.NotFormatted, diag.Span{Filename: filename, Start: whole.Start, End: whole.Start}, "file is not formatted")
+			d.Fixes = []diag.Fix{{Message: "format the file", Edits: []diag.Edit{{Span: whole, NewText: string(res)}}}}
+			if err := writeDiagnostics(r, diag.List{d}); err != nil {
+				return err
+			}
+		}
+		if *list {
+			_, _ = fmt.Fprintln(r, filename)
+		}
+		if *doDiff {
+			_, _ = r.Write(internal.Diff(filename+".orig", src, filename, res))
+		}
+		if *write {
+			if info == nil {
+				return fmt.Errorf("-w should not have been allowed with stdin")
+			}
+			if err := os.WriteFile(filename, res, info.Mode().Perm()); err != nil {
+				return err
+			}
+		}
+	}
+	if !*list && !*write && !*doDiff && !*diagJSON {
+		_, err = r.Write(res)
+	}
+	return err
+}
+
+// writeDiagnostics writes the diagnostics to r as JSON, one per line.
+func writeDiagnostics(r *internal.Reporter, diags diag.List) error {
+	for _, d := range diags {
+		data, err := json.Marshal(d)
+		if err != nil {
+			return err
+		}
+		if _, err := r.Write(append(data, '\n')); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// endPosition returns the position of the end of src.
+func endPosition(src []byte) diag.Position {
+	line := 1 + bytes.Count(src, []byte("\n"))
+	col := len(src) - bytes.LastIndexByte(src, '\n')
+	return diag.Position{Offset: len(src), Line: line, Column: col}
 }
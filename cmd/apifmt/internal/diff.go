@@ -0,0 +1,173 @@
+package internal
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// diffContext is the number of unchanged lines around a hunk.
+const diffContext = 3
+
+// Diff returns a unified diff of old and new, with the file names
+// oldName and newName, or nil if they are equal.
+func Diff(oldName string, old []byte, newName string, new []byte) []byte {
+	if bytes.Equal(old, new) {
+		return nil
+	}
+	a, b := splitLines(old), splitLines(new)
+	ops := editScript(a, b)
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "diff %s %s\n--- %s\n+++ %s\n", oldName, newName, oldName, newName)
+	for i := 0; i < len(ops); {
+		// find the next change and the extent of its hunk: changes
+		// separated by at most 2*diffContext unchanged lines share one
+		for i < len(ops) && ops[i].kind == ' ' {
+			i++
+		}
+		if i == len(ops) {
+			break
+		}
+		start := i - diffContext
+		if start < 0 {
+			start = 0
+		}
+		end, same := i, 0
+		for end < len(ops) && same <= 2*diffContext {
+			if ops[end].kind == ' ' {
+				same++
+			} else {
+				same = 0
+			}
+			end++
+		}
+		end -= same
+		if end += diffContext; end > len(ops) {
+			end = len(ops)
+		}
+
+		var na, nb int
+		for _, op := range ops[start:end] {
+			if op.kind != '+' {
+				na++
+			}
+			if op.kind != '-' {
+				nb++
+			}
+		}
+		fmt.Fprintf(&buf, "@@ -%s +%s @@\n", hunkRange(ops[start].a, na), hunkRange(ops[start].b, nb))
+		for _, op := range ops[start:end] {
+			line := op.line
+			buf.WriteByte(op.kind)
+			buf.WriteString(line)
+			if len(line) == 0 || line[len(line)-1] != '\n' {
+				buf.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+		i = end
+	}
+	return buf.Bytes()
+}
+
+// hunkRange formats the start line and line count of a hunk; start is
+// the zero-based index of its first line.
+func hunkRange(start, n int) string {
+	if n == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	if n == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, n)
+}
+
+// splitLines splits src after each newline.
+func splitLines(src []byte) []string {
+	var lines []string
+	for len(src) > 0 {
+		i := bytes.IndexByte(src, '\n') + 1
+		if i == 0 {
+			i = len(src)
+		}
+		lines = append(lines, string(src[:i]))
+		src = src[i:]
+	}
+	return lines
+}
+
+// A diffOp is a line of an edit script: kept (' '), deleted ('-') or
+// inserted ('+'), at index a of the old and b of the new lines.
+type diffOp struct {
+	kind byte
+	line string
+	a, b int
+}
+
+// editScript returns a shortest edit script turning a into b, computed
+// with Myers' algorithm.
+func editScript(a, b []string) []diffOp {
+	n, m := len(a), len(b)
+	max := n + m
+	v := make([]int, 2*max+2)
+	var trace [][]int
+	for d := 0; d <= max; d++ {
+		// the moves of step d only read the diagonals -d..d of step d-1
+		trace = append(trace, append([]int(nil), v[max-d:max+d+1]...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || k != d && v[max+k-1] < v[max+k+1] {
+				x = v[max+k+1]
+			} else {
+				x = v[max+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x, y = x+1, y+1
+			}
+			v[max+k] = x
+			if x >= n && y >= m {
+				return backtrack(a, b, trace, d, max)
+			}
+		}
+	}
+	return nil
+}
+
+// backtrack walks the trace of editScript back from the end of a and b.
+func backtrack(a, b []string, trace [][]int, d, max int) []diffOp {
+	var ops []diffOp
+	x, y := len(a), len(b)
+	for ; d >= 0; d-- {
+		v := trace[d] // diagonals -d..d
+		k := x - y
+		var prev int
+		if k == -d || k != d && v[d+k-1] < v[d+k+1] {
+			prev = k + 1
+		} else {
+			prev = k - 1
+		}
+		px := 0
+		if d > 0 {
+			px = v[d+prev]
+		}
+		py := px - prev
+		for x > px && y > py {
+			x, y = x-1, y-1
+			ops = append(ops, diffOp{' ', a[x], x, y})
+		}
+		if d == 0 {
+			break
+		}
+		if x == px {
+			y--
+			ops = append(ops, diffOp{'+', b[y], x, y})
+		} else {
+			x--
+			ops = append(ops, diffOp{'-', a[x], x, y})
+		}
+	}
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+	return ops
+}
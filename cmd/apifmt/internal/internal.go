@@ -2,13 +2,22 @@ package internal
 
 import (
 	"bytes"
+
 	"github.com/zeromicro/api-ast/ast"
+	"github.com/zeromicro/api-ast/diag"
 	"github.com/zeromicro/api-ast/parser"
+	"github.com/zeromicro/api-ast/printer"
 	"github.com/zeromicro/api-ast/token"
 )
 
-func Parse(fSet *token.FileSet, filename string, src []byte, parserMode parser.Mode) (*ast.File, error) {
-	file, err := parser.ParseFile(fSet, filename, src, parserMode)
+// cfg is the printer configuration of apifmt.
+var cfg = printer.Config{Mode: printer.UseSpaces | printer.TabIndent, TabWidth: 8}
+
+// Parse parses src. The syntax errors are passed to diagnostics as
+// diagnostics if it is not nil.
+func Parse(fSet *token.FileSet, filename string, src []byte, parserMode parser.Mode, diagnostics func(d *diag.Diagnostic)) (*ast.File, error) {
+	pcfg := &parser.Config{Mode: parserMode, Diagnostics: diagnostics}
+	file, err := pcfg.ParseFile(fSet, filename, src)
 	if err != nil {
 		return nil, err
 	}
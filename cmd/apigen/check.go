@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/zeromicro/api-ast/check"
+	"github.com/zeromicro/api-ast/diag"
+	"github.com/zeromicro/api-ast/loader"
+	"github.com/zeromicro/api-ast/parser"
+)
+
+func runCheck(args []string) error {
+	fs := newFlagSet("check", "file.api")
+	explain := fs.String("explain", "", "print the documentation of the diagnostic `code`, such as E0102, and exit")
+	asJSON := fs.Bool("json", false, "print the diagnostics as JSON")
+	fix := fs.Bool("fix", false, "apply the suggested fixes to the files in place")
+	_ = fs.Parse(args)
+	if *explain != "" {
+		text := diag.Explain(diag.Code(strings.ToUpper(*explain)))
+		if text == "" {
+			return fmt.Errorf("unknown diagnostic code %q", *explain)
+		}
+		return writeOutput("", []byte(text))
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	var diags diag.List
+	cfg := &loader.Config{
+		Mode:        parser.ParseComments | parser.AllErrors,
+		Diagnostics: func(d *diag.Diagnostic) { diags.Add(d) },
+	}
+	api, _ := cfg.Load(fs.Arg(0))
+	diags = append(diags, check.Files(api.Fset, api.Files)...)
+	diags.Sort()
+
+	if *fix {
+		return applyFixes(diags)
+	}
+	if *asJSON {
+		if diags == nil {
+			diags = diag.List{}
+		}
+		data, err := json.MarshalIndent(diags, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := writeOutput("", append(data, '\n')); err != nil {
+			return err
+		}
+	} else {
+		var b strings.Builder
+		for _, d := range diags {
+			fmt.Fprintln(&b, d.Error())
+			for _, r := range d.Related {
+				fmt.Fprintf(&b, "\t%s: %s\n", r.Span, r.Message)
+			}
+			for _, f := range d.Fixes {
+				fmt.Fprintf(&b, "\tfix: %s\n", f.Message)
+			}
+		}
+		if err := writeOutput("", []byte(b.String())); err != nil {
+			return err
+		}
+	}
+	if diags.HasErrors() {
+		os.Exit(1)
+	}
+	return nil
+}
+
+// applyFixes applies the first fix of every diagnostic that has fixes.
+func applyFixes(diags diag.List) error {
+	var edits []diag.Edit
+	for _, d := range diags {
+		if len(d.Fixes) > 0 {
+			edits = append(edits, d.Fixes[0].Edits...)
+		}
+	}
+	return applyEdits(edits)
+}
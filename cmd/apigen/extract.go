@@ -0,0 +1,64 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/zeromicro/api-ast/extract"
+	"github.com/zeromicro/api-ast/loader"
+)
+
+// nameFlag is a repeatable flag of the form path=Name.
+type nameFlag map[string]string
+
+func (f nameFlag) String() string { return "" }
+
+func (f nameFlag) Set(s string) error {
+	path, name, err := splitRename(s)
+	if err != nil {
+		return err
+	}
+	f[path] = name
+	return nil
+}
+
+func runExtract(args []string) error {
+	fs := newFlagSet("extract", "file.api [path ...]")
+	names := make(nameFlag)
+	fs.Var(names, "name", "name the type of the struct at a path: `path=Name`; may be repeated")
+	write := fs.Bool("w", false, "write the changed files in place instead of listing the structs")
+	asJSON := fs.Bool("json", false, "list the structs and edits as JSON")
+	_ = fs.Parse(args)
+	if fs.NArg() < 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	api, err := loader.Load(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	cfg := &extract.Config{Names: names, Paths: fs.Args()[1:]}
+	res, err := cfg.Extract(api)
+	if err != nil {
+		return err
+	}
+
+	if !*write {
+		if *asJSON {
+			data, err := json.MarshalIndent(res, "", "  ")
+			if err != nil {
+				return err
+			}
+			return writeOutput("", append(data, '\n'))
+		}
+		var b strings.Builder
+		for _, s := range res.Structs {
+			fmt.Fprintf(&b, "%s: %s -> %s\n", s.Position, s.Path, s.Name)
+		}
+		return writeOutput("", []byte(b.String()))
+	}
+	return applyEdits(res.Edits)
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bytes"
+	goast "go/ast"
+	goparser "go/parser"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/zeromicro/api-ast/gostruct"
+	"github.com/zeromicro/api-ast/printer"
+	"github.com/zeromicro/api-ast/token"
+)
+
+func runFromGo(args []string) error {
+	fs := newFlagSet("fromgo", "file.go|dir ...")
+	typeNames := fs.String("type", "", "comma-separated type name `patterns`, such as \"User,*Req\"; default: all exported struct types")
+	output := fs.String("o", "", "output `file`; default: standard output")
+	_ = fs.Parse(args)
+	if fs.NArg() == 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	fset := token.NewFileSet()
+	var files []*goast.File
+	for _, arg := range fs.Args() {
+		filenames, err := goFiles(arg)
+		if err != nil {
+			return err
+		}
+		for _, filename := range filenames {
+			f, err := goparser.ParseFile(fset, filename, nil, goparser.ParseComments)
+			if err != nil {
+				return err
+			}
+			files = append(files, f)
+		}
+	}
+
+	var patterns []string
+	if *typeNames != "" {
+		patterns = strings.Split(*typeNames, ",")
+	}
+	f, err := gostruct.Convert(fset, files, patterns...)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	cfg := printer.Config{Mode: printer.UseSpaces | printer.TabIndent, TabWidth: 8}
+	if err := cfg.Fprint(&buf, token.NewFileSet(), f); err != nil {
+		return err
+	}
+	return writeOutput(*output, buf.Bytes())
+}
+
+// goFiles returns the named Go file, or the Go files of the named
+// directory except for tests.
+func goFiles(name string) ([]string, error) {
+	fi, err := os.Stat(name)
+	if err != nil {
+		return nil, err
+	}
+	if !fi.IsDir() {
+		return []string{name}, nil
+	}
+	entries, err := os.ReadDir(name)
+	if err != nil {
+		return nil, err
+	}
+	var list []string
+	for _, e := range entries {
+		if n := e.Name(); !e.IsDir() && strings.HasSuffix(n, ".go") && !strings.HasSuffix(n, "_test.go") {
+			list = append(list, filepath.Join(name, n))
+		}
+	}
+	sort.Strings(list)
+	return list, nil
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/zeromicro/api-ast/loader"
+	"github.com/zeromicro/api-ast/typegraph"
+)
+
+func runGraph(args []string) error {
+	fs := newFlagSet("graph", "file.api")
+	format := fs.String("format", "dot", "output `format`: dot or json")
+	unused := fs.Bool("unused", false, "report unused and recursive types instead of the graph")
+	output := fs.String("o", "", "output `file`; default: standard output")
+	_ = fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	api, err := loader.Load(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	g, err := typegraph.Build(api.Fset, api.Files...)
+	if err != nil {
+		return err
+	}
+	var data []byte
+	switch {
+	case *unused:
+		var buf bytes.Buffer
+		for _, t := range g.Unused() {
+			fmt.Fprintf(&buf, "%s: type %s is not used by any route\n", t.Position, t.Name)
+		}
+		byName := make(map[string]*typegraph.Type)
+		for _, t := range g.Types {
+			byName[t.Name] = t
+		}
+		for _, c := range g.Cycles {
+			fmt.Fprintf(&buf, "%s: recursive types: %s\n", byName[c[0]].Position, strings.Join(c, ", "))
+		}
+		data = buf.Bytes()
+	case *format == "dot":
+		data = g.DOT()
+	case *format == "json":
+		if data, err = g.JSON(); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown format %q", *format)
+	}
+	return writeOutput(*output, data)
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"os"
+	"path/filepath"
+
+	"github.com/zeromicro/api-ast/highlight"
+)
+
+func runHighlight(args []string) error {
+	fs := newFlagSet("highlight", "file.api")
+	format := fs.String("format", "ansi", "output `format`: ansi or html")
+	links := fs.Bool("links", false, "link type references to their declarations (html)")
+	page := fs.Bool("page", false, "write a complete HTML page with the default style sheet (html)")
+	output := fs.String("o", "", "output `file`; default: standard output")
+	_ = fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	filename := fs.Arg(0)
+	src, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	cfg := &highlight.Config{Links: *links}
+	var buf bytes.Buffer
+	switch *format {
+	case "ansi":
+		err = cfg.ANSI(&buf, filename, src)
+	case "html":
+		if *page {
+			fmt.Fprintf(&buf, "<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>%s</title>\n<style>\n%s</style>\n</head>\n<body>\n",
+				html.EscapeString(filepath.Base(filename)), highlight.CSS)
+		}
+		err = cfg.HTML(&buf, filename, src)
+		if *page {
+			buf.WriteString("</body>\n</html>\n")
+		}
+	default:
+		return fmt.Errorf("unknown format %q", *format)
+	}
+	if err != nil {
+		return err
+	}
+	return writeOutput(*output, buf.Bytes())
+}
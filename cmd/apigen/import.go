@@ -0,0 +1,35 @@
+package main
+
+import (
+	"bytes"
+	"os"
+
+	"github.com/zeromicro/api-ast/openapi"
+	"github.com/zeromicro/api-ast/printer"
+	"github.com/zeromicro/api-ast/token"
+)
+
+func runImport(args []string) error {
+	fs := newFlagSet("import", "spec.json")
+	output := fs.String("o", "", "output `file`; default: standard output")
+	_ = fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	data, err := os.ReadFile(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	f, err := openapi.Import(data)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	cfg := printer.Config{Mode: printer.UseSpaces | printer.TabIndent, TabWidth: 8}
+	if err := cfg.Fprint(&buf, token.NewFileSet(), f); err != nil {
+		return err
+	}
+	return writeOutput(*output, buf.Bytes())
+}
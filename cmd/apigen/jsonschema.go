@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/zeromicro/api-ast/jsonschema"
+	"github.com/zeromicro/api-ast/loader"
+)
+
+func runJSONSchema(args []string) error {
+	fs := newFlagSet("jsonschema", "file.api")
+	base := fs.String("base", "", "base `URI` of the $id of documents")
+	dir := fs.String("dir", "", "write a document per type to `directory` instead of a bundle")
+	output := fs.String("o", "", "output `file` of the bundle; default: standard output")
+	_ = fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	api, err := loader.Load(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	cfg := &jsonschema.Config{BaseURI: *base}
+	if *dir == "" {
+		doc, err := cfg.Bundle(api.Files...)
+		if err != nil {
+			return err
+		}
+		data, err := doc.JSON()
+		if err != nil {
+			return err
+		}
+		return writeOutput(*output, data)
+	}
+
+	docs, err := cfg.Documents(api.Files...)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(*dir, 0o755); err != nil {
+		return err
+	}
+	names := make([]string, 0, len(docs))
+	for name := range docs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		data, err := docs[name].JSON()
+		if err != nil {
+			return err
+		}
+		filename := filepath.Join(*dir, name+".json")
+		if err := os.WriteFile(filename, data, 0o644); err != nil {
+			return err
+		}
+		fmt.Printf("wrote %s\n", filename)
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+// Apigen generates code from api files.
+//
+// Usage:
+//
+//	apigen <command> [flags] [path ...]
+//
+// The commands are:
+//
+//	check       report the diagnostics of an api and explain their codes
+//	extract     extract inline struct types into declared types
+//	fromgo      convert Go struct types into api type declarations
+//	graph       export the type dependency graph and report unused types
+//	highlight   render an api file with syntax highlighting as HTML or ANSI text
+//	import      convert an OpenAPI 3 or Swagger 2 JSON document into an api file
+//	jsonschema  export JSON Schema documents of the types
+//	markdown    generate Markdown reference documentation
+//	openapi     export an OpenAPI 3 document
+//	proto       export Protocol Buffers messages and gRPC services
+//	rename      rename a type, handler or field in all files of an api
+//	routes      list the routes with their settings as a table, CSV or JSON
+//	server      generate a go-zero server skeleton
+//	ts          generate TypeScript types and client
+//
+// Use "apigen <command> -h" for the flags of a command.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/zeromicro/api-ast/diag"
+	"github.com/zeromicro/api-ast/scanner"
+)
+
+// A command is an apigen subcommand.
+type command struct {
+	short string                    // one-line description
+	run   func(args []string) error // runs the command with its arguments
+}
+
+var commands = map[string]*command{
+	"check":      {"report the diagnostics of an api and explain their codes", runCheck},
+	"extract":    {"extract inline struct types into declared types", runExtract},
+	"fromgo":     {"convert Go struct types into api type declarations", runFromGo},
+	"graph":      {"export the type dependency graph and report unused types", runGraph},
+	"highlight":  {"render an api file with syntax highlighting as HTML or ANSI text", runHighlight},
+	"import":     {"convert an OpenAPI 3 or Swagger 2 JSON document into an api file", runImport},
+	"jsonschema": {"export JSON Schema documents of the types", runJSONSchema},
+	"markdown":   {"generate Markdown reference documentation", runMarkdown},
+	"openapi":    {"export an OpenAPI 3 document", runOpenAPI},
+	"proto":      {"export Protocol Buffers messages and gRPC services", runProto},
+	"rename":     {"rename a type, handler or field in all files of an api", runRename},
+	"routes":     {"list the routes with their settings as a table, CSV or JSON", runRoutes},
+	"server":     {"generate a go-zero server skeleton", runServer},
+	"ts":         {"generate TypeScript types and client", runTypeScript},
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: apigen <command> [flags] [path ...]\n\ncommands:\n")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "\t%-12s%s\n", name, commands[name].short)
+	}
+	os.Exit(2)
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "apigen: unknown command %q\n", os.Args[1])
+		usage()
+	}
+	if err := cmd.run(os.Args[2:]); err != nil {
+		report(err)
+		os.Exit(1)
+	}
+}
+
+func report(err error) {
+	scanner.PrintError(os.Stderr, err)
+}
+
+// newFlagSet returns the flag set of the command name.
+func newFlagSet(name, args string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: apigen %s [flags] %s\n", name, args)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// writeOutput writes data to the named file, or to standard output if
+// filename is empty.
+func writeOutput(filename string, data []byte) error {
+	if filename == "" {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+	return os.WriteFile(filename, data, 0o644)
+}
+
+// applyEdits applies edits to their files. All edits are applied before
+// any file is written, so that a failure leaves the files unchanged.
+func applyEdits(edits []diag.Edit) error {
+	changed, err := diag.ApplyFiles(edits, os.ReadFile)
+	if err != nil {
+		return err
+	}
+	filenames := make([]string, 0, len(changed))
+	for filename := range changed {
+		filenames = append(filenames, filename)
+	}
+	sort.Strings(filenames)
+	for _, filename := range filenames {
+		if err := writeOutput(filename, changed[filename]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
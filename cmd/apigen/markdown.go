@@ -0,0 +1,30 @@
+package main
+
+import (
+	"os"
+
+	"github.com/zeromicro/api-ast/codegen/markdown"
+	"github.com/zeromicro/api-ast/loader"
+)
+
+func runMarkdown(args []string) error {
+	fs := newFlagSet("markdown", "file.api")
+	title := fs.String("title", "", "document `title`; default: the title of the info declaration")
+	output := fs.String("o", "", "output `file`; default: standard output")
+	_ = fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	api, err := loader.Load(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	cfg := &markdown.Config{Title: *title}
+	src, err := cfg.Generate(api.Files...)
+	if err != nil {
+		return err
+	}
+	return writeOutput(*output, src)
+}
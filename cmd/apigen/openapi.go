@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/zeromicro/api-ast/loader"
+	"github.com/zeromicro/api-ast/openapi"
+)
+
+func runOpenAPI(args []string) error {
+	fs := newFlagSet("openapi", "file.api")
+	format := fs.String("format", "yaml", "output `format`: json or yaml")
+	output := fs.String("o", "", "output `file`; default: standard output")
+	_ = fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	api, err := loader.Load(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	doc, err := openapi.Export(api.Files...)
+	if err != nil {
+		return err
+	}
+	var data []byte
+	switch *format {
+	case "json":
+		data, err = doc.JSON()
+	case "yaml":
+		data, err = doc.YAML()
+	default:
+		return fmt.Errorf("unknown format %q", *format)
+	}
+	if err != nil {
+		return err
+	}
+	return writeOutput(*output, data)
+}
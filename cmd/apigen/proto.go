@@ -0,0 +1,58 @@
+package main
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+
+	"github.com/zeromicro/api-ast/codegen/protobuf"
+	"github.com/zeromicro/api-ast/loader"
+)
+
+func runProto(args []string) error {
+	flags := newFlagSet("proto", "file.api")
+	pkg := flags.String("package", "", "proto `package`; default: derived from the service name")
+	goPkg := flags.String("go_package", "", "value of the go_package `option`")
+	lockFile := flags.String("lock", "", "lock `file` keeping field numbers stable across runs")
+	output := flags.String("o", "", "output `file`; default: standard output")
+	_ = flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	api, err := loader.Load(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	cfg := &protobuf.Config{Package: *pkg, GoPackage: *goPkg}
+	if *lockFile != "" {
+		data, err := os.ReadFile(*lockFile)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			cfg.Lock = new(protobuf.Lock)
+		case err != nil:
+			return err
+		default:
+			if cfg.Lock, err = protobuf.ReadLock(data); err != nil {
+				return err
+			}
+		}
+	}
+
+	src, err := cfg.Generate(api.Files...)
+	if err != nil {
+		return err
+	}
+	if err := writeOutput(*output, src); err != nil {
+		return err
+	}
+	if cfg.Lock != nil {
+		data, err := cfg.Lock.JSON()
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(*lockFile, data, 0o644)
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/zeromicro/api-ast/diag"
+	"github.com/zeromicro/api-ast/loader"
+	"github.com/zeromicro/api-ast/rename"
+)
+
+func runRename(args []string) error {
+	fs := newFlagSet("rename", "file.api")
+	typ := fs.String("type", "", "rename a type: `Old=New`")
+	handler := fs.String("handler", "", "rename a handler: `[service.]old=new`")
+	field := fs.String("field", "", "rename a struct field: `Type.Old=New`")
+	tagName := fs.String("tag", "", "with -field, also rename the json tag, or add one, to `name`")
+	write := fs.Bool("w", false, "write the renamed files in place instead of listing the edits")
+	asJSON := fs.Bool("json", false, "list the edits as JSON")
+	_ = fs.Parse(args)
+	n := 0
+	for _, s := range []string{*typ, *handler, *field} {
+		if s != "" {
+			n++
+		}
+	}
+	if fs.NArg() != 1 || n != 1 || *tagName != "" && *field == "" {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	api, err := loader.Load(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	old, new, err := splitRename(*typ + *handler + *field)
+	if err != nil {
+		return err
+	}
+	var edits []diag.Edit
+	switch {
+	case *typ != "":
+		edits, err = rename.Type(api, old, new)
+	case *handler != "":
+		service := ""
+		if i := strings.LastIndex(old, "."); i >= 0 {
+			service, old = old[:i], old[i+1:]
+		}
+		edits, err = rename.Handler(api, service, old, new)
+	case *field != "":
+		i := strings.Index(old, ".")
+		if i < 0 {
+			return fmt.Errorf("invalid field %q: want Type.Field", old)
+		}
+		edits, err = rename.Field(api, old[:i], old[i+1:], new, *tagName)
+	}
+	if err != nil {
+		return err
+	}
+
+	if !*write {
+		if *asJSON {
+			data, err := json.MarshalIndent(edits, "", "  ")
+			if err != nil {
+				return err
+			}
+			return writeOutput("", append(data, '\n'))
+		}
+		var b strings.Builder
+		for _, e := range edits {
+			fmt.Fprintln(&b, e)
+		}
+		return writeOutput("", []byte(b.String()))
+	}
+
+	return applyEdits(edits)
+}
+
+// splitRename splits a rename of the form "old=new".
+func splitRename(s string) (old, new string, err error) {
+	i := strings.Index(s, "=")
+	if i <= 0 || i == len(s)-1 {
+		return "", "", fmt.Errorf("invalid rename %q: want old=new", s)
+	}
+	return s[:i], s[i+1:], nil
+}
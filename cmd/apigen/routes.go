@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/zeromicro/api-ast/ast"
+	"github.com/zeromicro/api-ast/loader"
+	"github.com/zeromicro/api-ast/printer"
+	"github.com/zeromicro/api-ast/token"
+	"github.com/zeromicro/api-ast/types"
+)
+
+// A routeInfo is a row of the route table.
+type routeInfo struct {
+	Method     string   `json:"method"`
+	Path       string   `json:"path"` // full path, including the prefix
+	Service    string   `json:"service"`
+	Handler    string   `json:"handler"`
+	Group      string   `json:"group"`
+	Jwt        string   `json:"jwt"`
+	Middleware []string `json:"middleware"`
+	Request    string   `json:"request"`
+	Response   string   `json:"response"`
+	Position   string   `json:"position"`
+}
+
+func runRoutes(args []string) error {
+	fs := newFlagSet("routes", "file.api")
+	format := fs.String("format", "table", "output `format`: table, csv or json")
+	group := fs.String("group", "", "list only the routes of `group`; \"-\" for routes without group")
+	method := fs.String("method", "", "list only the routes with one of the comma-separated `methods`")
+	output := fs.String("o", "", "output `file`; default: standard output")
+	_ = fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	api, err := loader.Load(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	methods := make(map[string]bool)
+	for _, m := range strings.Split(*method, ",") {
+		if m = strings.TrimSpace(m); m != "" {
+			methods[strings.ToUpper(m)] = true
+		}
+	}
+	wantGroup := *group
+	if wantGroup == "-" {
+		wantGroup = ""
+	}
+	var routes []*routeInfo
+	for _, r := range routeTable(api) {
+		if *group != "" && r.Group != wantGroup {
+			continue
+		}
+		if len(methods) > 0 && !methods[r.Method] {
+			continue
+		}
+		routes = append(routes, r)
+	}
+
+	var buf bytes.Buffer
+	switch *format {
+	case "table":
+		tw := tabwriter.NewWriter(&buf, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(tw, "METHOD\tPATH\tHANDLER\tGROUP\tJWT\tMIDDLEWARE\tREQUEST\tRESPONSE\tPOSITION")
+		for _, r := range routes {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", r.Method, r.Path, dash(r.Handler), dash(r.Group),
+				dash(r.Jwt), dash(strings.Join(r.Middleware, ",")), dash(r.Request), dash(r.Response), r.Position)
+		}
+		tw.Flush()
+	case "csv":
+		w := csv.NewWriter(&buf)
+		w.Write([]string{"method", "path", "service", "handler", "group", "jwt", "middleware", "request", "response", "position"})
+		for _, r := range routes {
+			w.Write([]string{r.Method, r.Path, r.Service, r.Handler, r.Group, r.Jwt,
+				strings.Join(r.Middleware, ","), r.Request, r.Response, r.Position})
+		}
+		w.Flush()
+	case "json":
+		if routes == nil {
+			routes = []*routeInfo{}
+		}
+		data, err := json.MarshalIndent(routes, "", "  ")
+		if err != nil {
+			return err
+		}
+		buf.Write(append(data, '\n'))
+	default:
+		return fmt.Errorf("unknown format %q", *format)
+	}
+	return writeOutput(*output, buf.Bytes())
+}
+
+// routeTable returns the routes of api in declaration order.
+func routeTable(api *loader.API) []*routeInfo {
+	var list []*routeInfo
+	for _, rt := range types.Routes(api.Files...) {
+		at := rt.Service.AtServer
+		r := &routeInfo{
+			Method:     rt.Method,
+			Path:       rt.Path,
+			Service:    rt.Service.ServiceApi.Name.Name,
+			Handler:    rt.Handler,
+			Group:      at.Value("group"),
+			Jwt:        at.Value("jwt"),
+			Middleware: []string{},
+			Position:   api.Fset.Position(rt.Decl.Route.Method.Pos()).String(),
+		}
+		for _, m := range strings.Split(at.Value("middleware"), ",") {
+			if m = strings.TrimSpace(m); m != "" {
+				r.Middleware = append(r.Middleware, m)
+			}
+		}
+		if rt.Req != nil {
+			r.Request = exprString(api.Fset, rt.Req)
+		}
+		if rt.Resp != nil {
+			r.Response = exprString(api.Fset, rt.Resp)
+		}
+		list = append(list, r)
+	}
+	return list
+}
+
+// exprString returns the source form of the type x.
+func exprString(fset *token.FileSet, x ast.Expr) string {
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, fset, x); err != nil {
+		return fmt.Sprintf("%T", x)
+	}
+	return buf.String()
+}
+
+// dash returns s, or "-" if s is empty.
+func dash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/zeromicro/api-ast/codegen"
+	"github.com/zeromicro/api-ast/loader"
+)
+
+func runServer(args []string) error {
+	fs := newFlagSet("server", "file.api")
+	dir := fs.String("dir", ".", "project root `directory`")
+	module := fs.String("module", "", "import `path` of the project root (required)")
+	_ = fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	api, err := loader.Load(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	out, err := (&codegen.Config{Module: *module}).Server(api.Files...)
+	if err != nil {
+		return err
+	}
+	written, skipped, err := codegen.WriteFiles(*dir, out)
+	for _, name := range written {
+		fmt.Printf("wrote %s\n", name)
+	}
+	for _, name := range skipped {
+		fmt.Printf("skipped %s: edited since generated\n", name)
+	}
+	return err
+}
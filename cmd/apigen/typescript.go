@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/zeromicro/api-ast/codegen/typescript"
+	"github.com/zeromicro/api-ast/loader"
+)
+
+func runTypeScript(args []string) error {
+	fs := newFlagSet("ts", "file.api")
+	dir := fs.String("dir", ".", "output `directory` for types.ts and client.ts")
+	_ = fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	api, err := loader.Load(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	types, err := typescript.Types(api.Files...)
+	if err != nil {
+		return err
+	}
+	client, err := typescript.Client(api.Files...)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(*dir, 0o755); err != nil {
+		return err
+	}
+	for _, out := range []struct {
+		name string
+		src  []byte
+	}{{"types.ts", types}, {"client.ts", client}} {
+		filename := filepath.Join(*dir, out.name)
+		if err := os.WriteFile(filename, out.src, 0o644); err != nil {
+			return err
+		}
+		fmt.Printf("wrote %s\n", filename)
+	}
+	return nil
+}
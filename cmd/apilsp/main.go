@@ -0,0 +1,45 @@
+// Apilsp is a language server for api files. It speaks the Language
+// Server Protocol over standard input and output; see package lsp for
+// the features it provides.
+//
+// Usage:
+//
+//	apilsp [-log file]
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/zeromicro/api-ast/lsp"
+)
+
+var logFile = flag.String("log", "", "write failed requests to `file`; default: standard error")
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: apilsp [-log file]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	var log io.Writer = os.Stderr
+	if *logFile != "" {
+		f, err := os.OpenFile(*logFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "apilsp: %v\n", err)
+			os.Exit(1)
+		}
+		defer f.Close()
+		log = f
+	}
+
+	s := lsp.NewServer()
+	s.Log = log
+	if err := s.Serve(os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintf(log, "apilsp: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,88 @@
+// Apimock serves mock responses for the routes of an api file, so that
+// clients can be developed before the server exists.
+//
+// Usage:
+//
+//	apimock [-addr host:port] [-fixtures dir] [-validate] file.api
+//
+// The file is loaded together with the files it imports. Every route
+// answers with an example of its response type; the JSON files in the
+// fixtures directory override the examples, see package mock. With
+// -validate, requests that do not match their request type are answered
+// with 400 Bad Request.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/zeromicro/api-ast/loader"
+	"github.com/zeromicro/api-ast/mock"
+	"github.com/zeromicro/api-ast/scanner"
+	"github.com/zeromicro/api-ast/types"
+)
+
+var (
+	addr     = flag.String("addr", "localhost:8888", "listen `address`")
+	fixtures = flag.String("fixtures", "", "`directory` of JSON fixtures overriding the examples")
+	validate = flag.Bool("validate", false, "reject requests that do not match their request type")
+)
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: apimock [flags] file.api\n")
+	flag.PrintDefaults()
+	os.Exit(2)
+}
+
+func main() {
+	flag.Usage = usage
+	flag.Parse()
+	if flag.NArg() != 1 {
+		usage()
+	}
+	log.SetFlags(0)
+	log.SetPrefix("apimock: ")
+
+	api, err := loader.Load(flag.Arg(0))
+	if err != nil {
+		scanner.PrintError(os.Stderr, err)
+		os.Exit(1)
+	}
+	cfg := &mock.Config{Validate: *validate}
+	if *fixtures != "" {
+		cfg.Fixtures = os.DirFS(*fixtures)
+	}
+	h, err := cfg.NewHandler(api.Files...)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for _, r := range types.Routes(api.Files...) {
+		log.Printf("%-7s http://%s%s", r.Method, *addr, r.Path)
+	}
+	log.Fatal(http.ListenAndServe(*addr, logRequests(h)))
+}
+
+// logRequests logs every request handled by h with its status.
+func logRequests(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		h.ServeHTTP(sw, r)
+		log.Printf("%s %s %d %v", r.Method, r.URL.RequestURI(), sw.status, time.Since(start).Round(time.Microsecond))
+	})
+}
+
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
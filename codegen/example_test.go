@@ -0,0 +1,125 @@
+package codegen_test
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/zeromicro/api-ast/codegen"
+	"github.com/zeromicro/api-ast/parser"
+	"github.com/zeromicro/api-ast/token"
+)
+
+func ExampleTypes() {
+	fset := token.NewFileSet() // positions are relative to fset
+
+	src := `syntax = "v1"
+
+// Base holds common fields.
+type Base {
+	Id int64 ` + "`json:\"id\"`" + `
+}
+
+type (
+	// User is a user.
+	User {
+		Base
+		Name    string            ` + "`json:\"name\"`" + ` // full name
+		Friends []*User           ` + "`json:\"friends,optional\"`" + `
+		Extra   map[string]string ` + "`json:\"extra\"`" + `
+		Created time.Time         ` + "`json:\"created\"`" + `
+	}
+
+	Users []User
+)
+`
+
+	f, err := parser.ParseFile(fset, "user.api", src, parser.ParseComments)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	out, err := codegen.Types("types", f)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Print(string(out))
+
+	// output:
+	// // Code generated from api definitions. DO NOT EDIT.
+	//
+	// package types
+	//
+	// import (
+	// 	"time"
+	// )
+	//
+	// // Base holds common fields.
+	// type Base struct {
+	// 	Id int64 `json:"id"`
+	// }
+	//
+	// type (
+	// 	// User is a user.
+	// 	User struct {
+	// 		Base
+	// 		Name    string            `json:"name"` // full name
+	// 		Friends []*User           `json:"friends,optional"`
+	// 		Extra   map[string]string `json:"extra"`
+	// 		Created time.Time         `json:"created"`
+	// 	}
+	//
+	// 	Users []User
+	// )
+}
+
+func ExampleConfig_Server() {
+	fset := token.NewFileSet() // positions are relative to fset
+
+	src := `syntax = "v1"
+
+type User {
+	Name string ` + "`json:\"name\"`" + `
+}
+
+@server(
+	group: user
+	middleware: Auth
+)
+service user-api {
+	@handler getUser
+	get /user/:name returns (User)
+}
+`
+
+	f, err := parser.ParseFile(fset, "user.api", src, parser.ParseComments)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	cfg := &codegen.Config{Module: "example.com/user"}
+	files, err := cfg.Server(f)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	var names []string
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Println(name)
+	}
+
+	// output:
+	// internal/config/config.go
+	// internal/handler/routes.go
+	// internal/handler/user/getuserhandler.go
+	// internal/logic/user/getuserlogic.go
+	// internal/middleware/authmiddleware.go
+	// internal/svc/servicecontext.go
+	// internal/types/types.go
+}
@@ -0,0 +1,100 @@
+package markdown_test
+
+import (
+	"fmt"
+
+	"github.com/zeromicro/api-ast/codegen/markdown"
+	"github.com/zeromicro/api-ast/parser"
+	"github.com/zeromicro/api-ast/token"
+)
+
+func ExampleGenerate() {
+	fset := token.NewFileSet() // positions are relative to fset
+
+	src := `syntax = "v1"
+
+info(
+	title: "User API"
+	desc: "manages users"
+	version: "1.0"
+)
+
+// User is a user.
+type User {
+	Id   int64  ` + "`json:\"id\"`" + `
+	Name string ` + "`json:\"name\"`" + ` // display name
+	Role string ` + "`json:\"role,options=admin|user,optional\"`" + `
+}
+
+type GetReq {
+	Id int64 ` + "`path:\"id\"`" + `
+}
+
+type Users []User
+
+@server(
+	group: user
+	prefix: /v1
+	jwt: Auth
+)
+service user-api {
+	@doc "get a user"
+	@handler getUser
+	get /user/:id (GetReq) returns (User)
+
+	@handler listUsers
+	get /users returns (Users)
+}
+`
+
+	f, err := parser.ParseFile(fset, "user.api", src, parser.ParseComments)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	doc, err := markdown.Generate(f)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Print(string(doc))
+
+	// output:
+	// # User API
+	//
+	// manages users
+	//
+	// - **Version:** 1.0
+	//
+	// ## user-api
+	//
+	// ### user
+	//
+	// | Method | Path | Handler | Request | Response | Description | Auth |
+	// | --- | --- | --- | --- | --- | --- | --- |
+	// | GET | `/v1/user/:id` | getUser | [GetReq](#getreq) | [User](#user-1) | get a user | JWT `Auth` |
+	// | GET | `/v1/users` | listUsers | - | [Users](#users) | - | JWT `Auth` |
+	//
+	// ## Types
+	//
+	// ### User
+	//
+	// User is a user.
+	//
+	// | Name | Type | In | Required | Description |
+	// | --- | --- | --- | --- | --- |
+	// | `id` | int64 | json | yes | - |
+	// | `name` | string | json | yes | display name |
+	// | `role` | string | json | no | One of: `admin`, `user`. |
+	//
+	// ### GetReq
+	//
+	// | Name | Type | In | Required | Description |
+	// | --- | --- | --- | --- | --- |
+	// | `id` | int64 | path | yes | - |
+	//
+	// ### Users
+	//
+	// Type: \[\][User](#user-1)
+}
@@ -0,0 +1,436 @@
+// Package markdown generates Markdown reference documentation from api
+// syntax trees.
+//
+// The document starts with the info declaration: the title as heading,
+// followed by the description, version and author. A section per
+// service lists the routes of each group in a table with method, full
+// path, handler, request and response types, doc text and the jwt
+// configuration that authenticates them. A final section describes
+// every declared type; struct types come with a table of their fields,
+// including the part of the request a field is bound to, whether it is
+// required and the constraints of its tag options. Declared types are
+// linked to their description wherever they appear.
+package markdown
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/zeromicro/api-ast/ast"
+	"github.com/zeromicro/api-ast/tag"
+	"github.com/zeromicro/api-ast/types"
+)
+
+// A Config controls the generated documentation.
+type Config struct {
+	// Title is the title of the document; default: the title of the
+	// info declaration, or else the name of the first service.
+	Title string
+}
+
+// Generate returns the documentation for the declarations of files,
+// using the default configuration.
+func Generate(files ...*ast.File) ([]byte, error) {
+	return (&Config{}).Generate(files...)
+}
+
+// Generate returns the documentation for the declarations of files.
+func (cfg *Config) Generate(files ...*ast.File) ([]byte, error) {
+	info, err := types.NewInfo(files...)
+	if err != nil {
+		return nil, fmt.Errorf("markdown: %v", err)
+	}
+	g := &generator{info: info, slugs: make(map[string]int), anchors: make(map[string]string)}
+
+	var infoDecl *ast.InfoType
+	var services []*service
+	byName := make(map[string]*service)
+	for _, f := range files {
+		for _, d := range f.Decls {
+			switch d := d.(type) {
+			case *ast.InfoType:
+				if infoDecl == nil {
+					infoDecl = d
+				}
+			case *ast.Service:
+				name := d.ServiceApi.Name.Name
+				s := byName[name]
+				if s == nil {
+					s = &service{name: name}
+					byName[name] = s
+					services = append(services, s)
+				}
+				s.add(d)
+			}
+		}
+	}
+
+	title := cfg.Title
+	if title == "" && infoDecl != nil {
+		title = infoDecl.Value("title")
+	}
+	if title == "" && len(services) > 0 {
+		title = services[0].name
+	}
+	if title == "" {
+		title = "API"
+	}
+
+	// Anchors depend on all preceding headings, and routes link to the
+	// types described after them: assign all anchors first.
+	g.slug(title)
+	for _, s := range services {
+		g.slug(s.name)
+		for _, b := range s.groups {
+			if b.group != "" {
+				g.slug(b.group)
+			}
+		}
+	}
+	if len(info.Specs) > 0 {
+		g.slug("Types")
+		for _, s := range info.Specs {
+			g.anchors[s.Name.Name] = g.slug(s.Name.Name)
+		}
+	}
+
+	g.printf("# %s\n", escape(title))
+	if infoDecl != nil {
+		g.infoDecl(infoDecl)
+	}
+	for _, s := range services {
+		g.service(s)
+	}
+	if len(info.Specs) > 0 {
+		g.printf("\n## Types\n")
+		for _, s := range info.Specs {
+			g.typeSpec(s)
+		}
+	}
+	if g.err != nil {
+		return nil, g.err
+	}
+	return g.buf.Bytes(), nil
+}
+
+// A service collects the routes of the service declarations with the
+// same name by group, in order of first appearance.
+type service struct {
+	name   string
+	groups []*block
+}
+
+// A block is a list of routes with the same group.
+type block struct {
+	group  string
+	routes []*route
+}
+
+type route struct {
+	decl  *ast.ServiceRoute
+	path  string // full path
+	jwt   string
+	extra []string // middleware
+}
+
+func (s *service) add(d *ast.Service) {
+	group := d.AtServer.Value("group")
+	var b *block
+	for _, x := range s.groups {
+		if x.group == group {
+			b = x
+		}
+	}
+	if b == nil {
+		b = &block{group: group}
+		if group == "" {
+			// routes without group come first, without a heading
+			s.groups = append([]*block{b}, s.groups...)
+		} else {
+			s.groups = append(s.groups, b)
+		}
+	}
+	prefix := d.AtServer.Value("prefix")
+	jwt := d.AtServer.Value("jwt")
+	var middleware []string
+	if m := d.AtServer.Value("middleware"); m != "" {
+		middleware = strings.Split(m, ",")
+	}
+	for _, sr := range d.ServiceApi.ServiceRoute {
+		b.routes = append(b.routes, &route{
+			decl:  sr,
+			path:  prefix + sr.Route.Path.Name,
+			jwt:   jwt,
+			extra: middleware,
+		})
+	}
+}
+
+// ----------------------------------------------------------------------------
+// Generator
+
+type generator struct {
+	info    *types.Info
+	slugs   map[string]int    // number of headings by anchor
+	anchors map[string]string // anchors of types
+	buf     bytes.Buffer
+	err     error // first error
+}
+
+func (g *generator) errorf(format string, args ...interface{}) {
+	if g.err == nil {
+		g.err = fmt.Errorf("markdown: "+format, args...)
+	}
+}
+
+func (g *generator) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+// slug returns the anchor of the next heading with the text heading,
+// as generated by GitHub: the text in lower case, without punctuation
+// and with spaces replaced by hyphens, made unique by a number suffix.
+func (g *generator) slug(heading string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(heading) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_':
+			b.WriteRune(r)
+		case r == ' ':
+			b.WriteByte('-')
+		}
+	}
+	s := b.String()
+	n := g.slugs[s]
+	g.slugs[s] = n + 1
+	if n > 0 {
+		s = fmt.Sprintf("%s-%d", s, n)
+	}
+	return s
+}
+
+func (g *generator) infoDecl(d *ast.InfoType) {
+	if desc := d.Value("desc"); desc != "" {
+		g.printf("\n%s\n", desc)
+	}
+	var items []string
+	if v := d.Value("version"); v != "" {
+		items = append(items, "**Version:** "+escape(v))
+	}
+	author, email := d.Value("author"), d.Value("email")
+	switch {
+	case author != "" && email != "":
+		items = append(items, fmt.Sprintf("**Author:** %s <%s>", escape(author), email))
+	case author != "":
+		items = append(items, "**Author:** "+escape(author))
+	case email != "":
+		items = append(items, fmt.Sprintf("**Author:** <%s>", email))
+	}
+	if len(items) > 0 {
+		g.printf("\n")
+		for _, item := range items {
+			g.printf("- %s\n", item)
+		}
+	}
+}
+
+func (g *generator) service(s *service) {
+	g.printf("\n## %s\n", escape(s.name))
+	for _, b := range s.groups {
+		if b.group != "" {
+			g.printf("\n### %s\n", escape(b.group))
+		}
+		g.printf("\n| Method | Path | Handler | Request | Response | Description | Auth |\n")
+		g.printf("| --- | --- | --- | --- | --- | --- | --- |\n")
+		for _, r := range b.routes {
+			rt := r.decl.Route
+			auth := "-"
+			if r.jwt != "" {
+				auth = "JWT `" + r.jwt + "`"
+			}
+			g.printf("| %s | `%s` | %s | %s | %s | %s | %s |\n",
+				strings.ToUpper(rt.Method.Name),
+				r.path,
+				cell(r.decl.AtHandler.Text()),
+				g.routeType(rt.Req),
+				g.routeType(rt.Resp),
+				cell(r.decl.AtDoc.Text()),
+				auth)
+		}
+		if m := middleware(b); len(m) > 0 {
+			g.printf("\nMiddleware: %s\n", strings.Join(m, ", "))
+		}
+	}
+}
+
+// middleware returns the names of the middleware of the routes of b,
+// as code spans.
+func middleware(b *block) []string {
+	var list []string
+	seen := make(map[string]bool)
+	for _, r := range b.routes {
+		for _, m := range r.extra {
+			if m = strings.TrimSpace(m); m != "" && !seen[m] {
+				seen[m] = true
+				list = append(list, "`"+m+"`")
+			}
+		}
+	}
+	return list
+}
+
+func (g *generator) routeType(x *ast.ParenExpr) string {
+	if x == nil {
+		return "-"
+	}
+	return g.typeString(x.X)
+}
+
+func (g *generator) typeSpec(s *ast.TypeSpec) {
+	g.printf("\n### %s\n", escape(s.Name.Name))
+	for _, cg := range []*ast.CommentGroup{s.Doc, s.Comment} {
+		if text := strings.TrimSpace(cg.Text()); text != "" {
+			g.printf("\n%s\n", text)
+			break
+		}
+	}
+	if g.info.Struct(s.Name) == nil {
+		g.printf("\nType: %s\n", g.typeString(s.Type))
+		return
+	}
+
+	if st := g.info.Struct(s.Name); st.Fields == nil || len(st.Fields.List) == 0 {
+		g.printf("\nNo fields.\n")
+		return
+	}
+	g.printf("\n| Name | Type | In | Required | Description |\n")
+	g.printf("| --- | --- | --- | --- | --- |\n")
+	g.fields(s.Name, "")
+}
+
+// fields prints the table rows of the fields of the struct type x. The
+// fields of inline struct types follow their field, with names prefixed
+// by its name and a dot.
+func (g *generator) fields(x ast.Expr, prefix string) {
+	fields, err := g.info.Fields(x)
+	if err != nil {
+		g.errorf("%v", err)
+		return
+	}
+	for _, f := range fields {
+		name, in := property(f)
+		if name == "" {
+			continue
+		}
+		required := "yes"
+		if f.Tags.Optional() {
+			required = "no"
+		}
+		g.printf("| `%s` | %s | %s | %s | %s |\n", prefix+name, g.typeString(f.Type), in, required, describe(f))
+		if st := ast.InlineStruct(f.Type); st != nil {
+			g.fields(st, prefix+name+".")
+		}
+	}
+}
+
+// property returns the name of field f in its encoding and the part of
+// the request it is bound to, or "" if it is not encoded.
+func property(f *types.Field) (name, in string) {
+	for _, key := range []string{tag.JSON, tag.Form, tag.Path, tag.Header} {
+		if t := f.Tags.Get(key); t != nil {
+			switch t.Name {
+			case "-":
+				return "", ""
+			case "":
+				return f.Name, key
+			}
+			return t.Name, key
+		}
+	}
+	return f.Name, tag.JSON
+}
+
+// describe returns the description of field f: its doc text and the
+// constraints of its tag options.
+func describe(f *types.Field) string {
+	var parts []string
+	for _, cg := range []*ast.CommentGroup{f.Field.Doc, f.Field.Comment} {
+		if text := strings.TrimSpace(cg.Text()); text != "" {
+			parts = append(parts, cell(text))
+			break
+		}
+	}
+	for _, t := range f.Tags {
+		if v, ok := t.Option("default"); ok {
+			parts = append(parts, "Default: `"+v+"`.")
+		}
+		if v, ok := t.Option("options"); ok {
+			parts = append(parts, "One of: `"+strings.Join(strings.Split(v, "|"), "`, `")+"`.")
+		}
+		if v, ok := t.Option("range"); ok {
+			parts = append(parts, "Range: `"+v+"`.")
+		}
+	}
+	if len(parts) == 0 {
+		return "-"
+	}
+	return strings.Join(parts, " ")
+}
+
+// typeString returns the Markdown for the type x, with declared types
+// linked to their description.
+func (g *generator) typeString(x ast.Expr) string {
+	switch t := x.(type) {
+	case *ast.Ident:
+		if anchor, ok := g.anchors[t.Name]; ok {
+			return fmt.Sprintf("[%s](#%s)", t.Name, anchor)
+		}
+		return t.Name
+	case *ast.SelectorExpr:
+		return g.typeString(t.X) + "." + t.Sel.Name
+	case *ast.StarExpr:
+		return `\*` + g.typeString(t.X)
+	case *ast.ParenExpr:
+		return g.typeString(t.X)
+	case *ast.ArrayType:
+		return `\[\]` + g.typeString(t.Elt)
+	case *ast.MapType:
+		return `map\[` + g.typeString(t.Key) + `\]` + g.typeString(t.Value)
+	case *ast.StructType:
+		return "struct"
+	case nil:
+		g.errorf("missing type")
+		return ""
+	}
+	g.errorf("unsupported type %T", x)
+	return ""
+}
+
+// escape escapes the characters of s that Markdown would interpret in
+// a heading or paragraph.
+func escape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune("\\`*_[]<>#|", r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// cell returns s as the content of a table cell: escaped, with line
+// breaks as <br>.
+func cell(s string) string {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return "-"
+	}
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = escape(strings.TrimSpace(line))
+	}
+	return strings.Join(lines, "<br>")
+}
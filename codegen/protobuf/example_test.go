@@ -0,0 +1,91 @@
+package protobuf_test
+
+import (
+	"fmt"
+
+	"github.com/zeromicro/api-ast/codegen/protobuf"
+	"github.com/zeromicro/api-ast/parser"
+	"github.com/zeromicro/api-ast/token"
+)
+
+func ExampleConfig_Generate() {
+	fset := token.NewFileSet() // positions are relative to fset
+
+	src := `syntax = "v1"
+
+// User is a user.
+type User {
+	Id      int64             ` + "`json:\"id\"`" + `
+	Name    string            ` + "`json:\"name\"`" + `
+	Email   *string           ` + "`json:\"email\"`" + `
+	Tags    []string          ` + "`json:\"tags,optional\"`" + `
+	Labels  map[string]string ` + "`json:\"labels,optional\"`" + `
+	Created time.Time         ` + "`json:\"created_at\"`" + `
+}
+
+type GetUserReq {
+	Id int64 ` + "`path:\"id\"`" + `
+}
+
+service user-api {
+	@doc "returns a user"
+	@handler getUser
+	get /user/:id (GetUserReq) returns (User)
+}
+`
+
+	f, err := parser.ParseFile(fset, "user.api", src, parser.ParseComments)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	// The lock records that User had a field "nick" with number 3,
+	// which has been removed since.
+	lock, err := protobuf.ReadLock([]byte(`{"messages": {"User": {"fields": {"id": 1, "name": 2, "nick": 3}}}}`))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	cfg := &protobuf.Config{GoPackage: "example.com/user/pb", Lock: lock}
+	out, err := cfg.Generate(f)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Print(string(out))
+
+	// output:
+	// // Code generated from api definitions. DO NOT EDIT.
+	//
+	// syntax = "proto3";
+	//
+	// package user_api;
+	//
+	// import "google/protobuf/timestamp.proto";
+	//
+	// option go_package = "example.com/user/pb";
+	//
+	// // User is a user.
+	// message User {
+	//   int64 id = 1;
+	//   string name = 2;
+	//   optional string email = 4;
+	//   repeated string tags = 5;
+	//   map<string, string> labels = 6;
+	//   google.protobuf.Timestamp created_at = 7 [json_name = "created_at"];
+	//   reserved 3;
+	//   reserved "nick";
+	// }
+	//
+	// message GetUserReq {
+	//   int64 id = 1;
+	// }
+	//
+	// service UserApi {
+	//   // returns a user
+	//   //
+	//   // GET /user/:id
+	//   rpc GetUser(GetUserReq) returns (User);
+	// }
+}
@@ -0,0 +1,671 @@
+// Package protobuf generates Protocol Buffers (proto3) definitions with
+// gRPC services from api syntax trees.
+//
+// Every struct type becomes a message. Its fields are those of the
+// struct, with embedded structs flattened; a field is named after its
+// json tag (or its form, path or header tag) in snake case, with a
+// json_name option if the JSON name differs from the proto3 default.
+// Types map as follows:
+//
+//	bool, string            bool, string
+//	int, int64              int64
+//	int8, int16, int32      int32
+//	uint, uint64            uint64
+//	uint8, uint16, uint32   uint32
+//	float32, float64        float, double
+//	[]byte                  bytes
+//	[]T                     repeated T
+//	map[K]V                 map<K, V>
+//	time.Time               google.protobuf.Timestamp
+//	any, interface{}        google.protobuf.Value
+//	struct{...}             nested message
+//
+// Pointers and fields with the optional tag option are optional fields
+// if they are of scalar type. Named types that are not structs are
+// replaced by their definitions. Slices of slices, slices or maps as map
+// values and map keys other than strings, integers and booleans have no
+// proto3 equivalent and are reported as errors.
+//
+// Every service becomes a gRPC service with one rpc per route, named
+// after the route's @handler. Requests and responses must be struct
+// types; a missing one is google.protobuf.Empty.
+package protobuf
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/zeromicro/api-ast/ast"
+	"github.com/zeromicro/api-ast/types"
+)
+
+// Well-known types used by the generated definitions.
+const (
+	emptyType     = "google.protobuf.Empty"
+	timestampType = "google.protobuf.Timestamp"
+	valueType     = "google.protobuf.Value"
+)
+
+var imports = map[string]string{
+	emptyType:     "google/protobuf/empty.proto",
+	timestampType: "google/protobuf/timestamp.proto",
+	valueType:     "google/protobuf/struct.proto",
+}
+
+// A Config controls the generated definitions.
+type Config struct {
+	// Package is the proto package; default: the name of the first
+	// service in snake case, or "api" if there is none.
+	Package string
+
+	// GoPackage, if set, is the value of the go_package option.
+	GoPackage string
+
+	// Lock, if set, keeps field numbers stable across runs: fields
+	// recorded in Lock keep their numbers, new fields get numbers never
+	// used before in their message, and the numbers and names of
+	// removed fields are reserved. Generate records the new numbering
+	// in Lock. Without Lock, fields are numbered in source order.
+	Lock *Lock
+}
+
+// A Lock records the field numbers of messages; see Config.Lock.
+type Lock struct {
+	Messages map[string]*MessageLock `json:"messages"` // by message name, such as "User" or "User.Address"
+}
+
+// A MessageLock records the field numbers of a message.
+type MessageLock struct {
+	Fields   map[string]int `json:"fields"`             // numbers of fields, by field name
+	Reserved map[string]int `json:"reserved,omitempty"` // numbers of removed fields, by field name
+}
+
+// ReadLock decodes a lock file written by Lock.JSON.
+func ReadLock(data []byte) (*Lock, error) {
+	l := new(Lock)
+	if err := json.Unmarshal(data, l); err != nil {
+		return nil, fmt.Errorf("protobuf: invalid lock file: %v", err)
+	}
+	return l, nil
+}
+
+// JSON returns the indented JSON encoding of l.
+func (l *Lock) JSON() ([]byte, error) {
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// Generate returns the proto3 definitions for the types and services
+// declared in files, using the default configuration.
+func Generate(files ...*ast.File) ([]byte, error) {
+	return (&Config{}).Generate(files...)
+}
+
+// Generate returns the proto3 definitions for the types and services
+// declared in files: a message for every struct type, in source order,
+// followed by a service for every service name, in order of first
+// declaration.
+func (cfg *Config) Generate(files ...*ast.File) ([]byte, error) {
+	info, err := types.NewInfo(files...)
+	if err != nil {
+		return nil, fmt.Errorf("protobuf: %v", err)
+	}
+	g := &generator{cfg: cfg, info: info, imports: make(map[string]bool)}
+
+	var messages []*message
+	for _, s := range info.Specs {
+		if info.Struct(s.Name) != nil {
+			messages = append(messages, g.message(s.Name.Name, s.Name, s.Doc))
+		}
+	}
+	services := g.services(files)
+	if g.err != nil {
+		return nil, g.err
+	}
+	for _, m := range messages {
+		g.number(m)
+	}
+
+	pkg := cfg.Package
+	if pkg == "" {
+		pkg = "api"
+		if len(services) > 0 {
+			pkg = snakeName(services[0].api)
+		}
+	}
+	g.printf("// Code generated from api definitions. DO NOT EDIT.\n\n")
+	g.printf("syntax = \"proto3\";\n\n")
+	g.printf("package %s;\n", pkg)
+	if len(g.imports) > 0 {
+		g.printf("\n")
+		list := make([]string, 0, len(g.imports))
+		for path := range g.imports {
+			list = append(list, path)
+		}
+		sort.Strings(list)
+		for _, path := range list {
+			g.printf("import %s;\n", strconv.Quote(path))
+		}
+	}
+	if cfg.GoPackage != "" {
+		g.printf("\noption go_package = %s;\n", strconv.Quote(cfg.GoPackage))
+	}
+	for _, m := range messages {
+		g.printf("\n")
+		g.printMessage(m, "")
+	}
+	for _, s := range services {
+		g.printf("\n")
+		g.printService(s)
+	}
+	return g.buf.Bytes(), nil
+}
+
+// ----------------------------------------------------------------------------
+// Generator
+
+type generator struct {
+	cfg     *Config
+	info    *types.Info
+	imports map[string]bool // imported files
+	buf     bytes.Buffer
+	err     error // first error
+}
+
+func (g *generator) errorf(format string, args ...interface{}) {
+	if g.err == nil {
+		g.err = fmt.Errorf("protobuf: "+format, args...)
+	}
+}
+
+func (g *generator) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+func (g *generator) use(wellKnown string) string {
+	g.imports[imports[wellKnown]] = true
+	return wellKnown
+}
+
+// ----------------------------------------------------------------------------
+// Messages
+
+type message struct {
+	name     string // full name, such as "User.Address"
+	doc      *ast.CommentGroup
+	fields   []*field
+	nested   []*message
+	reserved map[string]int // numbers of removed fields, by name
+}
+
+type field struct {
+	label    string // "", "optional" or "repeated"
+	typ      string
+	name     string
+	jsonName string // JSON name if it differs from the proto3 default, or ""
+	number   int
+	doc      *ast.CommentGroup
+}
+
+// message returns the message for the struct type x.
+func (g *generator) message(name string, x ast.Expr, doc *ast.CommentGroup) *message {
+	m := &message{name: name, doc: doc}
+	fields, err := g.info.Fields(x)
+	if err != nil {
+		g.errorf("%s: %v", name, err)
+		return m
+	}
+	names := make(map[string]bool)
+	for _, f := range fields {
+		prop := f.PropertyName()
+		if prop == "" {
+			continue
+		}
+		fld := &field{name: snakeName(prop), doc: f.Field.Doc}
+		if fld.doc == nil {
+			fld.doc = f.Field.Comment
+		}
+		if !types.IsIdentifier(fld.name) || !isASCII(fld.name) {
+			g.errorf("field %s.%s: cannot derive a field name from %q", name, f.Name, prop)
+			continue
+		}
+		if names[fld.name] {
+			g.errorf("field %s.%s: duplicate field name %s", name, f.Name, fld.name)
+			continue
+		}
+		names[fld.name] = true
+		if prop != jsonName(fld.name) {
+			fld.jsonName = prop
+		}
+		fld.label, fld.typ = g.fieldType(m, f)
+		m.fields = append(m.fields, fld)
+	}
+	return m
+}
+
+// fieldType returns the label and the type of field f of message m.
+func (g *generator) fieldType(m *message, f *types.Field) (label, typ string) {
+	x := g.inline(f.Type)
+	optional := f.Tags.Optional()
+	if p, ok := x.(*ast.StarExpr); ok {
+		x = g.inline(p.X)
+		optional = true
+	}
+	switch t := x.(type) {
+	case *ast.ArrayType:
+		if isBytes(t) {
+			break
+		}
+		typ, _ := g.elemType(m, f, t.Elt, "repeated field")
+		return "repeated", typ
+	case *ast.MapType:
+		key := g.mapKey(m, f, t.Key)
+		value, _ := g.elemType(m, f, t.Value, "map value")
+		return "", "map<" + key + ", " + value + ">"
+	}
+	typ, scalar := g.elemType(m, f, x, "")
+	if optional && scalar {
+		label = "optional"
+	}
+	return label, typ
+}
+
+// elemType returns the type of values of type x, which must not be
+// repeated, and reports whether it is a scalar type. The context, such as
+// "map value", is used in errors.
+func (g *generator) elemType(m *message, f *types.Field, x ast.Expr, context string) (typ string, scalar bool) {
+	x = g.inline(x)
+	if p, ok := x.(*ast.StarExpr); ok {
+		x = g.inline(p.X)
+	}
+	switch t := x.(type) {
+	case *ast.Ident:
+		if typ, ok := scalarTypes[t.Name]; ok {
+			return typ, true
+		}
+		switch t.Name {
+		case "any", "interface{}":
+			return g.use(valueType), false
+		}
+		if g.info.Lookup(t.Name) == nil {
+			g.errorf("field %s.%s: undeclared type %s", m.name, f.Name, t.Name)
+		}
+		return t.Name, false
+
+	case *ast.SelectorExpr:
+		if pkg, ok := t.X.(*ast.Ident); ok && pkg.Name == "time" && t.Sel.Name == "Time" {
+			return g.use(timestampType), false
+		}
+		g.errorf("field %s.%s: unsupported type %s", m.name, f.Name, typeString(t))
+
+	case *ast.ArrayType:
+		if isBytes(t) {
+			return "bytes", true
+		}
+		g.errorf("field %s.%s: a %s cannot be repeated", m.name, f.Name, context)
+
+	case *ast.MapType:
+		if context == "" {
+			context = "field"
+		}
+		g.errorf("field %s.%s: a %s cannot be a map", m.name, f.Name, context)
+
+	case *ast.StructType:
+		name := types.ExportedName(f.Name)
+		for _, n := range m.nested {
+			if n.name == m.name+"."+name {
+				g.errorf("field %s.%s: duplicate nested message %s", m.name, f.Name, name)
+			}
+		}
+		m.nested = append(m.nested, g.message(m.name+"."+name, t, nil))
+		return name, false
+
+	case nil:
+		g.errorf("field %s.%s: missing type", m.name, f.Name)
+
+	default:
+		g.errorf("field %s.%s: unsupported type %T", m.name, f.Name, t)
+	}
+	return "", false
+}
+
+// mapKey returns the type of map keys of type x.
+func (g *generator) mapKey(m *message, f *types.Field, x ast.Expr) string {
+	if id, ok := g.inline(x).(*ast.Ident); ok {
+		if typ, ok := scalarTypes[id.Name]; ok && typ != "float" && typ != "double" && typ != "bytes" {
+			return typ
+		}
+	}
+	g.errorf("field %s.%s: map keys must be strings, integers or booleans, not %s", m.name, f.Name, typeString(x))
+	return ""
+}
+
+var scalarTypes = map[string]string{
+	"bool":    "bool",
+	"string":  "string",
+	"int":     "int64",
+	"int8":    "int32",
+	"int16":   "int32",
+	"int32":   "int32",
+	"rune":    "int32",
+	"int64":   "int64",
+	"uint":    "uint64",
+	"uint8":   "uint32",
+	"byte":    "uint32",
+	"uint16":  "uint32",
+	"uint32":  "uint32",
+	"uint64":  "uint64",
+	"uintptr": "uint64",
+	"float32": "float",
+	"float64": "double",
+}
+
+// inline returns x with parentheses removed and names of declared types
+// that are not structs replaced by their definitions.
+func (g *generator) inline(x ast.Expr) ast.Expr {
+	seen := make(map[string]bool)
+	for {
+		switch t := x.(type) {
+		case *ast.ParenExpr:
+			x = t.X
+			continue
+		case *ast.Ident:
+			if s := g.info.Lookup(t.Name); s != nil && !seen[t.Name] && g.info.Struct(t) == nil {
+				seen[t.Name] = true
+				x = s.Type
+				continue
+			}
+		}
+		return x
+	}
+}
+
+func isBytes(t *ast.ArrayType) bool {
+	elt, ok := t.Elt.(*ast.Ident)
+	return ok && t.Len == nil && (elt.Name == "byte" || elt.Name == "uint8")
+}
+
+// number assigns the field numbers of m and its nested messages.
+func (g *generator) number(m *message) {
+	for _, n := range m.nested {
+		g.number(n)
+	}
+	if g.cfg.Lock == nil {
+		for i, f := range m.fields {
+			f.number = i + 1
+		}
+		return
+	}
+
+	lock := g.cfg.Lock
+	if lock.Messages == nil {
+		lock.Messages = make(map[string]*MessageLock)
+	}
+	ml := lock.Messages[m.name]
+	if ml == nil {
+		ml = &MessageLock{}
+		lock.Messages[m.name] = ml
+	}
+	if ml.Fields == nil {
+		ml.Fields = make(map[string]int)
+	}
+	if ml.Reserved == nil {
+		ml.Reserved = make(map[string]int)
+	}
+	next := 1
+	for _, numbers := range []map[string]int{ml.Fields, ml.Reserved} {
+		for _, n := range numbers {
+			if n >= next {
+				next = n + 1
+			}
+		}
+	}
+
+	present := make(map[string]bool)
+	for _, f := range m.fields {
+		present[f.name] = true
+		if n, ok := ml.Fields[f.name]; ok {
+			f.number = n
+			continue
+		}
+		if n, ok := ml.Reserved[f.name]; ok {
+			// a removed field is back
+			f.number = n
+			delete(ml.Reserved, f.name)
+		} else {
+			if next >= 19000 && next <= 19999 {
+				next = 20000 // reserved for the protobuf implementation
+			}
+			f.number = next
+			next++
+		}
+		ml.Fields[f.name] = f.number
+	}
+	for name, n := range ml.Fields {
+		if !present[name] {
+			ml.Reserved[name] = n
+			delete(ml.Fields, name)
+		}
+	}
+	if len(ml.Reserved) == 0 {
+		ml.Reserved = nil
+	} else {
+		m.reserved = ml.Reserved
+	}
+}
+
+func (g *generator) printMessage(m *message, indent string) {
+	g.comment(m.doc, indent)
+	g.printf("%smessage %s {\n", indent, m.name[strings.LastIndex(m.name, ".")+1:])
+	for _, n := range m.nested {
+		g.printMessage(n, indent+"  ")
+		g.printf("\n")
+	}
+	for _, f := range m.fields {
+		g.comment(f.doc, indent+"  ")
+		g.printf("%s  ", indent)
+		if f.label != "" {
+			g.printf("%s ", f.label)
+		}
+		g.printf("%s %s = %d", f.typ, f.name, f.number)
+		if f.jsonName != "" {
+			g.printf(" [json_name = %s]", strconv.Quote(f.jsonName))
+		}
+		g.printf(";\n")
+	}
+	if len(m.reserved) > 0 {
+		names := make([]string, 0, len(m.reserved))
+		numbers := make([]int, 0, len(m.reserved))
+		for name, n := range m.reserved {
+			names = append(names, strconv.Quote(name))
+			numbers = append(numbers, n)
+		}
+		sort.Strings(names)
+		sort.Ints(numbers)
+		list := make([]string, len(numbers))
+		for i, n := range numbers {
+			list[i] = strconv.Itoa(n)
+		}
+		g.printf("%s  reserved %s;\n", indent, strings.Join(list, ", "))
+		g.printf("%s  reserved %s;\n", indent, strings.Join(names, ", "))
+	}
+	g.printf("%s}\n", indent)
+}
+
+// comment prints the comment group cg, if any, on lines of its own.
+func (g *generator) comment(cg *ast.CommentGroup, indent string) {
+	if cg == nil {
+		return
+	}
+	for _, c := range cg.List {
+		g.printf("%s%s\n", indent, c.Text)
+	}
+}
+
+// ----------------------------------------------------------------------------
+// Services
+
+type service struct {
+	api  string // api service name, such as "user-api"
+	name string // gRPC service name, such as "UserApi"
+	rpcs []*rpc
+}
+
+type rpc struct {
+	name  string
+	doc   string
+	route string // such as "GET /user/:id"
+	req   string
+	resp  string
+}
+
+// services returns the services declared in files. Service declarations
+// with the same name make up one service.
+func (g *generator) services(files []*ast.File) []*service {
+	var list []*service
+	byName := make(map[string]*service)
+	rpcs := make(map[string]string) // route of rpc, by service and rpc name
+	for _, f := range files {
+		for _, d := range f.Decls {
+			svc, ok := d.(*ast.Service)
+			if !ok {
+				continue
+			}
+			api := svc.ServiceApi.Name.Name
+			s := byName[api]
+			if s == nil {
+				s = &service{api: api, name: types.ExportedName(api)}
+				byName[api] = s
+				list = append(list, s)
+			}
+			prefix := svc.AtServer.Value("prefix")
+			for _, sr := range svc.ServiceApi.ServiceRoute {
+				rt := sr.Route
+				r := &rpc{
+					name:  types.ExportedName(sr.AtHandler.Text()),
+					doc:   sr.AtDoc.Text(),
+					route: strings.ToUpper(rt.Method.Name) + " " + prefix + rt.Path.Name,
+				}
+				if sr.AtHandler == nil {
+					g.errorf("route %s: missing @handler", r.route)
+					continue
+				}
+				key := s.name + "." + r.name
+				if route, ok := rpcs[key]; ok {
+					g.errorf("route %s: rpc %s already defined for route %s", r.route, r.name, route)
+					continue
+				}
+				rpcs[key] = r.route
+				r.req = g.rpcType(r, "request", rt.Req)
+				r.resp = g.rpcType(r, "response", rt.Resp)
+				s.rpcs = append(s.rpcs, r)
+			}
+		}
+	}
+	return list
+}
+
+// rpcType returns the message type for the request or response x of r.
+func (g *generator) rpcType(r *rpc, kind string, x *ast.ParenExpr) string {
+	if x == nil {
+		return g.use(emptyType)
+	}
+	if id, ok := x.X.(*ast.Ident); ok && g.info.Struct(id) != nil {
+		return id.Name
+	}
+	g.errorf("route %s: %s type must be a declared struct type, not %s", r.route, kind, typeString(x.X))
+	return ""
+}
+
+func (g *generator) printService(s *service) {
+	g.printf("service %s {\n", s.name)
+	for i, r := range s.rpcs {
+		if i > 0 {
+			g.printf("\n")
+		}
+		if r.doc != "" {
+			for _, line := range strings.Split(r.doc, "\n") {
+				g.printf("  // %s\n", strings.TrimSpace(line))
+			}
+			g.printf("  //\n")
+		}
+		g.printf("  // %s\n", r.route)
+		g.printf("  rpc %s(%s) returns (%s);\n", r.name, r.req, r.resp)
+	}
+	g.printf("}\n")
+}
+
+// ----------------------------------------------------------------------------
+// Names
+
+// typeString returns the api source of the type x.
+func typeString(x ast.Expr) string {
+	switch t := x.(type) {
+	case *ast.Ident:
+		return t.Name
+	case *ast.SelectorExpr:
+		return typeString(t.X) + "." + t.Sel.Name
+	case *ast.StarExpr:
+		return "*" + typeString(t.X)
+	case *ast.ParenExpr:
+		return "(" + typeString(t.X) + ")"
+	case *ast.ArrayType:
+		if t.Len != nil {
+			return "[" + typeString(t.Len) + "]" + typeString(t.Elt)
+		}
+		return "[]" + typeString(t.Elt)
+	case *ast.MapType:
+		return "map[" + typeString(t.Key) + "]" + typeString(t.Value)
+	case *ast.StructType:
+		return "struct{...}"
+	case *ast.BasicLit:
+		return t.Value
+	}
+	return fmt.Sprintf("%T", x)
+}
+
+// snakeName returns s in lower case with words separated by
+// underscores, such as "user_id" for "userId".
+func snakeName(s string) string {
+	return strings.ToLower(strings.Join(types.Words(s), "_"))
+}
+
+// jsonName returns the default JSON name of the proto field name, as
+// computed by protoc: underscores are removed and the letters following
+// them converted to upper case.
+func jsonName(name string) string {
+	var b strings.Builder
+	upper := false
+	for _, r := range name {
+		switch {
+		case r == '_':
+			upper = true
+		case upper:
+			b.WriteRune(unicode.ToUpper(r))
+			upper = false
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// isASCII reports whether s has only ASCII characters, as identifiers of
+// proto files must.
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
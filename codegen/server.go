@@ -0,0 +1,525 @@
+package codegen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"path"
+	"sort"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/zeromicro/api-ast/ast"
+	"github.com/zeromicro/api-ast/types"
+)
+
+// ----------------------------------------------------------------------------
+// Server skeleton
+
+// The go-zero project layout of the generated server skeleton.
+const (
+	configDir     = "internal/config"
+	handlerDir    = "internal/handler"
+	logicDir      = "internal/logic"
+	middlewareDir = "internal/middleware"
+	svcDir        = "internal/svc"
+	typesDir      = "internal/types"
+)
+
+// A block is the list of routes of a single service declaration,
+// together with the settings of its @server block.
+type block struct {
+	Group      string   // handler group, such as "user/admin"; or ""
+	Jwt        string   // name of the jwt config; or ""
+	Prefix     string   // path prefix; or ""
+	Middleware []string // middleware names
+	Routes     []*route
+}
+
+type route struct {
+	Method  string // http.MethodXxx
+	Path    string
+	Doc     string
+	Name    string // handler name without "Handler" suffix, such as "GetUser"
+	Req     string // Go request type; or ""
+	Resp    string // Go response type; or ""
+	GroupId string // import name of the handler group in routes.go; or ""
+
+	ReqParam  string // type of the request parameter of the logic method
+	ReqArg    string // argument passed by the handler
+	RespParam string // type of the result of the logic method
+	ReqTypes  bool   // set if Req refers to the types package
+	Types     bool   // set if Req or Resp refer to the types package
+}
+
+// Server returns the files of a go-zero style server skeleton for the
+// services declared in files, keyed by slash-separated path relative to
+// the project root:
+//
+//	internal/config/config.go                 configuration
+//	internal/handler/routes.go                route registration
+//	internal/handler/<group>/<name>handler.go one handler per route
+//	internal/logic/<group>/<name>logic.go     one logic stub per route
+//	internal/middleware/<name>middleware.go   one stub per middleware
+//	internal/svc/servicecontext.go            service context
+//	internal/types/types.go                   types, see Config.Types
+//
+// Groups, jwt, middleware and path prefixes are taken from the @server
+// block of each service declaration; every route must have a @handler.
+// cfg.Module must be set to the import path of the project root. Use
+// WriteFiles to write the result without overwriting edited files.
+func (cfg *Config) Server(files ...*ast.File) (map[string][]byte, error) {
+	if cfg.Module == "" {
+		return nil, fmt.Errorf("codegen: module path required")
+	}
+
+	out := make(map[string][]byte)
+	types, err := (&Config{Package: "types", Imports: cfg.Imports}).Types(files...)
+	if err != nil {
+		return nil, err
+	}
+	out[typesDir+"/types.go"] = types
+
+	s, err := cfg.newServer(files)
+	if err != nil {
+		return nil, err
+	}
+
+	data := map[string]interface{}{
+		"Module":      cfg.Module,
+		"Blocks":      s.blocks,
+		"Groups":      s.groups,
+		"Jwt":         s.jwt,
+		"Middlewares": s.middlewares,
+	}
+	gen := func(filename string, tmpl *template.Template, data interface{}) {
+		if err != nil {
+			return
+		}
+		var buf bytes.Buffer
+		if err = tmpl.Execute(&buf, data); err != nil {
+			return
+		}
+		var src []byte
+		if src, err = format.Source(buf.Bytes()); err != nil {
+			err = fmt.Errorf("codegen: %s: invalid Go source: %v", filename, err)
+			return
+		}
+		out[filename] = src
+	}
+
+	gen(configDir+"/config.go", configTemplate, data)
+	gen(svcDir+"/servicecontext.go", svcTemplate, data)
+	gen(handlerDir+"/routes.go", routesTemplate, data)
+	for _, name := range s.middlewares {
+		gen(middlewareDir+"/"+strings.ToLower(name)+"middleware.go", middlewareTemplate, name)
+	}
+	for _, b := range s.blocks {
+		for _, r := range b.Routes {
+			d := map[string]interface{}{
+				"Module":  cfg.Module,
+				"Group":   b.Group,
+				"Package": packageName(b.Group, "handler"),
+				"Logic":   packageName(b.Group, "logic"),
+				"Route":   r,
+			}
+			file := strings.ToLower(r.Name)
+			gen(path.Join(handlerDir, b.Group, file+"handler.go"), handlerTemplate, d)
+			d["Package"] = packageName(b.Group, "logic")
+			gen(path.Join(logicDir, b.Group, file+"logic.go"), logicTemplate, d)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// packageName returns the package name for the directory group, or def
+// for the root directory.
+func packageName(group, def string) string {
+	if group == "" {
+		return def
+	}
+	return path.Base(group)
+}
+
+type server struct {
+	declared    map[string]bool
+	blocks      []*block
+	groups      []string // handler groups, sorted
+	jwt         []string // jwt config names, sorted
+	middlewares []string // middleware names, sorted
+}
+
+func (cfg *Config) newServer(files []*ast.File) (*server, error) {
+	s := &server{declared: make(map[string]bool)}
+	for _, f := range files {
+		for _, d := range f.Decls {
+			if d, ok := d.(*ast.GenDecl); ok {
+				for _, spec := range d.Specs {
+					if spec, ok := spec.(*ast.TypeSpec); ok {
+						s.declared[spec.Name.Name] = true
+					}
+				}
+			}
+		}
+	}
+
+	groups := make(map[string]bool)
+	jwt := make(map[string]bool)
+	middlewares := make(map[string]bool)
+	handlers := make(map[string]bool) // group/name
+	for _, f := range files {
+		for _, d := range f.Decls {
+			svc, ok := d.(*ast.Service)
+			if !ok {
+				continue
+			}
+			b := &block{
+				Group:  svc.AtServer.Value("group"),
+				Jwt:    svc.AtServer.Value("jwt"),
+				Prefix: svc.AtServer.Value("prefix"),
+			}
+			if b.Group != "" && !isValidGroup(b.Group) {
+				return nil, fmt.Errorf("codegen: invalid group %q", b.Group)
+			}
+			groups[b.Group] = true
+			if b.Jwt != "" {
+				if !types.IsIdentifier(b.Jwt) {
+					return nil, fmt.Errorf("codegen: invalid jwt %q", b.Jwt)
+				}
+				jwt[b.Jwt] = true
+			}
+			for _, m := range strings.Split(svc.AtServer.Value("middleware"), ",") {
+				if m = strings.TrimSpace(m); m == "" {
+					continue
+				}
+				if !types.IsIdentifier(m) {
+					return nil, fmt.Errorf("codegen: invalid middleware %q", m)
+				}
+				m = exported(m)
+				b.Middleware = append(b.Middleware, m)
+				middlewares[m] = true
+			}
+
+			for _, sr := range svc.ServiceApi.ServiceRoute {
+				r, err := cfg.newRoute(s, sr)
+				if err != nil {
+					return nil, err
+				}
+				key := b.Group + "/" + r.Name
+				if handlers[key] {
+					return nil, fmt.Errorf("codegen: duplicate handler %s in group %q", r.Name, b.Group)
+				}
+				handlers[key] = true
+				if b.Group != "" {
+					r.GroupId = strings.ReplaceAll(b.Group, "/", "")
+				}
+				b.Routes = append(b.Routes, r)
+			}
+			s.blocks = append(s.blocks, b)
+		}
+	}
+
+	s.groups = sortedKeys(groups)
+	s.jwt = sortedKeys(jwt)
+	s.middlewares = sortedKeys(middlewares)
+	return s, nil
+}
+
+var methods = map[string]string{
+	"get":     "http.MethodGet",
+	"head":    "http.MethodHead",
+	"post":    "http.MethodPost",
+	"put":     "http.MethodPut",
+	"patch":   "http.MethodPatch",
+	"delete":  "http.MethodDelete",
+	"connect": "http.MethodConnect",
+	"options": "http.MethodOptions",
+	"trace":   "http.MethodTrace",
+}
+
+func (cfg *Config) newRoute(s *server, sr *ast.ServiceRoute) (*route, error) {
+	method, ok := methods[strings.ToLower(sr.Route.Method.Name)]
+	if !ok {
+		return nil, fmt.Errorf("codegen: unknown method %q for %s", sr.Route.Method.Name, sr.Route.Path.Name)
+	}
+	handler := sr.AtHandler.Text()
+	if handler == "" {
+		return nil, fmt.Errorf("codegen: missing @handler for %s %s", sr.Route.Method.Name, sr.Route.Path.Name)
+	}
+	if !types.IsIdentifier(handler) {
+		return nil, fmt.Errorf("codegen: invalid handler name %q", handler)
+	}
+
+	r := &route{
+		Method: method,
+		Path:   sr.Route.Path.Name,
+		Doc:    sr.AtDoc.Text(),
+		Name:   exported(strings.TrimSuffix(handler, "Handler")),
+	}
+	var err error
+	if sr.Route.Req != nil {
+		if r.Req, err = cfg.typeString(sr.Route.Req.X, s.declared); err != nil {
+			return nil, err
+		}
+		r.ReqParam, r.ReqArg = r.Req, "req"
+		if isNamed(r.Req) {
+			r.ReqParam, r.ReqArg = "*"+r.Req, "&req"
+		}
+	}
+	if sr.Route.Resp != nil {
+		if r.Resp, err = cfg.typeString(sr.Route.Resp.X, s.declared); err != nil {
+			return nil, err
+		}
+		r.RespParam = r.Resp
+		if isNamed(r.Resp) {
+			r.RespParam = "*" + r.Resp
+		}
+	}
+	r.ReqTypes = strings.Contains(r.Req, "types.")
+	r.Types = r.ReqTypes || strings.Contains(r.Resp, "types.")
+	return r, nil
+}
+
+// isNamed reports whether the Go type t is a (possibly qualified) type name.
+func isNamed(t string) bool {
+	return !strings.HasPrefix(t, "[") && !strings.HasPrefix(t, "map[") && !strings.HasPrefix(t, "*")
+}
+
+// typeString returns the Go type for x, qualifying declared types with
+// the types package name.
+func (cfg *Config) typeString(x ast.Expr, declared map[string]bool) (string, error) {
+	g := cfg.newGenerator()
+	g.qualifier = "types"
+	g.declared = declared
+	g.expr(x)
+	return g.buf.String(), g.err
+}
+
+func sortedKeys(m map[string]bool) []string {
+	list := make([]string, 0, len(m))
+	for k := range m {
+		if k != "" {
+			list = append(list, k)
+		}
+	}
+	sort.Strings(list)
+	return list
+}
+
+func exported(name string) string {
+	for i, r := range name {
+		return string(unicode.ToUpper(r)) + name[i+len(string(r)):]
+	}
+	return name
+}
+
+func isValidGroup(group string) bool {
+	for _, elem := range strings.Split(group, "/") {
+		if !types.IsIdentifier(elem) {
+			return false
+		}
+	}
+	return true
+}
+
+// ----------------------------------------------------------------------------
+// Templates
+
+var funcs = template.FuncMap{
+	"id": func(s string) string { return strings.ReplaceAll(s, "/", "") },
+}
+
+func newTemplate(name, text string) *template.Template {
+	return template.Must(template.New(name).Funcs(funcs).Parse(text))
+}
+
+var configTemplate = newTemplate("config", `// Code scaffolded from api definitions. Safe to edit.
+
+package config
+
+import "github.com/zeromicro/go-zero/rest"
+
+type Config struct {
+	rest.RestConf
+{{- range .Jwt}}
+	{{.}} struct {
+		AccessSecret string
+		AccessExpire int64
+	}
+{{- end}}
+}
+`)
+
+var svcTemplate = newTemplate("svc", `// Code scaffolded from api definitions. Safe to edit.
+
+package svc
+
+import (
+	"{{.Module}}/internal/config"
+{{- if .Middlewares}}
+	"{{.Module}}/internal/middleware"
+
+	"github.com/zeromicro/go-zero/rest"
+{{- end}}
+)
+
+type ServiceContext struct {
+	Config config.Config
+{{- range .Middlewares}}
+	{{.}} rest.Middleware
+{{- end}}
+}
+
+func NewServiceContext(c config.Config) *ServiceContext {
+	return &ServiceContext{
+		Config: c,
+{{- range .Middlewares}}
+		{{.}}: middleware.New{{.}}Middleware().Handle,
+{{- end}}
+	}
+}
+`)
+
+var routesTemplate = newTemplate("routes", `// Code generated from api definitions. DO NOT EDIT.
+
+package handler
+
+import (
+	"net/http"
+
+{{range .Groups}}	{{id .}} "{{$.Module}}/internal/handler/{{.}}"
+{{end}}	"{{.Module}}/internal/svc"
+
+	"github.com/zeromicro/go-zero/rest"
+)
+
+func RegisterHandlers(server *rest.Server, serverCtx *svc.ServiceContext) {
+{{- range .Blocks}}
+	server.AddRoutes(
+	{{- if .Middleware}}
+		rest.WithMiddlewares(
+			[]rest.Middleware{ {{- range $i, $m := .Middleware}}{{if $i}}, {{end}}serverCtx.{{$m}}{{end -}} },
+	{{- end}}
+		[]rest.Route{
+		{{- range .Routes}}
+			{
+				Method:  {{.Method}},
+				Path:    {{printf "%q" .Path}},
+				Handler: {{with .GroupId}}{{.}}.{{end}}{{.Name}}Handler(serverCtx),
+			},
+		{{- end}}
+		}{{if .Middleware}}...,
+		){{end}},
+	{{- with .Jwt}}
+		rest.WithJwt(serverCtx.Config.{{.}}.AccessSecret),
+	{{- end}}
+	{{- with .Prefix}}
+		rest.WithPrefix({{printf "%q" .}}),
+	{{- end}}
+	)
+{{- end}}
+}
+`)
+
+var handlerTemplate = newTemplate("handler", `// Code scaffolded from api definitions. Safe to edit.
+
+package {{.Package}}
+
+import (
+	"net/http"
+
+	"{{.Module}}/internal/logic{{with .Group}}/{{.}}{{end}}"
+	"{{.Module}}/internal/svc"
+{{- if .Route.ReqTypes}}
+	"{{.Module}}/internal/types"
+{{- end}}
+
+	"github.com/zeromicro/go-zero/rest/httpx"
+)
+
+{{with .Route.Doc}}// {{.}}
+{{end -}}
+func {{.Route.Name}}Handler(svcCtx *svc.ServiceContext) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+	{{- if .Route.Req}}
+		var req {{.Route.Req}}
+		if err := httpx.Parse(r, &req); err != nil {
+			httpx.ErrorCtx(r.Context(), w, err)
+			return
+		}
+
+	{{end}}
+		l := {{.Logic}}.New{{.Route.Name}}Logic(r.Context(), svcCtx)
+		{{if .Route.Resp}}resp, {{end}}err := l.{{.Route.Name}}({{.Route.ReqArg}})
+		if err != nil {
+			httpx.ErrorCtx(r.Context(), w, err)
+		} else {
+			{{if .Route.Resp}}httpx.OkJsonCtx(r.Context(), w, resp){{else}}httpx.Ok(w){{end}}
+		}
+	}
+}
+`)
+
+var logicTemplate = newTemplate("logic", `// Code scaffolded from api definitions. Safe to edit.
+
+package {{.Package}}
+
+import (
+	"context"
+
+	"{{.Module}}/internal/svc"
+{{- if .Route.Types}}
+	"{{.Module}}/internal/types"
+{{- end}}
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+type {{.Route.Name}}Logic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+{{with .Route.Doc}}// {{.}}
+{{end -}}
+func New{{.Route.Name}}Logic(ctx context.Context, svcCtx *svc.ServiceContext) *{{.Route.Name}}Logic {
+	return &{{.Route.Name}}Logic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *{{.Route.Name}}Logic) {{.Route.Name}}({{with .Route.ReqParam}}req {{.}}{{end}}) ({{with .Route.RespParam}}resp {{.}}, {{end}}err error) {
+	// todo: add your logic here and delete this line
+
+	return
+}
+`)
+
+var middlewareTemplate = newTemplate("middleware", `// Code scaffolded from api definitions. Safe to edit.
+
+package middleware
+
+import "net/http"
+
+type {{.}}Middleware struct {
+}
+
+func New{{.}}Middleware() *{{.}}Middleware {
+	return &{{.}}Middleware{}
+}
+
+func (m *{{.}}Middleware) Handle(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// todo: add your middleware logic here
+
+		next(w, r)
+	}
+}
+`)
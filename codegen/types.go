@@ -0,0 +1,263 @@
+// Package codegen generates Go source code from api syntax trees.
+package codegen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"sort"
+	"strconv"
+
+	"github.com/zeromicro/api-ast/ast"
+	"github.com/zeromicro/api-ast/token"
+)
+
+// A Config controls the generated Go source.
+type Config struct {
+	Package string // Go package name; default: "types"
+	Module  string // import path of the project root, see Config.Server
+
+	// Imports maps the package names of qualified types (such as
+	// time.Time) to import paths. A package name that is not listed
+	// is used as import path, which is right for standard library
+	// packages such as time.
+	Imports map[string]string
+}
+
+// Types returns the gofmt'ed Go declarations of all types declared in
+// files. It calls Config.Types with default settings and the given
+// package name.
+func Types(pkg string, files ...*ast.File) ([]byte, error) {
+	return (&Config{Package: pkg}).Types(files...)
+}
+
+// Types returns the gofmt'ed Go declarations of all types declared in
+// files, in source order. Struct fields and their tags are carried over
+// verbatim, as are doc and line comments. The result is stable for a
+// given input.
+func (cfg *Config) Types(files ...*ast.File) ([]byte, error) {
+	g := cfg.newGenerator()
+	for _, f := range files {
+		for _, d := range f.Decls {
+			if d, ok := d.(*ast.GenDecl); ok && d.Tok == token.TYPE {
+				g.typeDecl(d)
+			}
+		}
+	}
+	return g.source()
+}
+
+// ----------------------------------------------------------------------------
+// Generator
+
+type generator struct {
+	cfg     *Config
+	buf     bytes.Buffer      // declarations
+	imports map[string]string // package name -> import path, for used packages
+	types   map[string]bool   // declared types
+	err     error             // first error
+
+	// if set, references to the types in declared are qualified with
+	// the package name qualifier
+	qualifier string
+	declared  map[string]bool
+}
+
+func (cfg *Config) newGenerator() *generator {
+	return &generator{
+		cfg:     cfg,
+		imports: make(map[string]string),
+		types:   make(map[string]bool),
+	}
+}
+
+func (g *generator) errorf(format string, args ...interface{}) {
+	if g.err == nil {
+		g.err = fmt.Errorf(format, args...)
+	}
+}
+
+func (g *generator) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+// source returns the gofmt'ed source file.
+func (g *generator) source() ([]byte, error) {
+	if g.err != nil {
+		return nil, g.err
+	}
+
+	pkg := g.cfg.Package
+	if pkg == "" {
+		pkg = "types"
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated from api definitions. DO NOT EDIT.\n\npackage %s\n", pkg)
+	if len(g.imports) > 0 {
+		paths := make([]string, 0, len(g.imports))
+		for _, path := range g.imports {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+		buf.WriteString("\nimport (\n")
+		for _, path := range paths {
+			fmt.Fprintf(&buf, "\t%s\n", strconv.Quote(path))
+		}
+		buf.WriteString(")\n")
+	}
+	buf.Write(g.buf.Bytes())
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("codegen: invalid Go source: %v", err)
+	}
+	return src, nil
+}
+
+func (g *generator) comment(cg *ast.CommentGroup) {
+	if cg == nil {
+		return
+	}
+	for _, c := range cg.List {
+		g.printf("%s\n", c.Text)
+	}
+}
+
+func (g *generator) lineComment(cg *ast.CommentGroup) {
+	if cg == nil {
+		return
+	}
+	for _, c := range cg.List {
+		g.printf(" %s", c.Text)
+	}
+}
+
+// ----------------------------------------------------------------------------
+// Declarations
+
+func (g *generator) typeDecl(d *ast.GenDecl) {
+	if !d.Lparen.IsValid() {
+		for _, s := range d.Specs {
+			g.buf.WriteByte('\n')
+			if s, ok := s.(*ast.TypeSpec); ok {
+				g.comment(s.Doc)
+				g.printf("type ")
+				g.typeSpec(s)
+			}
+		}
+		return
+	}
+
+	g.buf.WriteByte('\n')
+	g.comment(d.Doc)
+	g.printf("type (\n")
+	for i, s := range d.Specs {
+		if s, ok := s.(*ast.TypeSpec); ok {
+			if i > 0 {
+				g.buf.WriteByte('\n')
+			}
+			g.comment(s.Doc)
+			g.typeSpec(s)
+		}
+	}
+	g.printf(")\n")
+}
+
+func (g *generator) typeSpec(s *ast.TypeSpec) {
+	name := s.Name.Name
+	if g.types[name] {
+		g.errorf("codegen: type %s redeclared", name)
+	}
+	g.types[name] = true
+
+	g.printf("%s ", name)
+	g.expr(s.Type)
+	g.lineComment(s.Comment)
+	g.buf.WriteByte('\n')
+}
+
+func (g *generator) fieldList(list *ast.FieldList) {
+	g.printf("struct {\n")
+	if list != nil {
+		for _, f := range list.List {
+			g.comment(f.Doc)
+			for i, name := range f.Names {
+				if i > 0 {
+					g.printf(", ")
+				}
+				g.printf("%s", name.Name)
+			}
+			if len(f.Names) > 0 {
+				g.printf(" ")
+			}
+			g.expr(f.Type)
+			if f.Tag != nil {
+				g.printf(" %s", f.Tag.Value)
+			}
+			g.lineComment(f.Comment)
+			g.buf.WriteByte('\n')
+		}
+	}
+	g.printf("}")
+}
+
+// ----------------------------------------------------------------------------
+// Types
+
+// expr prints the Go type corresponding to the api type x.
+func (g *generator) expr(x ast.Expr) {
+	switch x := x.(type) {
+	case *ast.Ident:
+		if g.qualifier != "" && g.declared[x.Name] {
+			g.printf("%s.", g.qualifier)
+		}
+		g.printf("%s", x.Name)
+
+	case *ast.SelectorExpr:
+		pkg, ok := x.X.(*ast.Ident)
+		if !ok {
+			g.errorf("codegen: unsupported qualified type %T", x.X)
+			return
+		}
+		path := pkg.Name
+		if p, ok := g.cfg.Imports[pkg.Name]; ok {
+			path = p
+		}
+		g.imports[pkg.Name] = path
+		g.printf("%s.%s", pkg.Name, x.Sel.Name)
+
+	case *ast.StarExpr:
+		g.printf("*")
+		g.expr(x.X)
+
+	case *ast.ArrayType:
+		g.printf("[")
+		if x.Len != nil {
+			g.expr(x.Len)
+		}
+		g.printf("]")
+		g.expr(x.Elt)
+
+	case *ast.MapType:
+		g.printf("map[")
+		g.expr(x.Key)
+		g.printf("]")
+		g.expr(x.Value)
+
+	case *ast.StructType:
+		g.fieldList(x.Fields)
+
+	case *ast.ParenExpr:
+		g.expr(x.X)
+
+	case *ast.BasicLit:
+		g.printf("%s", x.Value) // array length
+
+	case nil:
+		g.errorf("codegen: missing type")
+
+	default:
+		g.errorf("codegen: unsupported type %T", x)
+	}
+}
@@ -0,0 +1,64 @@
+package typescript_test
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/zeromicro/api-ast/codegen/typescript"
+	"github.com/zeromicro/api-ast/parser"
+	"github.com/zeromicro/api-ast/token"
+)
+
+func ExampleClient() {
+	fset := token.NewFileSet() // positions are relative to fset
+
+	src := `syntax = "v1"
+
+type Req {
+	Id    int64  ` + "`path:\"id\"`" + `
+	Page  int    ` + "`form:\"page,optional\"`" + `
+	Token string ` + "`header:\"token\"`" + `
+	Name  string ` + "`json:\"name\"`" + `
+}
+
+service user-api {
+	@handler updateUser
+	put /user/:id (Req) returns (Req)
+}
+`
+
+	f, err := parser.ParseFile(fset, "user.api", src, parser.ParseComments)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	types, err := typescript.Types(f)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Print(string(types))
+
+	client, err := typescript.Client(f)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	// print the generated function only
+	i := bytes.Index(client, []byte("export function"))
+	fmt.Print(string(client[i:]))
+
+	// output:
+	// // Code generated from api definitions. DO NOT EDIT.
+	//
+	// export interface Req {
+	// 	id: number;
+	// 	page?: number;
+	// 	token: string;
+	// 	name: string;
+	// }
+	// export function updateUser(req: Req): Promise<Req> {
+	// 	return request<Req>("PUT", `/user/${encodeURIComponent(String(req.id))}`, { page: req.page }, { token: req.token }, { name: req.name });
+	// }
+}
@@ -0,0 +1,565 @@
+// Package typescript generates TypeScript type definitions and a typed,
+// fetch based client from api syntax trees.
+package typescript
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/zeromicro/api-ast/ast"
+	"github.com/zeromicro/api-ast/tag"
+	"github.com/zeromicro/api-ast/types"
+)
+
+// A Config controls the generated TypeScript source.
+type Config struct {
+	// TypesModule is the module specifier from which the client imports
+	// the type definitions; default: "./types".
+	TypesModule string
+}
+
+// Types returns the TypeScript type definitions for the types declared
+// in files, using the default configuration.
+func Types(files ...*ast.File) ([]byte, error) {
+	return (&Config{}).Types(files...)
+}
+
+// Client returns the TypeScript client for the services declared in
+// files, using the default configuration.
+func Client(files ...*ast.File) ([]byte, error) {
+	return (&Config{}).Client(files...)
+}
+
+// Types returns the TypeScript type definitions for the types declared
+// in files, in source order. Struct types become interfaces whose
+// properties are named after the json tag of a field, or else after its
+// form, path or header tag, or else after the field itself; optional
+// fields (see tag.Tags.Optional) are optional properties. Embedded
+// structs are flattened. Other types become type aliases.
+func (cfg *Config) Types(files ...*ast.File) ([]byte, error) {
+	g, err := newGenerator(files)
+	if err != nil {
+		return nil, err
+	}
+	g.printf("// Code generated from api definitions. DO NOT EDIT.\n")
+	for _, s := range g.info.Specs {
+		g.printf("\n")
+		g.comment(s.Doc, "")
+		if g.info.Struct(s.Name) != nil {
+			g.printf("export interface %s ", s.Name.Name)
+			g.fields(s.Name, "")
+			g.printf("\n")
+		} else {
+			g.printf("export type %s = ", s.Name.Name)
+			g.expr(s.Type, "")
+			g.printf(";\n")
+		}
+	}
+	return g.source()
+}
+
+// ----------------------------------------------------------------------------
+// Generator
+
+type generator struct {
+	info *types.Info
+	buf  bytes.Buffer
+	err  error // first error
+}
+
+func newGenerator(files []*ast.File) (*generator, error) {
+	info, err := types.NewInfo(files...)
+	if err != nil {
+		return nil, fmt.Errorf("typescript: %v", err)
+	}
+	return &generator{info: info}, nil
+}
+
+func (g *generator) errorf(format string, args ...interface{}) {
+	if g.err == nil {
+		g.err = fmt.Errorf("typescript: "+format, args...)
+	}
+}
+
+func (g *generator) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+func (g *generator) source() ([]byte, error) {
+	if g.err != nil {
+		return nil, g.err
+	}
+	return g.buf.Bytes(), nil
+}
+
+// comment prints cg as a JSDoc comment.
+func (g *generator) comment(cg *ast.CommentGroup, indent string) {
+	text := strings.TrimSpace(cg.Text())
+	if text == "" {
+		return
+	}
+	lines := strings.Split(text, "\n")
+	if len(lines) == 1 {
+		g.printf("%s/** %s */\n", indent, sanitize(lines[0]))
+		return
+	}
+	g.printf("%s/**\n", indent)
+	for _, line := range lines {
+		g.printf("%s *%s\n", indent, strings.TrimRight(" "+sanitize(line), " "))
+	}
+	g.printf("%s */\n", indent)
+}
+
+// sanitize makes sure s does not terminate a comment.
+func sanitize(s string) string {
+	return strings.ReplaceAll(s, "*/", "* /")
+}
+
+// fields prints the properties of the struct type underlying x.
+func (g *generator) fields(x ast.Expr, indent string) {
+	list, err := g.info.Fields(x)
+	if err != nil {
+		g.errorf("%v", err)
+		return
+	}
+	g.printf("{\n")
+	for _, f := range list {
+		name := f.PropertyName()
+		if name == "" {
+			continue
+		}
+		doc := f.Field.Doc
+		if doc == nil {
+			doc = f.Field.Comment
+		}
+		g.comment(doc, indent+"\t")
+		opt := ""
+		if f.Tags.Optional() {
+			opt = "?"
+		}
+		g.printf("%s\t%s%s: ", indent, property(name), opt)
+		g.expr(f.Type, indent+"\t")
+		g.printf(";\n")
+	}
+	g.printf("%s}", indent)
+}
+
+// property returns name as property name, quoted if necessary.
+func property(name string) string {
+	if isName(name) {
+		return name
+	}
+	return strconv.Quote(name)
+}
+
+// access returns the expression selecting the property name of x.
+func access(x, name string) string {
+	if isName(name) {
+		return x + "." + name
+	}
+	return x + "[" + strconv.Quote(name) + "]"
+}
+
+var basicTypes = map[string]string{
+	"bool":        "boolean",
+	"string":      "string",
+	"int":         "number",
+	"int8":        "number",
+	"int16":       "number",
+	"int32":       "number",
+	"int64":       "number",
+	"uint":        "number",
+	"uint8":       "number",
+	"uint16":      "number",
+	"uint32":      "number",
+	"uint64":      "number",
+	"uintptr":     "number",
+	"byte":        "number",
+	"rune":        "number",
+	"float32":     "number",
+	"float64":     "number",
+	"complex64":   "number",
+	"complex128":  "number",
+	"any":         "any",
+	"interface{}": "any",
+}
+
+// expr prints the TypeScript type for the api type x, which encodes
+// to JSON as values of type x do in Go.
+func (g *generator) expr(x ast.Expr, indent string) {
+	switch x := x.(type) {
+	case *ast.Ident:
+		if t, ok := basicTypes[x.Name]; ok {
+			g.printf("%s", t)
+		} else if g.info.Lookup(x.Name) != nil {
+			g.printf("%s", x.Name)
+		} else {
+			g.errorf("undeclared type %s", x.Name)
+		}
+
+	case *ast.SelectorExpr:
+		if pkg, ok := x.X.(*ast.Ident); ok && pkg.Name == "time" && x.Sel.Name == "Time" {
+			g.printf("string")
+		} else {
+			g.printf("any")
+		}
+
+	case *ast.StarExpr:
+		g.expr(x.X, indent)
+
+	case *ast.ArrayType:
+		if elt, ok := x.Elt.(*ast.Ident); ok && (elt.Name == "byte" || elt.Name == "uint8") {
+			g.printf("string") // base64
+			return
+		}
+		g.expr(x.Elt, indent)
+		g.printf("[]")
+
+	case *ast.MapType:
+		g.printf("{ [key: string]: ")
+		g.expr(x.Value, indent)
+		g.printf(" }")
+
+	case *ast.StructType:
+		g.fields(x, indent)
+
+	case *ast.ParenExpr:
+		g.expr(x.X, indent)
+
+	case nil:
+		g.errorf("missing type")
+
+	default:
+		g.errorf("unsupported type %T", x)
+	}
+}
+
+// ----------------------------------------------------------------------------
+// Client
+
+// Client returns a TypeScript client with one exported function per
+// route of the services declared in files. The function is named after
+// the route's @handler and takes the request type, if any, as argument:
+// path parameters are substituted from fields with path tags, the query
+// string is built from fields with form tags, headers from fields with
+// header tags and the JSON body from fields with json tags. The
+// function returns a promise of the response type.
+//
+// The generated module exports a mutable config object with the base
+// URL, default headers and fetch implementation used for all requests.
+func (cfg *Config) Client(files ...*ast.File) ([]byte, error) {
+	g, err := newGenerator(files)
+	if err != nil {
+		return nil, err
+	}
+
+	var routes []*route
+	names := make(map[string]bool)
+	used := make(map[string]bool) // declared types referenced by routes
+	for _, f := range files {
+		for _, d := range f.Decls {
+			svc, ok := d.(*ast.Service)
+			if !ok {
+				continue
+			}
+			prefix := svc.AtServer.Value("prefix")
+			for _, sr := range svc.ServiceApi.ServiceRoute {
+				r := g.newRoute(prefix, sr)
+				if r == nil {
+					return nil, g.err
+				}
+				if names[r.name] {
+					return nil, fmt.Errorf("typescript: duplicate handler %s", r.name)
+				}
+				names[r.name] = true
+				for _, x := range []*ast.ParenExpr{sr.Route.Req, sr.Route.Resp} {
+					if x != nil {
+						ast.Inspect(x, func(n ast.Node) bool {
+							if id, ok := n.(*ast.Ident); ok && g.info.Lookup(id.Name) != nil {
+								used[id.Name] = true
+							}
+							return true
+						})
+					}
+				}
+				routes = append(routes, r)
+			}
+		}
+	}
+
+	module := cfg.TypesModule
+	if module == "" {
+		module = "./types"
+	}
+	g.printf("// Code generated from api definitions. DO NOT EDIT.\n\n")
+	if len(used) > 0 {
+		list := make([]string, 0, len(used))
+		for name := range used {
+			list = append(list, name)
+		}
+		sort.Strings(list)
+		g.printf("import type { %s } from %s;\n\n", strings.Join(list, ", "), strconv.Quote(module))
+	}
+	g.printf("%s", runtime)
+	for _, r := range routes {
+		g.printf("\n")
+		g.route(r)
+	}
+	return g.source()
+}
+
+// runtime is the part of the client that is independent of the routes.
+const runtime = `export interface ClientConfig {
+	/** baseUrl is prepended to all request paths. */
+	baseUrl: string;
+	/** headers are sent with every request. */
+	headers?: Record<string, string>;
+	/** fetch replaces the global fetch function. */
+	fetch?: typeof fetch;
+}
+
+export const config: ClientConfig = { baseUrl: "" };
+
+/** An ApiError reports a response with a non-2xx status code. */
+export class ApiError extends Error {
+	constructor(readonly status: number, readonly body: string) {
+		super(` + "`request failed with status ${status}`" + `);
+	}
+}
+
+async function request<T>(
+	method: string,
+	path: string,
+	query?: Record<string, unknown>,
+	headers?: Record<string, unknown>,
+	body?: unknown,
+): Promise<T> {
+	let url = config.baseUrl + path;
+	if (query) {
+		const params = new URLSearchParams();
+		for (const [key, value] of Object.entries(query)) {
+			for (const v of Array.isArray(value) ? value : [value]) {
+				if (v !== undefined && v !== null) {
+					params.append(key, String(v));
+				}
+			}
+		}
+		const qs = params.toString();
+		if (qs) {
+			url += "?" + qs;
+		}
+	}
+	const h: Record<string, string> = { ...config.headers };
+	for (const [key, value] of Object.entries(headers ?? {})) {
+		if (value !== undefined && value !== null) {
+			h[key] = String(value);
+		}
+	}
+	if (body !== undefined) {
+		h["Content-Type"] = "application/json";
+	}
+	const res = await (config.fetch ?? fetch)(url, {
+		method,
+		headers: h,
+		body: body === undefined ? undefined : JSON.stringify(body),
+	});
+	const text = await res.text();
+	if (!res.ok) {
+		throw new ApiError(res.status, text);
+	}
+	return (text ? JSON.parse(text) : undefined) as T;
+}
+`
+
+type route struct {
+	sr     *ast.ServiceRoute
+	name   string // function name
+	method string // HTTP method
+	path   string // TypeScript expression for the path
+	req    string // TypeScript request type; or ""
+	resp   string // TypeScript response type
+	query  []param
+	header []param
+	body   string // TypeScript expression for the body; or ""
+}
+
+// A param maps a request property to a query parameter or header.
+type param struct {
+	name string // parameter name
+	prop string // request property name
+}
+
+// newRoute returns the client function for sr, or nil in case of errors.
+func (g *generator) newRoute(prefix string, sr *ast.ServiceRoute) *route {
+	rt := sr.Route
+	r := &route{
+		sr:     sr,
+		name:   functionName(sr),
+		method: strings.ToUpper(rt.Method.Name),
+		resp:   "void",
+	}
+	if r.name == "" {
+		g.errorf("cannot derive function name for %s %s", rt.Method.Name, rt.Path.Name)
+		return nil
+	}
+
+	// request
+	paths := make(map[string]string) // path parameter -> property
+	if rt.Req != nil {
+		r.req = g.typeString(rt.Req.X)
+		if g.info.Struct(rt.Req.X) == nil {
+			r.body = "req"
+		} else {
+			list, err := g.info.Fields(rt.Req.X)
+			if err != nil {
+				g.errorf("%v", err)
+				return nil
+			}
+			var body []string
+			for _, f := range list {
+				prop := f.PropertyName()
+				if prop == "" {
+					continue
+				}
+				if t := f.Tags.Get(tag.Path); t != nil {
+					paths[t.Name] = prop
+				}
+				if t := f.Tags.Get(tag.Form); t != nil {
+					r.query = append(r.query, param{t.Name, prop})
+				}
+				if t := f.Tags.Get(tag.Header); t != nil {
+					r.header = append(r.header, param{t.Name, prop})
+				}
+				if t := f.Tags.Get(tag.JSON); t != nil {
+					body = append(body, property(prop)+": "+access("req", prop))
+				}
+			}
+			if len(body) > 0 {
+				r.body = "{ " + strings.Join(body, ", ") + " }"
+			}
+		}
+	}
+	if rt.Resp != nil {
+		r.resp = g.typeString(rt.Resp.X)
+	}
+
+	// path
+	path := prefix + rt.Path.Name
+	var b strings.Builder
+	hasParams := false
+	for i, seg := range strings.Split(path, "/") {
+		if i > 0 {
+			b.WriteByte('/')
+		}
+		if strings.HasPrefix(seg, ":") {
+			prop, ok := paths[seg[1:]]
+			if !ok {
+				g.errorf("%s %s: no request field with tag path:%q", rt.Method.Name, path, seg[1:])
+				return nil
+			}
+			fmt.Fprintf(&b, "${encodeURIComponent(String(%s))}", access("req", prop))
+			hasParams = true
+			continue
+		}
+		b.WriteString(strings.NewReplacer("\\", "\\\\", "`", "\\`", "$", "\\$").Replace(seg))
+	}
+	if hasParams {
+		r.path = "`" + b.String() + "`"
+	} else {
+		r.path = strconv.Quote(path)
+	}
+
+	if g.err != nil {
+		return nil
+	}
+	return r
+}
+
+// typeString returns the TypeScript type for x.
+func (g *generator) typeString(x ast.Expr) string {
+	var buf bytes.Buffer
+	buf, g.buf = g.buf, buf
+	g.expr(x, "")
+	buf, g.buf = g.buf, buf
+	return buf.String()
+}
+
+// functionName returns the client function name for sr: the @handler
+// name without "Handler" suffix, or else a name derived from the method
+// and path, in lower camel case.
+func functionName(sr *ast.ServiceRoute) string {
+	if h := sr.AtHandler.Text(); h != "" {
+		h = strings.TrimSuffix(h, "Handler")
+		if !isName(h) {
+			return ""
+		}
+		return strings.ToLower(h[:1]) + h[1:]
+	}
+	name := strings.ToLower(sr.Route.Method.Name)
+	for _, seg := range strings.FieldsFunc(sr.Route.Path.Name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != ':'
+	}) {
+		if strings.HasPrefix(seg, ":") {
+			if seg = seg[1:]; seg == "" {
+				continue
+			}
+			seg = "by" + strings.ToUpper(seg[:1]) + seg[1:]
+		}
+		name += strings.ToUpper(seg[:1]) + seg[1:]
+	}
+	if !isName(name) {
+		return ""
+	}
+	return name
+}
+
+func (g *generator) route(r *route) {
+	g.comment(docComment(r.sr), "")
+	arg := ""
+	if r.req != "" {
+		arg = "req: " + r.req
+	}
+	g.printf("export function %s(%s): Promise<%s> {\n", r.name, arg, r.resp)
+	g.printf("\treturn request<%s>(%s, %s", r.resp, strconv.Quote(r.method), r.path)
+	args := []string{params(r.query), params(r.header), r.body}
+	for len(args) > 0 && args[len(args)-1] == "" {
+		args = args[:len(args)-1] // trim trailing undefined arguments
+	}
+	for _, a := range args {
+		if a == "" {
+			a = "undefined"
+		}
+		g.printf(", %s", a)
+	}
+	g.printf(");\n}\n")
+}
+
+// params returns the object literal for list, or "".
+func params(list []param) string {
+	if len(list) == 0 {
+		return ""
+	}
+	elems := make([]string, len(list))
+	for i, p := range list {
+		elems[i] = property(p.name) + ": " + access("req", p.prop)
+	}
+	return "{ " + strings.Join(elems, ", ") + " }"
+}
+
+// docComment returns the @doc value of sr as comment, or nil.
+func docComment(sr *ast.ServiceRoute) *ast.CommentGroup {
+	if doc := sr.AtDoc.Text(); doc != "" {
+		return &ast.CommentGroup{List: []*ast.Comment{{Text: "// " + doc}}}
+	}
+	return nil
+}
+
+// isName reports whether name is a TypeScript identifier, which unlike a
+// Go one may contain '$'.
+func isName(name string) bool {
+	return types.IsIdentifier(strings.ReplaceAll(name, "$", "_"))
+}
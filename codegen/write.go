@@ -0,0 +1,114 @@
+package codegen
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// SumFile is the name of the manifest file written by WriteFiles. It
+// records the checksum of every file as last generated.
+const SumFile = ".codegen.sum"
+
+// WriteFiles writes files, keyed by slash-separated path, below the
+// directory dir. A file that exists already is only overwritten if its
+// content is still the content recorded in the manifest SumFile, that
+// is, if it has not been edited since it was generated; otherwise it is
+// left alone and reported in skipped. Files whose content does not
+// change are reported in neither list.
+func WriteFiles(dir string, files map[string][]byte) (written, skipped []string, err error) {
+	sums, err := readSums(filepath.Join(dir, SumFile))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		src := files[name]
+		filename := filepath.Join(dir, filepath.FromSlash(name))
+		old, err := os.ReadFile(filename)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			// write new file
+		case err != nil:
+			return written, skipped, err
+		case bytes.Equal(old, src):
+			sums[name] = checksum(src)
+			continue
+		case sums[name] != checksum(old):
+			skipped = append(skipped, name) // edited by the user
+			continue
+		}
+
+		if err := os.MkdirAll(filepath.Dir(filename), 0o755); err != nil {
+			return written, skipped, err
+		}
+		if err := os.WriteFile(filename, src, 0o644); err != nil {
+			return written, skipped, err
+		}
+		sums[name] = checksum(src)
+		written = append(written, name)
+	}
+
+	return written, skipped, writeSums(filepath.Join(dir, SumFile), sums)
+}
+
+func checksum(src []byte) string {
+	sum := sha256.Sum256(src)
+	return hex.EncodeToString(sum[:])
+}
+
+// readSums reads a manifest of "path checksum" lines. A missing manifest
+// is empty.
+func readSums(filename string) (map[string]string, error) {
+	sums := make(map[string]string)
+	data, err := os.ReadFile(filename)
+	if errors.Is(err, fs.ErrNotExist) {
+		return sums, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	s := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; s.Scan(); line++ {
+		fields := strings.Fields(s.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("%s:%d: malformed line", filename, line)
+		}
+		sums[fields[0]] = fields[1]
+	}
+	return sums, s.Err()
+}
+
+func writeSums(filename string, sums map[string]string) error {
+	names := make([]string, 0, len(sums))
+	for name := range sums {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	for _, name := range names {
+		fmt.Fprintf(&buf, "%s %s\n", name, sums[name])
+	}
+	if err := os.MkdirAll(filepath.Dir(filename), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(filename, buf.Bytes(), 0o644)
+}
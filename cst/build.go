@@ -0,0 +1,211 @@
+package cst
+
+import (
+	"bytes"
+	"sort"
+	"unicode/utf8"
+
+	"github.com/zeromicro/api-ast/ast"
+	"github.com/zeromicro/api-ast/token"
+)
+
+// A RawToken is a token as returned by scanner.Scanner.Scan.
+type RawToken struct {
+	Pos token.Pos
+	Tok token.Token
+	Lit string
+}
+
+// New returns the concrete syntax tree for the source src of file.
+// The list toks must hold every token returned by scanner.Scanner.Scan,
+// with comments (scanner.ScanComments) and up to and including EOF; f is
+// the abstract syntax tree parsed from the same tokens. Usually New is
+// called by parser.ParseConcrete.
+func New(file *token.File, src []byte, f *ast.File, toks []RawToken) *File {
+	cf := &File{
+		AST:   f,
+		file:  file,
+		nodes: make(map[ast.Node]*Node),
+	}
+	cf.Tokens = tokenize(file, src, toks)
+
+	b := builder{file: cf}
+	cf.Root = b.node(f, token.Pos(file.Base()+file.Size()+1))
+	return cf
+}
+
+// tokenize converts toks into tokens with attached trivia. Every byte of
+// src ends up either in a token's text or in its leading trivia.
+func tokenize(file *token.File, src []byte, toks []RawToken) []*Token {
+	var list []*Token
+	var trivia []Trivia
+	prev := 0 // end offset of the previous token or comment
+	leadPos := file.Pos(0)
+
+	for _, x := range toks {
+		offs := file.Offset(x.Pos)
+		if offs < prev {
+			offs = prev // be conservative and guard against bad positions
+		}
+		trivia = appendSpace(trivia, file, src, prev, offs)
+		end := tokenEnd(src, offs, x)
+		text := string(src[offs:end])
+		prev = end
+
+		if x.Tok == token.COMMENT {
+			trivia = append(trivia, Trivia{Kind: Comment, Pos: file.Pos(offs), Text: text})
+			continue
+		}
+
+		t := &Token{
+			Leading:  trivia,
+			TokPos:   file.Pos(offs),
+			Tok:      x.Tok,
+			Text:     text,
+			Implicit: x.Tok == token.SEMICOLON && text == "",
+			leadPos:  leadPos,
+			origLead: leading(trivia),
+			origText: text,
+		}
+		list = append(list, t)
+		trivia = nil
+		leadPos = file.Pos(end)
+
+		if x.Tok == token.EOF {
+			break
+		}
+	}
+
+	// make sure the token list ends with EOF and covers all of src
+	// (an incomplete token list is a caller error, but keep the text)
+	if n := len(list); n == 0 || list[n-1].Tok != token.EOF {
+		trivia = appendSpace(trivia, file, src, prev, len(src))
+		list = append(list, &Token{
+			Leading:  trivia,
+			TokPos:   file.Pos(len(src)),
+			Tok:      token.EOF,
+			leadPos:  leadPos,
+			origLead: leading(trivia),
+		})
+	}
+
+	return list
+}
+
+// tokenEnd returns the offset immediately after the token x starting
+// at offs. The literal returned by the scanner cannot be used in all
+// cases: carriage returns are stripped from raw strings and comments,
+// and implicit semicolons have no source text at all.
+func tokenEnd(src []byte, offs int, x RawToken) int {
+	end := offs
+	switch {
+	case x.Tok == token.EOF:
+		// nothing to do
+	case x.Tok == token.SEMICOLON:
+		if x.Lit == ";" {
+			end++
+		}
+		// the newline of an implicit semicolon is trivia
+	case x.Tok == token.COMMENT:
+		if bytes.HasPrefix(src[offs:], []byte("//")) {
+			if i := bytes.IndexByte(src[offs:], '\n'); i >= 0 {
+				end += i
+			} else {
+				end = len(src)
+			}
+		} else if i := bytes.Index(src[offs+2:], []byte("*/")); i >= 0 {
+			end += 2 + i + 2
+		} else {
+			end = len(src)
+		}
+	case x.Tok == token.STRING && len(x.Lit) > 0 && x.Lit[0] == '`':
+		if i := bytes.IndexByte(src[offs+1:], '`'); i >= 0 {
+			end += 1 + i + 1
+		} else {
+			end = len(src)
+		}
+	case x.Tok == token.ILLEGAL:
+		_, w := utf8.DecodeRune(src[offs:])
+		end += w
+	case x.Lit != "":
+		end += len(x.Lit)
+	default:
+		end += len(x.Tok.String())
+	}
+	if end > len(src) {
+		end = len(src)
+	}
+	return end
+}
+
+// appendSpace splits src[from:to], which must not contain comments, into
+// Whitespace and Newline trivia.
+func appendSpace(list []Trivia, file *token.File, src []byte, from, to int) []Trivia {
+	for i := from; i < to; {
+		if src[i] == '\n' {
+			list = append(list, Trivia{Kind: Newline, Pos: file.Pos(i), Text: "\n"})
+			i++
+			continue
+		}
+		j := i
+		for j < to && src[j] != '\n' {
+			j++
+		}
+		list = append(list, Trivia{Kind: Whitespace, Pos: file.Pos(i), Text: string(src[i:j])})
+		i = j
+	}
+	return list
+}
+
+// ----------------------------------------------------------------------------
+// Tree construction
+
+type builder struct {
+	file *File
+	i    int // index of the next unassigned token
+}
+
+// node builds the concrete node for n. It claims all tokens before end
+// that are not claimed by one of the children of n. Syntax tree nodes
+// with inaccurate positions therefore never lose tokens; the tokens are
+// simply assigned to a parent node.
+func (b *builder) node(n ast.Node, end token.Pos) *Node {
+	cn := &Node{AST: n}
+	toks := b.file.Tokens
+	for _, c := range children(n) {
+		for b.i < len(toks) && toks[b.i].TokPos < c.Pos() {
+			cn.Children = append(cn.Children, toks[b.i])
+			b.i++
+		}
+		if cc := b.node(c, c.End()); len(cc.Children) > 0 {
+			cn.Children = append(cn.Children, cc)
+		}
+	}
+	for b.i < len(toks) && toks[b.i].TokPos < end {
+		cn.Children = append(cn.Children, toks[b.i])
+		b.i++
+	}
+	if len(cn.Children) > 0 {
+		b.file.nodes[n] = cn
+	}
+	return cn
+}
+
+// children returns the direct children of n in source order. Comments
+// are trivia and thus excluded.
+func children(n ast.Node) []ast.Node {
+	var list []ast.Node
+	ast.Inspect(n, func(c ast.Node) bool {
+		if c == n {
+			return true
+		}
+		if c != nil && c.Pos().IsValid() {
+			if _, isComment := c.(*ast.CommentGroup); !isComment {
+				list = append(list, c)
+			}
+		}
+		return false
+	})
+	sort.SliceStable(list, func(i, j int) bool { return list[i].Pos() < list[j].Pos() })
+	return list
+}
@@ -0,0 +1,231 @@
+// Package cst declares the types used to represent a lossless concrete
+// syntax tree for api source files.
+//
+// In contrast to the abstract syntax tree of package ast, a concrete
+// syntax tree keeps every token of the source, including the semicolons
+// inserted by the scanner, together with the white space, newlines and
+// comments (trivia) in front of it. Printing an unmodified tree
+// reproduces the original source byte for byte, and modifying single
+// tokens yields minimal edits instead of a reformatted file.
+package cst
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/zeromicro/api-ast/ast"
+	"github.com/zeromicro/api-ast/token"
+)
+
+// ----------------------------------------------------------------------------
+// Trivia
+
+// A TriviaKind describes the kind of source text between two tokens.
+type TriviaKind int
+
+const (
+	Whitespace TriviaKind = iota // blanks, tabs, carriage returns, or a leading byte order mark
+	Newline                      // a single '\n'
+	Comment                      // a //-style or /*-style comment
+)
+
+var triviaKinds = [...]string{
+	Whitespace: "Whitespace",
+	Newline:    "Newline",
+	Comment:    "Comment",
+}
+
+func (k TriviaKind) String() string {
+	if 0 <= k && int(k) < len(triviaKinds) {
+		return triviaKinds[k]
+	}
+	return "TriviaKind(?)"
+}
+
+// A Trivia is a piece of source text that is not a token.
+type Trivia struct {
+	Kind TriviaKind
+	Pos  token.Pos // position of the first character
+	Text string    // exact source text
+}
+
+// ----------------------------------------------------------------------------
+// Tokens and nodes
+
+// An Element is either a *Token or a *Node.
+type Element interface {
+	Pos() token.Pos
+	element()
+}
+
+// A Token is a single token together with the trivia preceding it.
+type Token struct {
+	Leading  []Trivia    // white space, newlines and comments before the token
+	TokPos   token.Pos   // position of the token
+	Tok      token.Token // token kind
+	Text     string      // source text; empty for implicit semicolons and EOF
+	Implicit bool        // set for semicolons inserted by the scanner
+
+	// original source, for computing edits
+	leadPos  token.Pos // position of the first leading trivia
+	origLead string    // original leading trivia text
+	origText string    // original token text
+}
+
+// A Node groups the tokens belonging to a syntax tree node.
+type Node struct {
+	AST      ast.Node  // corresponding syntax tree node; *ast.File for the root
+	Children []Element // child nodes and tokens in source order
+}
+
+func (t *Token) Pos() token.Pos { return t.TokPos }
+func (t *Token) element()       {}
+
+// Pos returns the position of the first token of n, or token.NoPos if
+// n has no tokens.
+func (n *Node) Pos() token.Pos {
+	if len(n.Children) > 0 {
+		return n.Children[0].Pos()
+	}
+	return token.NoPos
+}
+func (n *Node) element() {}
+
+// Tokens returns the tokens of n and its descendants in source order.
+func (n *Node) Tokens() []*Token {
+	var list []*Token
+	var collect func(*Node)
+	collect = func(n *Node) {
+		for _, e := range n.Children {
+			switch e := e.(type) {
+			case *Token:
+				list = append(list, e)
+			case *Node:
+				collect(e)
+			}
+		}
+	}
+	collect(n)
+	return list
+}
+
+// ----------------------------------------------------------------------------
+// Files
+
+// A File is the concrete syntax tree of a single source file.
+type File struct {
+	AST    *ast.File // abstract syntax tree of the file
+	Root   *Node     // root node; Root.AST == AST
+	Tokens []*Token  // all tokens in source order; the last one is token.EOF
+
+	file  *token.File
+	nodes map[ast.Node]*Node
+}
+
+// Node returns the concrete node corresponding to the syntax tree node n,
+// or nil if n has no tokens in f.
+func (f *File) Node(n ast.Node) *Node {
+	return f.nodes[n]
+}
+
+// Token returns the token starting at pos, or nil. Implicit semicolons
+// are only returned if no other token starts at pos.
+func (f *File) Token(pos token.Pos) *Token {
+	var found *Token
+	for _, t := range f.Tokens {
+		if t.TokPos == pos {
+			if !t.Implicit {
+				return t
+			}
+			found = t
+		} else if t.TokPos > pos {
+			break
+		}
+	}
+	return found
+}
+
+// Replace replaces the text of all tokens of the syntax tree node n
+// with text. The trivia in front of n is kept, the trivia inside of n
+// is removed. Replace reports whether n was found in f.
+func (f *File) Replace(n ast.Node, text string) bool {
+	node := f.nodes[n]
+	if node == nil {
+		return false
+	}
+	toks := node.Tokens()
+	if len(toks) == 0 {
+		return false
+	}
+	toks[0].Text = text
+	for _, t := range toks[1:] {
+		t.Text = ""
+		t.Leading = nil
+	}
+	return true
+}
+
+// WriteTo writes the source text of f to w. If f was not modified, the
+// result is identical to the source it was parsed from.
+func (f *File) WriteTo(w io.Writer) (int64, error) {
+	var buf bytes.Buffer
+	for _, t := range f.Tokens {
+		buf.WriteString(leading(t.Leading))
+		buf.WriteString(t.Text)
+	}
+	return buf.WriteTo(w)
+}
+
+// Bytes returns the source text of f.
+func (f *File) Bytes() []byte {
+	var buf bytes.Buffer
+	_, _ = f.WriteTo(&buf)
+	return buf.Bytes()
+}
+
+func leading(list []Trivia) string {
+	switch len(list) {
+	case 0:
+		return ""
+	case 1:
+		return list[0].Text
+	}
+	var buf bytes.Buffer
+	for _, x := range list {
+		buf.WriteString(x.Text)
+	}
+	return buf.String()
+}
+
+// ----------------------------------------------------------------------------
+// Edits
+
+// An Edit replaces the source text in [Pos, End) with Text.
+type Edit struct {
+	Pos, End token.Pos
+	Text     string
+}
+
+// Edits returns the minimal list of edits, in source order, that turn
+// the original source of f into the current text of f. Adjacent edits
+// are merged.
+func (f *File) Edits() []Edit {
+	var list []Edit
+	add := func(pos, end token.Pos, text string) {
+		if n := len(list); n > 0 && list[n-1].End == pos {
+			list[n-1].End = end
+			list[n-1].Text += text
+			return
+		}
+		list = append(list, Edit{Pos: pos, End: end, Text: text})
+	}
+	for _, t := range f.Tokens {
+		if lead := leading(t.Leading); lead != t.origLead {
+			add(t.leadPos, t.TokPos, lead)
+		}
+		if t.Text != t.origText {
+			add(t.TokPos, t.TokPos+token.Pos(len(t.origText)), t.Text)
+		}
+	}
+	return list
+}
@@ -0,0 +1,52 @@
+package cst_test
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/zeromicro/api-ast/ast"
+	"github.com/zeromicro/api-ast/parser"
+	"github.com/zeromicro/api-ast/token"
+)
+
+func Example_edits() {
+	fset := token.NewFileSet() // positions are relative to fset
+
+	src := `syntax = "v1"
+
+// User is a user.
+type User {
+	Name   string   ` + "`json:\"name\"`" + ` // the name
+	Emails []string ` + "`json:\"emails\"`" + `
+}
+`
+
+	f, err := parser.ParseConcrete(fset, "user.api", src, 0)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	// rename the type User to Account
+	ast.Inspect(f.AST, func(n ast.Node) bool {
+		if spec, ok := n.(*ast.TypeSpec); ok {
+			f.Replace(spec.Name, "Account")
+		}
+		return true
+	})
+
+	for _, e := range f.Edits() {
+		fmt.Printf("%s-%s: %q\n", fset.Position(e.Pos), fset.Position(e.End), e.Text)
+	}
+	_, _ = f.WriteTo(os.Stdout)
+
+	// output:
+	// user.api:4:6-user.api:4:10: "Account"
+	// syntax = "v1"
+	//
+	// // User is a user.
+	// type Account {
+	// 	Name   string   `json:"name"` // the name
+	// 	Emails []string `json:"emails"`
+	// }
+}
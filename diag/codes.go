@@ -0,0 +1,266 @@
+package diag
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// A Code identifies a kind of diagnostic. Codes are stable: a code is
+// never reused for another kind of problem. The first digits group the
+// codes by their source: 00 for the scanner, 01 for the parser, 02 for
+// the checker and 03 for the formatter.
+type Code string
+
+// Scanner codes.
+const (
+	IllegalCharacter     Code = "E0001"
+	NotTerminated        Code = "E0002"
+	InvalidNumber        Code = "E0003"
+	InvalidEscape        Code = "E0004"
+	InvalidLineDirective Code = "E0005"
+)
+
+// Parser codes.
+const (
+	SyntaxError        Code = "E0100"
+	ExpectedToken      Code = "E0101"
+	ExpectedSemicolon  Code = "E0102"
+	ExpectedType       Code = "E0103"
+	ExpectedDecl       Code = "E0104"
+	InvalidImportPath  Code = "E0105"
+	ExpectedMethod     Code = "E0106"
+	ExpectedAnnotation Code = "E0107"
+)
+
+// Checker codes.
+const (
+	ImportFailed     Code = "E0201"
+	TypeRedeclared   Code = "E0202"
+	UndeclaredType   Code = "E0203"
+	DuplicateHandler Code = "E0204"
+	DuplicateRoute   Code = "E0205"
+)
+
+// Formatter codes.
+const (
+	NotFormatted Code = "W0301"
+)
+
+// A codeDoc documents a code.
+type codeDoc struct {
+	severity Severity
+	title    string
+	text     string
+}
+
+var codes = map[Code]codeDoc{
+	IllegalCharacter: {Error, "illegal character", `
+The source contains a character that cannot start a token, a NUL byte,
+invalid UTF-8 or a byte order mark that is not at the start of the file.
+
+	type User {
+		Name string §
+	}
+
+Remove the character, or quote it in a string or tag.`},
+
+	NotTerminated: {Error, "literal or comment not terminated", `
+A string, raw string, rune literal or general comment has no closing
+delimiter before the end of the line or file.
+
+	info (
+		title: "user api
+	)
+
+Add the closing ", ` + "`" + `, ' or */.`},
+
+	InvalidNumber: {Error, "invalid number literal", `
+A number literal is malformed, such as a hexadecimal literal without
+digits, an exponent without digits, or a digit that is invalid for the
+base of the literal.
+
+	0x
+	1e
+	0b102`},
+
+	InvalidEscape: {Error, "invalid escape sequence or rune literal", `
+An escape sequence in a string or rune literal is unknown, is not
+terminated, or denotes an invalid Unicode code point, or a rune literal
+has no or several characters.
+
+	"\q"
+	"\uD800"
+
+Use one of the escapes of Go string literals, or a raw string.`},
+
+	InvalidLineDirective: {Error, "invalid line directive", `
+A //line or /*line comment at the start of a line has a line or column
+number that is not a positive integer.
+
+	//line user.api:0`},
+
+	SyntaxError: {Error, "syntax error", `
+The source does not match the grammar of api files.`},
+
+	ExpectedToken: {Error, "expected token", `
+A specific token is required here but another was found, such as a
+missing closing parenthesis or brace.
+
+	service user-api {
+		@handler getUser
+		get /users/:id returns (User
+	}
+
+The suggested fix inserts a missing closing delimiter. A misspelled
+keyword, such as retuns for returns, is reported here too, with the
+keyword as suggested fix.`},
+
+	ExpectedSemicolon: {Error, "expected ';'", `
+Declarations, fields and routes are separated by newlines or semicolons,
+but two of them were found on the same line, or they were separated by a
+comma.
+
+	type User {
+		Name string Age int
+	}
+
+Put each on a line of its own. The suggested fix inserts a semicolon, or
+replaces the comma by one.`},
+
+	ExpectedType: {Error, "expected type", `
+A type is required here, such as the type of a field or of a type
+declaration, but another token was found.
+
+	type User {
+		Name
+	}`},
+
+	ExpectedDecl: {Error, "expected declaration", `
+At the top level of a file only syntax, info, import, type, @server and
+service declarations are allowed.
+
+	syntax = "v1"
+
+	struct User {}
+
+A misspelled keyword, such as servce for service, is reported with the
+closest keywords as suggested fixes.`},
+
+	InvalidImportPath: {Error, "invalid import path", `
+An import path must be a non-empty string of graphic characters without
+spaces or any of the characters !"#$%&'()*,:;<=>?[\]^{|}.
+
+	import "user api.api"`},
+
+	ExpectedMethod: {Error, "expected method", `
+A route starts with its HTTP method, one of get, head, post, put, patch,
+delete, connect, options and trace, in any letter case.
+
+	service user-api {
+		@handler createUser
+		pots /users (User)
+	}
+
+The closest methods are suggested as fixes.`},
+
+	ExpectedAnnotation: {Error, "expected annotation", `
+Only the @doc and @handler annotations may precede a route.
+
+	service user-api {
+		@hanlder getUser
+		get /users/:id returns (User)
+	}
+
+The closest annotations are suggested as fixes.`},
+
+	ImportFailed: {Error, "cannot import", `
+An imported file cannot be read. Relative import paths are resolved
+against the directory of the importing file.
+
+	import "missing.api"`},
+
+	TypeRedeclared: {Error, "type redeclared", `
+A type name is declared more than once across the files of an api,
+including the files they import. The first declaration is used.
+
+	type User {}
+	type User {}
+
+Rename or remove one of the declarations.`},
+
+	UndeclaredType: {Error, "undeclared type", `
+A type name used in a type declaration, a request or a response is
+neither predeclared nor declared in the files of the api.
+
+	type User {
+		Team Team
+	}
+
+Declare the type, import the file declaring it, or fix the name. The
+closest declared or predeclared type names are suggested as fixes.`},
+
+	DuplicateHandler: {Error, "duplicate handler", `
+Two routes of a service have the same handler name, which would
+generate the same handler function twice.
+
+	service user-api {
+		@handler getUser
+		get /users/:id
+		@handler getUser
+		get /users/:name
+	}`},
+
+	DuplicateRoute: {Error, "duplicate route", `
+Two routes have the same method and path, including the prefix of
+their @server block, so that the second can never be reached.
+
+	service user-api {
+		@handler getUser
+		get /users/:id
+		@handler findUser
+		get /users/:id
+	}`},
+
+	NotFormatted: {Warning, "not formatted", `
+The file differs from its formatting by apifmt. The suggested fix
+replaces the file by the formatted source.`},
+}
+
+// Severity returns the default severity of the code: that of the
+// diagnostics of the code unless stated otherwise.
+func (c Code) Severity() Severity {
+	if doc, ok := codes[c]; ok {
+		return doc.severity
+	}
+	if strings.HasPrefix(string(c), "W") {
+		return Warning
+	}
+	return Error
+}
+
+// Title returns a short description of the code, such as "expected ';'",
+// or "" if the code is unknown.
+func (c Code) Title() string {
+	return codes[c].title
+}
+
+// Explain returns the documentation of the code, made of its title, an
+// explanation and an example, or "" if the code is unknown.
+func Explain(c Code) string {
+	doc, ok := codes[c]
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf("%s: %s\n%s\n", c, doc.title, doc.text)
+}
+
+// Codes returns all known codes in increasing order.
+func Codes() []Code {
+	list := make([]Code, 0, len(codes))
+	for c := range codes {
+		list = append(list, c)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i][1:] < list[j][1:] })
+	return list
+}
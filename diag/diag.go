@@ -0,0 +1,252 @@
+// Package diag defines the diagnostics reported for api files by the
+// parser, the checker and the formatter.
+//
+// A diagnostic carries a stable code, such as E0102 for a missing
+// semicolon, a severity, the span of source it is about, related spans
+// elsewhere, and optionally fixes that can be applied to the source
+// mechanically. Explain returns the documentation of a code.
+package diag
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/zeromicro/api-ast/token"
+)
+
+// A Severity is the severity of a diagnostic.
+type Severity int
+
+const (
+	Error   Severity = iota // the source is invalid
+	Warning                 // the source is valid but likely wrong
+	Info                    // a remark
+	Hint                    // a suggestion
+)
+
+var severities = [...]string{
+	Error:   "error",
+	Warning: "warning",
+	Info:    "info",
+	Hint:    "hint",
+}
+
+func (s Severity) String() string {
+	if 0 <= s && int(s) < len(severities) {
+		return severities[s]
+	}
+	return fmt.Sprintf("Severity(%d)", int(s))
+}
+
+// MarshalText encodes s as its name, such as "error".
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// A Position is a position in a file.
+type Position struct {
+	Offset int `json:"offset"` // byte offset, starting at 0
+	Line   int `json:"line"`   // line number, starting at 1
+	Column int `json:"column"` // column number, starting at 1 (byte count)
+}
+
+// A Span is the source of a file from Start up to, but not including,
+// End. An empty span has End equal to Start.
+type Span struct {
+	Filename string   `json:"filename"`
+	Start    Position `json:"start"`
+	End      Position `json:"end"`
+}
+
+// NewSpan returns the span from start to end, which must be in the same
+// file. If end is not valid, the span is empty.
+func NewSpan(start, end token.Position) Span {
+	s := Span{
+		Filename: start.Filename,
+		Start:    Position{start.Offset, start.Line, start.Column},
+	}
+	s.End = s.Start
+	if end.IsValid() {
+		s.End = Position{end.Offset, end.Line, end.Column}
+	}
+	return s
+}
+
+// SpanOf returns the span from pos to end in fset. If end is not valid,
+// the span is empty.
+func SpanOf(fset *token.FileSet, pos, end token.Pos) Span {
+	var e token.Position
+	if end.IsValid() {
+		e = fset.Position(end)
+	}
+	return NewSpan(fset.Position(pos), e)
+}
+
+// String returns the start of the span in the form file:line:column.
+func (s Span) String() string {
+	return token.Position{Filename: s.Filename, Offset: s.Start.Offset, Line: s.Start.Line, Column: s.Start.Column}.String()
+}
+
+// A Related is a span related to a diagnostic, such as the other
+// declaration of a name declared twice.
+type Related struct {
+	Span    Span   `json:"span"`
+	Message string `json:"message"`
+}
+
+// An Edit replaces the source of a span by NewText. OldText, if not
+// empty, is the replaced source; Apply then checks that it is unchanged.
+type Edit struct {
+	Span    Span   `json:"span"`
+	OldText string `json:"oldText,omitempty"`
+	NewText string `json:"newText"`
+}
+
+// String returns e in the form "file:line:column: old -> new".
+func (e Edit) String() string {
+	return fmt.Sprintf("%s: %s -> %s", e.Span, e.OldText, e.NewText)
+}
+
+// A Fix is a change of the source that resolves a diagnostic.
+type Fix struct {
+	Message string `json:"message"` // description, such as "insert ';'"
+	Edits   []Edit `json:"edits"`
+}
+
+// A Diagnostic is a problem found in the source.
+type Diagnostic struct {
+	Code     Code      `json:"code"`
+	Severity Severity  `json:"severity"`
+	Span     Span      `json:"span"`
+	Message  string    `json:"message"`
+	Related  []Related `json:"related,omitempty"`
+	Fixes    []Fix     `json:"fixes,omitempty"`
+}
+
+// New returns a diagnostic of the code with the default severity of the
+// code.
+func New(code Code, span Span, msg string) *Diagnostic {
+	return &Diagnostic{Code: code, Severity: code.Severity(), Span: span, Message: msg}
+}
+
+// Error returns the diagnostic in the form
+//
+//	file:line:column: severity code: message
+func (d *Diagnostic) Error() string {
+	if d.Span.Filename != "" || d.Span.Start.Line > 0 {
+		return fmt.Sprintf("%s: %s %s: %s", d.Span, d.Severity, d.Code, d.Message)
+	}
+	return fmt.Sprintf("%s %s: %s", d.Severity, d.Code, d.Message)
+}
+
+// A List is a list of diagnostics.
+type List []*Diagnostic
+
+// Add adds the diagnostic d to the list.
+func (l *List) Add(d *Diagnostic) {
+	*l = append(*l, d)
+}
+
+// Len, Swap and Less implement sort.Interface, ordering by position.
+func (l List) Len() int      { return len(l) }
+func (l List) Swap(i, j int) { l[i], l[j] = l[j], l[i] }
+
+func (l List) Less(i, j int) bool {
+	if l[i].Span.Filename != l[j].Span.Filename {
+		return l[i].Span.Filename < l[j].Span.Filename
+	}
+	e, f := &l[i].Span.Start, &l[j].Span.Start
+	if e.Line != f.Line {
+		return e.Line < f.Line
+	}
+	if e.Column != f.Column {
+		return e.Column < f.Column
+	}
+	return l[i].Message < l[j].Message
+}
+
+// Sort sorts the list by position. The order of diagnostics at the same
+// position is stable.
+func (l List) Sort() {
+	sort.Stable(l)
+}
+
+// HasErrors reports whether the list has a diagnostic of severity Error.
+func (l List) HasErrors() bool {
+	for _, d := range l {
+		if d.Severity == Error {
+			return true
+		}
+	}
+	return false
+}
+
+// Error returns the first diagnostic and the number of the others.
+func (l List) Error() string {
+	switch len(l) {
+	case 0:
+		return "no diagnostics"
+	case 1:
+		return l[0].Error()
+	}
+	return fmt.Sprintf("%s (and %d more diagnostics)", l[0], len(l)-1)
+}
+
+// Err returns an error equivalent to the list, or nil if it is empty.
+func (l List) Err() error {
+	if len(l) == 0 {
+		return nil
+	}
+	return l
+}
+
+// Apply applies the edits, which must not overlap, to src. The edits
+// are matched to src by the offsets of their spans; their file names are
+// ignored.
+func Apply(src []byte, edits []Edit) ([]byte, error) {
+	sorted := make([]Edit, len(edits))
+	copy(sorted, edits)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Span.Start.Offset < sorted[j].Span.Start.Offset
+	})
+	var out []byte
+	last := 0
+	for _, e := range sorted {
+		start, end := e.Span.Start.Offset, e.Span.End.Offset
+		if start < last || end < start || end > len(src) {
+			return nil, fmt.Errorf("diag: %s: invalid or overlapping edit", e)
+		}
+		if e.OldText != "" && string(src[start:end]) != e.OldText {
+			return nil, fmt.Errorf("diag: %s: source has changed", e)
+		}
+		out = append(out, src[last:start]...)
+		out = append(out, e.NewText...)
+		last = end
+	}
+	return append(out, src[last:]...), nil
+}
+
+// ApplyFiles applies the edits to the files named by their spans, whose
+// sources are read by readFile, and returns the changed sources by file
+// name. Nothing is returned if an edit of any file fails.
+func ApplyFiles(edits []Edit, readFile func(filename string) ([]byte, error)) (map[string][]byte, error) {
+	byFile := make(map[string][]Edit)
+	var filenames []string
+	for _, e := range edits {
+		if byFile[e.Span.Filename] == nil {
+			filenames = append(filenames, e.Span.Filename)
+		}
+		byFile[e.Span.Filename] = append(byFile[e.Span.Filename], e)
+	}
+	changed := make(map[string][]byte)
+	for _, filename := range filenames {
+		src, err := readFile(filename)
+		if err != nil {
+			return nil, err
+		}
+		if changed[filename], err = Apply(src, byFile[filename]); err != nil {
+			return nil, err
+		}
+	}
+	return changed, nil
+}
@@ -0,0 +1,147 @@
+package diag_test
+
+import (
+	"fmt"
+
+	"github.com/zeromicro/api-ast/diag"
+	"github.com/zeromicro/api-ast/parser"
+	"github.com/zeromicro/api-ast/token"
+)
+
+func ExampleApply() {
+	src := []byte(`syntax = "v1"
+
+type User {
+	Name string, Age int
+}
+
+service user-api {
+	@handler getUser
+	get /users/:id returns (User
+}
+`)
+	var diags diag.List
+	cfg := &parser.Config{
+		Mode:        parser.AllErrors,
+		Diagnostics: func(d *diag.Diagnostic) { diags.Add(d) },
+	}
+	cfg.ParseFile(token.NewFileSet(), "user.api", src)
+
+	var edits []diag.Edit
+	for _, d := range diags {
+		fmt.Println(d)
+		for _, fix := range d.Fixes {
+			fmt.Printf("\tfix: %s\n", fix.Message)
+			edits = append(edits, fix.Edits...)
+		}
+	}
+	fixed, err := diag.Apply(src, edits)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Print(string(fixed))
+
+	// Output:
+	// user.api:4:13: error E0102: expected ';', found ','
+	// 	fix: replace ',' by ';'
+	// user.api:9:30: error E0101: expected ')', found newline
+	// 	fix: insert ')'
+	// syntax = "v1"
+	//
+	// type User {
+	// 	Name string; Age int
+	// }
+	//
+	// service user-api {
+	// 	@handler getUser
+	// 	get /users/:id returns (User)
+	// }
+}
+
+func ExampleDiagnostic_misspelling() {
+	src := `service user-api {
+	@handler getUser
+	get /users/:id retuns (User)
+}
+`
+	cfg := &parser.Config{
+		Diagnostics: func(d *diag.Diagnostic) {
+			fmt.Println(d)
+			for _, fix := range d.Fixes {
+				fmt.Printf("\tfix: %s\n", fix.Message)
+			}
+		},
+	}
+	cfg.ParseFile(token.NewFileSet(), "user.api", src)
+
+	// Output:
+	// user.api:3:17: error E0101: expected 'returns', found retuns; did you mean returns?
+	// 	fix: change to returns
+}
+
+func ExampleExplain() {
+	fmt.Print(diag.Explain(diag.UndeclaredType))
+
+	// Output:
+	// E0203: undeclared type
+	//
+	// A type name used in a type declaration, a request or a response is
+	// neither predeclared nor declared in the files of the api.
+	//
+	// 	type User {
+	// 		Team Team
+	// 	}
+	//
+	// Declare the type, import the file declaring it, or fix the name. The
+	// closest declared or predeclared type names are suggested as fixes.
+}
+
+func ExampleClosest() {
+	methods := []string{"get", "head", "post", "put", "patch", "delete"}
+	fmt.Println(diag.Closest("pots", methods))
+	fmt.Println(diag.Closest("gte", methods))
+	fmt.Println(diag.Closest("remove", methods))
+
+	// Output:
+	// [post]
+	// [get]
+	// []
+}
+
+func ExampleApplyFiles() {
+	files := map[string]string{
+		"a.api": "type User {}\n",
+		"b.api": "type Page {\n\tUsers []User\n}\n",
+	}
+	edits := []diag.Edit{
+		{Span: diag.Span{Filename: "a.api", Start: diag.Position{Offset: 5, Line: 1, Column: 6}, End: diag.Position{Offset: 9, Line: 1, Column: 10}}, OldText: "User", NewText: "Account"},
+		{Span: diag.Span{Filename: "b.api", Start: diag.Position{Offset: 21, Line: 2, Column: 10}, End: diag.Position{Offset: 25, Line: 2, Column: 14}}, OldText: "User", NewText: "Account"},
+	}
+	changed, err := diag.ApplyFiles(edits, func(filename string) ([]byte, error) {
+		return []byte(files[filename]), nil
+	})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	for _, e := range edits {
+		fmt.Println(e)
+	}
+	fmt.Print(string(changed["a.api"]), string(changed["b.api"]))
+
+	files["b.api"] = "type Page {\n\tList []User\n}\n"
+	if _, err := diag.ApplyFiles(edits, func(filename string) ([]byte, error) {
+		return []byte(files[filename]), nil
+	}); err != nil {
+		fmt.Println(err)
+	}
+	// output:
+	// a.api:1:6: User -> Account
+	// b.api:2:10: User -> Account
+	// type Account {}
+	// type Page {
+	// 	Users []Account
+	// }
+	// diag: b.api:2:10: User -> Account: source has changed
+}
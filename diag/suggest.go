@@ -0,0 +1,103 @@
+package diag
+
+import (
+	"strings"
+)
+
+// Closest returns the candidates closest to name by edit distance, at
+// most three of them in the order of candidates, or nil if none is
+// close enough to be a likely misspelling of name. Letter case is
+// ignored, and a transposition of adjacent letters counts as a single
+// edit, so that "pots" is close to "post".
+func Closest(name string, candidates []string) []string {
+	max := (len(name) + 1) / 3
+	if max < 1 {
+		max = 1
+	}
+	best := max + 1
+	var list []string
+	for _, c := range candidates {
+		if c == name {
+			continue
+		}
+		d := distance(strings.ToLower(name), strings.ToLower(c))
+		if d >= len(c) {
+			continue
+		}
+		switch {
+		case d < best:
+			best = d
+			list = append(list[:0], c)
+		case d == best && len(list) < 3 && !contains(list, c):
+			list = append(list, c)
+		}
+	}
+	return list
+}
+
+// Suggest returns a hint naming the suggestions for the source of the
+// span, such as "did you mean post?", and for each a fix that replaces
+// the span by it. The hint is empty if there are no suggestions.
+func Suggest(span Span, suggestions []string) (hint string, fixes []Fix) {
+	if len(suggestions) == 0 {
+		return "", nil
+	}
+	n := len(suggestions)
+	hint = "did you mean " + suggestions[n-1] + "?"
+	if n > 1 {
+		hint = "did you mean " + strings.Join(suggestions[:n-1], ", ") + " or " + suggestions[n-1] + "?"
+	}
+	for _, s := range suggestions {
+		fixes = append(fixes, Fix{
+			Message: "change to " + s,
+			Edits:   []Edit{{Span: span, NewText: s}},
+		})
+	}
+	return hint, fixes
+}
+
+// distance returns the optimal string alignment distance of a and b: the
+// number of insertions, deletions, substitutions and transpositions of
+// adjacent bytes that turn a into b, where no substring is edited twice.
+func distance(a, b string) int {
+	// d[i][j] is the distance of a[:i] and b[:j]
+	d := make([][]int, len(a)+1)
+	for i := range d {
+		d[i] = make([]int, len(b)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(a)][len(b)]
+}
+
+func min(x int, ys ...int) int {
+	for _, y := range ys {
+		if y < x {
+			x = y
+		}
+	}
+	return x
+}
+
+func contains(list []string, s string) bool {
+	for _, t := range list {
+		if t == s {
+			return true
+		}
+	}
+	return false
+}
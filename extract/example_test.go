@@ -0,0 +1,82 @@
+package extract_test
+
+import (
+	"fmt"
+
+	"github.com/zeromicro/api-ast/ast"
+	"github.com/zeromicro/api-ast/diag"
+	"github.com/zeromicro/api-ast/extract"
+	"github.com/zeromicro/api-ast/loader"
+	"github.com/zeromicro/api-ast/parser"
+	"github.com/zeromicro/api-ast/token"
+)
+
+func ExampleConfig_Extract() {
+	const src = `type Order {
+	Id int64 ` + "`json:\"id\"`" + `
+	// Lines are the ordered items.
+	Lines []{
+		Sku      string ` + "`json:\"sku\"`" + `
+		Quantity int    ` + "`json:\"quantity\"`" + `
+	} ` + "`json:\"lines\"`" + `
+}
+
+service shop-api {
+	@handler createOrder
+	post /orders ({
+		Lines []OrderLine ` + "`json:\"lines\"`" + `
+	}) returns (Order)
+}
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "shop.api", src, parser.ParseComments)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	api := &loader.API{Fset: fset, Files: []*ast.File{f}, Filenames: []string{"shop.api"}}
+
+	cfg := &extract.Config{
+		Names: map[string]string{"Order.Lines": "OrderLine"},
+		ReadFile: func(string) ([]byte, error) {
+			return []byte(src), nil
+		},
+	}
+	res, err := cfg.Extract(api)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	for _, s := range res.Structs {
+		fmt.Printf("%s: %s -> %s\n", s.Position, s.Path, s.Name)
+	}
+	out, err := diag.Apply([]byte(src), res.Edits)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Print(string(out))
+	// Output:
+	// shop.api:4:10: Order.Lines -> OrderLine
+	// shop.api:12:16: @createOrder.request -> CreateOrderRequest
+	// type Order {
+	// 	Id int64 `json:"id"`
+	// 	// Lines are the ordered items.
+	// 	Lines []OrderLine `json:"lines"`
+	// }
+	//
+	// // Lines are the ordered items.
+	// type OrderLine {
+	// 	Sku      string `json:"sku"`
+	// 	Quantity int    `json:"quantity"`
+	// }
+	//
+	// type CreateOrderRequest {
+	// 	Lines []OrderLine `json:"lines"`
+	// }
+	//
+	// service shop-api {
+	// 	@handler createOrder
+	// 	post /orders (CreateOrderRequest) returns (Order)
+	// }
+}
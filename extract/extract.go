@@ -0,0 +1,459 @@
+// Package extract computes the edits that extract inline struct types
+// into declared types.
+//
+// An inline struct is a struct type literal used as the type of a field,
+// as the element, value or pointer base of such a type, or as the request
+// or response of a route. Each one is replaced by the name of a new type
+// declared with the struct as its definition. Structs nested in extracted
+// structs are extracted too.
+//
+// A struct is identified by its path: the name of the declared type and
+// the names of the fields leading to it, such as "User.Address.Geo", or,
+// for a route, "@" followed by the handler and "request" or "response",
+// such as "@getUser.response". The new type is named after the path,
+// as in UserAddressGeo or GetUserResponse, unless a name is supplied;
+// generated names are numbered if they are taken.
+//
+// The new type is declared right after the declared type containing the
+// struct, inside the same parenthesized declaration if there is one, or
+// before the service of the route. The comments inside the struct move
+// along with it. The doc comment of the field of the struct is copied to
+// the new type and kept on the field as well, since it documents both
+// the field and, in most APIs, the struct.
+package extract
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/zeromicro/api-ast/ast"
+	"github.com/zeromicro/api-ast/diag"
+	"github.com/zeromicro/api-ast/loader"
+	"github.com/zeromicro/api-ast/token"
+	"github.com/zeromicro/api-ast/types"
+)
+
+// A Config controls the extraction.
+type Config struct {
+	// Names maps the paths of structs to the names of their new types;
+	// other structs get generated names.
+	Names map[string]string
+
+	// Paths limits the extraction to the structs with these paths and the
+	// structs nested in them; default: all inline structs.
+	Paths []string
+
+	// ReadFile reads the source of a file; default: os.ReadFile.
+	ReadFile func(filename string) ([]byte, error)
+}
+
+// A Struct is an extracted inline struct.
+type Struct struct {
+	Path     string `json:"path"`
+	Name     string `json:"name"`     // name of the new type
+	Position string `json:"position"` // position of the struct
+}
+
+// A Result is the outcome of an extraction.
+type Result struct {
+	Structs []*Struct   `json:"structs"` // in source order, outer structs first
+	Edits   []diag.Edit `json:"edits"`
+}
+
+// Extract returns the edits extracting all inline structs of api, using
+// the default configuration.
+func Extract(api *loader.API) (*Result, error) {
+	return (&Config{}).Extract(api)
+}
+
+// Extract returns the edits extracting the inline structs of api.
+func (cfg *Config) Extract(api *loader.API) (*Result, error) {
+	info, err := types.NewInfo(api.Files...)
+	if err != nil {
+		return nil, fmt.Errorf("extract: %v", err)
+	}
+	x := &extractor{
+		cfg:     cfg,
+		api:     api,
+		src:     make(map[string][]byte),
+		names:   make(map[string]bool),
+		matched: make(map[string]bool),
+		res:     &Result{Structs: []*Struct{}, Edits: []diag.Edit{}},
+	}
+	for _, s := range info.Specs {
+		x.names[s.Name.Name] = true
+	}
+	for _, f := range api.Files {
+		for _, d := range f.Decls {
+			switch d := d.(type) {
+			case *ast.GenDecl:
+				if d.Tok != token.TYPE {
+					continue
+				}
+				for _, spec := range d.Specs {
+					if s, ok := spec.(*ast.TypeSpec); ok {
+						if err := x.typeSpec(f, d, s); err != nil {
+							return nil, err
+						}
+					}
+				}
+			case *ast.Service:
+				if err := x.service(f, d); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	for _, p := range cfg.Paths {
+		if !x.matched[p] {
+			return nil, fmt.Errorf("extract: no inline struct at %s", p)
+		}
+	}
+	for p := range cfg.Names {
+		if !x.matched[p] {
+			return nil, fmt.Errorf("extract: no inline struct at %s", p)
+		}
+	}
+	sort.SliceStable(x.res.Edits, func(i, j int) bool {
+		a, b := x.res.Edits[i].Span, x.res.Edits[j].Span
+		if a.Filename != b.Filename {
+			return a.Filename < b.Filename
+		}
+		return a.Start.Offset < b.Start.Offset
+	})
+	return x.res, nil
+}
+
+type extractor struct {
+	cfg     *Config
+	api     *loader.API
+	src     map[string][]byte // sources by file name
+	names   map[string]bool   // declared and new type names
+	matched map[string]bool   // paths of the inline structs found
+	res     *Result
+}
+
+// A decl is the text of a new type declaration.
+type decl struct {
+	doc  []string // lines of the doc comment
+	name string
+	body string // struct text, starting with "{", indented at top level
+}
+
+// typeSpec extracts the inline structs of the declared type s, which is
+// declared by d in file f.
+func (x *extractor) typeSpec(f *ast.File, d *ast.GenDecl, s *ast.TypeSpec) error {
+	var decls []*decl
+	var err error
+	if st, ok := s.Type.(*ast.StructType); ok {
+		decls, err = x.fields(st, s.Name.Name, s.Name.Name, false, true)
+	} else {
+		decls, err = x.inline(s.Type, nil, s.Name.Name, s.Name.Name+"Elem", false, true)
+	}
+	if err != nil || len(decls) == 0 {
+		return err
+	}
+
+	// insert the new types at the end of the line of the spec or decl
+	end := s.End()
+	if s.Comment != nil {
+		end = s.Comment.End()
+	}
+	group := d.Lparen.IsValid()
+	if !group {
+		end = d.End()
+	}
+	src, pos, err := x.source(end)
+	if err != nil {
+		return err
+	}
+	off := pos.Offset
+	for off < len(src) && src[off] != '\n' {
+		off++
+	}
+	var b strings.Builder
+	for _, nd := range decls {
+		if group {
+			b.WriteString("\n")
+			for _, line := range nd.doc {
+				b.WriteString("\t" + line + "\n")
+			}
+			b.WriteString("\t" + nd.name + " " + indent(nd.body))
+		} else {
+			b.WriteString("\n\n")
+			for _, line := range nd.doc {
+				b.WriteString(line + "\n")
+			}
+			b.WriteString("type " + nd.name + " " + nd.body)
+		}
+	}
+	x.insert(pos.Filename, off, b.String())
+	return nil
+}
+
+// service extracts the inline requests and responses of the routes of
+// the service svc in file f.
+func (x *extractor) service(f *ast.File, svc *ast.Service) error {
+	var decls []*decl
+	for _, sr := range svc.ServiceApi.ServiceRoute {
+		rt := sr.Route
+		if rt == nil {
+			continue
+		}
+		handler := ""
+		if sr.AtHandler != nil {
+			handler = sr.AtHandler.Text()
+		}
+		for _, part := range []struct {
+			x    *ast.ParenExpr
+			name string
+		}{{rt.Req, "request"}, {rt.Resp, "response"}} {
+			if part.x == nil {
+				continue
+			}
+			path := "@" + handler + "." + part.name
+			list, err := x.inline(part.x.X, nil, path, export(handler)+export(part.name), false, true)
+			if err != nil {
+				return err
+			}
+			decls = append(decls, list...)
+		}
+	}
+	if len(decls) == 0 {
+		return nil
+	}
+
+	// insert the new types before the service and the comments above it
+	start := svc.Pos()
+	line := x.api.Fset.Position(start).Line
+	for i := len(f.Comments) - 1; i >= 0; i-- {
+		c := f.Comments[i]
+		if c.Pos() < start && x.api.Fset.Position(c.End()).Line >= line-1 {
+			start, line = c.Pos(), x.api.Fset.Position(c.Pos()).Line
+		}
+	}
+	src, pos, err := x.source(start)
+	if err != nil {
+		return err
+	}
+	off := pos.Offset
+	for off > 0 && src[off-1] != '\n' {
+		off--
+	}
+	var b strings.Builder
+	for _, nd := range decls {
+		for _, line := range nd.doc {
+			b.WriteString(line + "\n")
+		}
+		b.WriteString("type " + nd.name + " " + nd.body + "\n\n")
+	}
+	x.insert(pos.Filename, off, b.String())
+	return nil
+}
+
+// fields extracts the inline structs of the fields of st, whose path is
+// path and whose type is named name. Nested and selected are passed on
+// to inline.
+func (x *extractor) fields(st *ast.StructType, path, name string, nested, selected bool) ([]*decl, error) {
+	var decls []*decl
+	if st.Fields == nil {
+		return nil, nil
+	}
+	for _, f := range st.Fields.List {
+		if len(f.Names) == 0 {
+			continue
+		}
+		field := f.Names[0].Name
+		list, err := x.inline(f.Type, f.Doc, path+"."+field, name+export(field), nested, selected)
+		if err != nil {
+			return nil, err
+		}
+		decls = append(decls, list...)
+	}
+	return decls, nil
+}
+
+// inline extracts the outermost inline structs of the type expression t,
+// which has the path path and whose new types are named after name; doc
+// is the doc comment of the field of t. If nested is set, t is inside a
+// struct being extracted, whose text carries the replacements; otherwise
+// the replacements are recorded as edits. Selected reports whether an
+// enclosing struct is selected for extraction.
+func (x *extractor) inline(t ast.Expr, doc *ast.CommentGroup, path, name string, nested, selected bool) ([]*decl, error) {
+	st := ast.InlineStruct(t)
+	if st == nil {
+		return nil, nil
+	}
+	x.matched[path] = true
+	if !nested && len(x.cfg.Paths) > 0 {
+		selected = false
+		for _, p := range x.cfg.Paths {
+			selected = selected || p == path
+		}
+	}
+	if !selected {
+		// look for selected structs further in
+		return x.fields(st, path, name, false, false)
+	}
+
+	name, err := x.newName(path, name)
+	if err != nil {
+		return nil, err
+	}
+	src, pos, err := x.source(st.Pos())
+	if err != nil {
+		return nil, err
+	}
+	x.res.Structs = append(x.res.Structs, &Struct{Path: path, Name: name, Position: pos.String()})
+
+	inner, err := x.fields(st, path, name, true, true)
+	if err != nil {
+		return nil, err
+	}
+	body, err := x.body(src, st)
+	if err != nil {
+		return nil, err
+	}
+	nd := &decl{name: name, body: body}
+	if doc != nil {
+		// a copy: the field keeps its doc comment
+		for _, c := range doc.List {
+			nd.doc = append(nd.doc, c.Text)
+		}
+	}
+	if !nested {
+		end := x.api.Fset.Position(st.End())
+		x.res.Edits = append(x.res.Edits, diag.Edit{
+			Span:    diag.NewSpan(pos, end),
+			OldText: string(src[pos.Offset:end.Offset]),
+			NewText: name,
+		})
+	}
+	return append([]*decl{nd}, inner...), nil
+}
+
+// body returns the text of the struct st from its opening brace, with
+// the inline structs of its fields replaced by the names of their new
+// types and dedented to the top level.
+func (x *extractor) body(src []byte, st *ast.StructType) (string, error) {
+	open := x.api.Fset.Position(st.Fields.Opening).Offset
+	end := x.api.Fset.Position(st.End()).Offset
+	var b strings.Builder
+	last := open
+	// the structs extracted from the fields of st were appended in
+	// source order; find them by position
+	for _, f := range st.Fields.List {
+		inner := ast.InlineStruct(f.Type)
+		if inner == nil || len(f.Names) == 0 {
+			continue
+		}
+		name := x.structName(inner)
+		from := x.api.Fset.Position(inner.Pos()).Offset
+		to := x.api.Fset.Position(inner.End()).Offset
+		b.Write(src[last:from])
+		b.WriteString(name)
+		last = to
+	}
+	b.Write(src[last:end])
+
+	// dedent by the indentation of the closing brace
+	closing := x.api.Fset.Position(st.Fields.Closing).Offset
+	start := closing
+	for start > 0 && src[start-1] != '\n' {
+		start--
+	}
+	prefix := string(src[start:closing])
+	if strings.TrimSpace(prefix) != "" {
+		return b.String(), nil // closing brace on the line of a field
+	}
+	lines := strings.Split(b.String(), "\n")
+	for i := 1; i < len(lines); i++ {
+		lines[i] = strings.TrimPrefix(lines[i], prefix)
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// structName returns the name of the new type of the extracted struct
+// st.
+func (x *extractor) structName(st *ast.StructType) string {
+	pos := x.api.Fset.Position(st.Pos()).String()
+	for _, s := range x.res.Structs {
+		if s.Position == pos {
+			return s.Name
+		}
+	}
+	return ""
+}
+
+// newName returns the name of the new type of the struct at path, whose
+// generated name is name.
+func (x *extractor) newName(path, name string) (string, error) {
+	if n, ok := x.cfg.Names[path]; ok {
+		if !token.IsIdentifier(n) {
+			return "", fmt.Errorf("extract: invalid type name %q for %s", n, path)
+		}
+		if x.names[n] {
+			return "", fmt.Errorf("extract: type %s for %s is already declared", n, path)
+		}
+		x.names[n] = true
+		return n, nil
+	}
+	if !token.IsIdentifier(name) {
+		name = "Struct"
+	}
+	n := name
+	for i := 2; x.names[n]; i++ {
+		n = fmt.Sprintf("%s%d", name, i)
+	}
+	x.names[n] = true
+	return n, nil
+}
+
+// source returns the source of the file containing pos and the position.
+func (x *extractor) source(p token.Pos) ([]byte, token.Position, error) {
+	pos := x.api.Fset.Position(p)
+	if src, ok := x.src[pos.Filename]; ok {
+		return src, pos, nil
+	}
+	read := x.cfg.ReadFile
+	if read == nil {
+		read = os.ReadFile
+	}
+	src, err := read(pos.Filename)
+	if err != nil {
+		return nil, pos, fmt.Errorf("extract: %v", err)
+	}
+	x.src[pos.Filename] = src
+	return src, pos, nil
+}
+
+// insert records the insertion of text at offset off of the file.
+func (x *extractor) insert(filename string, off int, text string) {
+	src := x.src[filename]
+	line := 1 + bytes.Count(src[:off], []byte("\n"))
+	col := off - bytes.LastIndexByte(src[:off], '\n')
+	at := diag.Position{Offset: off, Line: line, Column: col}
+	x.res.Edits = append(x.res.Edits, diag.Edit{
+		Span:    diag.Span{Filename: filename, Start: at, End: at},
+		NewText: text,
+	})
+}
+
+// indent indents the lines of s after the first by a tab.
+func indent(s string) string {
+	return strings.ReplaceAll(s, "\n", "\n\t")
+}
+
+// export returns s with its first letter in upper case.
+func export(s string) string {
+	if s == "" {
+		return ""
+	}
+	r, n := utf8.DecodeRuneInString(s)
+	return string(unicode.ToUpper(r)) + s[n:]
+}
@@ -0,0 +1,70 @@
+package gostruct_test
+
+import (
+	"fmt"
+	goast "go/ast"
+	goparser "go/parser"
+	"os"
+
+	"github.com/zeromicro/api-ast/gostruct"
+	"github.com/zeromicro/api-ast/printer"
+	"github.com/zeromicro/api-ast/token"
+)
+
+func ExampleConvert() {
+	fset := token.NewFileSet() // positions are relative to fset
+
+	src := `package dto
+
+import "time"
+
+// Order is an order.
+type Order struct {
+	ID      int64     ` + "`json:\"id\"`" + ` // order number
+	Items   []Item    ` + "`json:\"items\"`" + `
+	Created time.Time ` + "`json:\"created\"`" + `
+	secret  string
+}
+
+// Item is an order item.
+type Item struct {
+	SKU string ` + "`json:\"sku\"`" + `
+	Qty int    ` + "`json:\"qty,default=1\"`" + `
+}
+
+type orderRequest struct {
+	ID int64 ` + "`path:\"id\"`" + `
+}
+`
+
+	f, err := goparser.ParseFile(fset, "dto.go", src, goparser.ParseComments)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	// Item is declared because Order refers to it.
+	api, err := gostruct.Convert(fset, []*goast.File{f}, "Order")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	cfg := printer.Config{Mode: printer.UseSpaces | printer.TabIndent, TabWidth: 8}
+	cfg.Fprint(os.Stdout, token.NewFileSet(), api)
+
+	// output:
+	// syntax = "v1"
+	//
+	// // Order is an order.
+	// type Order {
+	// 	ID      int64     `json:"id"` // order number
+	// 	Items   []Item    `json:"items"`
+	// 	Created time.Time `json:"created"`
+	// }
+	//
+	// // Item is an order item.
+	// type Item {
+	// 	SKU string `json:"sku"`
+	// 	Qty int    `json:"qty,default=1"`
+	// }
+}
@@ -0,0 +1,236 @@
+// Package gostruct converts Go struct types into api type declarations.
+//
+// The Go source is parsed with go/parser; Convert then translates the
+// selected type declarations into the api syntax tree, from which the
+// printer package produces an api file. Field names, types and tags are
+// carried over as is, and so are the doc and line comments of types and
+// fields, whose representation go/ast and the ast package share.
+// Unexported fields, which encoding/json ignores, are left out.
+//
+// Types with an api equivalent translate directly; for the others:
+//
+//	[N]T                 []T
+//	interface{...}       any
+//	pkg.T                pkg.T, unchecked
+//
+// Channel, function and generic types have no api equivalent and are
+// reported as errors.
+package gostruct
+
+import (
+	"fmt"
+	goast "go/ast"
+	"path"
+	"strconv"
+
+	"github.com/zeromicro/api-ast/ast"
+	"github.com/zeromicro/api-ast/token"
+)
+
+// Convert returns an api file declaring the struct types of files whose
+// names match one of patterns, in the syntax of path.Match, such as
+// "User" or "*Req"; without patterns all exported struct types are
+// selected. The types declared in files that selected types refer to,
+// directly or indirectly, are declared too, so that the result is
+// complete. Declarations are in source order.
+//
+// The result has no position information; print it with the printer
+// package. fset is used for the positions in errors only.
+func Convert(fset *token.FileSet, files []*goast.File, patterns ...string) (*ast.File, error) {
+	for _, p := range patterns {
+		if _, err := path.Match(p, ""); err != nil {
+			return nil, fmt.Errorf("gostruct: invalid pattern %q", p)
+		}
+	}
+
+	c := &converter{
+		fset:     fset,
+		decls:    make(map[string]*typeDecl),
+		selected: make(map[string]bool),
+	}
+	var order []*typeDecl
+	for _, f := range files {
+		for _, d := range f.Decls {
+			d, ok := d.(*goast.GenDecl)
+			if !ok {
+				continue
+			}
+			for _, s := range d.Specs {
+				s, ok := s.(*goast.TypeSpec)
+				if !ok {
+					continue
+				}
+				doc := s.Doc
+				if doc == nil && len(d.Specs) == 1 {
+					doc = d.Doc
+				}
+				td := &typeDecl{spec: s, doc: doc}
+				if c.decls[s.Name.Name] == nil {
+					c.decls[s.Name.Name] = td
+					order = append(order, td)
+				}
+			}
+		}
+	}
+
+	for _, td := range order {
+		if _, ok := td.spec.Type.(*goast.StructType); ok && match(td.spec.Name, patterns) {
+			c.use(td.spec.Name.Name)
+		}
+	}
+	if c.err != nil {
+		return nil, c.err
+	}
+	if len(c.selected) == 0 {
+		return nil, fmt.Errorf("gostruct: no struct types selected")
+	}
+
+	f := &ast.File{
+		Syntax: &ast.SyntaxSpec{Name: &ast.BasicLit{Kind: token.STRING, Value: strconv.Quote("v1")}},
+	}
+	for _, td := range order {
+		if c.selected[td.spec.Name.Name] {
+			f.Decls = append(f.Decls, &ast.GenDecl{
+				Doc:   copyComments(td.doc),
+				Tok:   token.TYPE,
+				Specs: []ast.Spec{td.converted},
+			})
+		}
+	}
+	return f, nil
+}
+
+// match reports whether the type name matches one of patterns, or, if
+// there are none, whether it is exported.
+func match(name *goast.Ident, patterns []string) bool {
+	if len(patterns) == 0 {
+		return name.IsExported()
+	}
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, name.Name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+type typeDecl struct {
+	spec      *goast.TypeSpec
+	doc       *goast.CommentGroup
+	converted *ast.TypeSpec
+}
+
+type converter struct {
+	fset     *token.FileSet
+	decls    map[string]*typeDecl // Go type declarations by name
+	selected map[string]bool      // converted declarations
+	err      error                // first error
+}
+
+func (c *converter) errorf(pos token.Pos, format string, args ...interface{}) {
+	if c.err == nil {
+		c.err = fmt.Errorf("gostruct: %s: %s", c.fset.Position(pos), fmt.Sprintf(format, args...))
+	}
+}
+
+// use converts the declaration of the type name, and those of the types
+// it refers to, unless it has been converted already.
+func (c *converter) use(name string) {
+	td := c.decls[name]
+	if td == nil || c.selected[name] {
+		return
+	}
+	c.selected[name] = true
+	s := td.spec
+	td.converted = &ast.TypeSpec{Name: ast.NewIdent(s.Name.Name), Comment: copyComments(s.Comment)}
+	td.converted.Type = c.typ(s.Type)
+}
+
+// typ returns the api type for the Go type x.
+func (c *converter) typ(x goast.Expr) ast.Expr {
+	switch t := x.(type) {
+	case *goast.Ident:
+		c.use(t.Name)
+		return ast.NewIdent(t.Name)
+
+	case *goast.SelectorExpr:
+		pkg, ok := t.X.(*goast.Ident)
+		if !ok {
+			break
+		}
+		return &ast.SelectorExpr{X: ast.NewIdent(pkg.Name), Sel: ast.NewIdent(t.Sel.Name)}
+
+	case *goast.StarExpr:
+		return &ast.StarExpr{X: c.typ(t.X)}
+
+	case *goast.ParenExpr:
+		return c.typ(t.X)
+
+	case *goast.ArrayType:
+		// arrays and slices have the same JSON encoding
+		return &ast.ArrayType{Elt: c.typ(t.Elt)}
+
+	case *goast.MapType:
+		return &ast.MapType{Key: c.typ(t.Key), Value: c.typ(t.Value)}
+
+	case *goast.InterfaceType:
+		return ast.NewIdent("any")
+
+	case *goast.StructType:
+		fields := &ast.FieldList{}
+		for _, f := range t.Fields.List {
+			if fld := c.field(f); fld != nil {
+				fields.List = append(fields.List, fld)
+			}
+		}
+		return &ast.StructType{Fields: fields}
+
+	case *goast.ChanType:
+		c.errorf(t.Pos(), "channel types are not supported")
+		return ast.NewIdent("any")
+
+	case *goast.FuncType:
+		c.errorf(t.Pos(), "function types are not supported")
+		return ast.NewIdent("any")
+
+	case *goast.IndexExpr:
+		c.errorf(t.Pos(), "generic types are not supported")
+		return ast.NewIdent("any")
+	}
+	c.errorf(x.Pos(), "unsupported type %T", x)
+	return ast.NewIdent("any")
+}
+
+// field returns the api field for the Go struct field f, or nil if it
+// only declares unexported fields.
+func (c *converter) field(f *goast.Field) *ast.Field {
+	fld := &ast.Field{
+		Doc:     copyComments(f.Doc),
+		Comment: copyComments(f.Comment),
+	}
+	for _, name := range f.Names {
+		if name.IsExported() {
+			fld.Names = append(fld.Names, ast.NewIdent(name.Name))
+		}
+	}
+	if len(f.Names) > 0 && len(fld.Names) == 0 {
+		return nil
+	}
+	fld.Type = c.typ(f.Type)
+	if f.Tag != nil {
+		fld.Tag = &ast.BasicLit{Kind: token.STRING, Value: f.Tag.Value}
+	}
+	return fld
+}
+
+// copyComments returns a copy of cg without position information.
+func copyComments(cg *goast.CommentGroup) *ast.CommentGroup {
+	if cg == nil {
+		return nil
+	}
+	cp := &ast.CommentGroup{}
+	for _, c := range cg.List {
+		cp.List = append(cp.List, &ast.Comment{Text: c.Text})
+	}
+	return cp
+}
@@ -0,0 +1,42 @@
+package highlight_test
+
+import (
+	"os"
+
+	"github.com/zeromicro/api-ast/highlight"
+)
+
+func ExampleConfig_HTML() {
+	const src = `type Reply {
+	Users []*User ` + "`json:\"users\"`" + `
+}
+
+type User {
+	Name string
+}
+
+service user-api {
+	@handler listUsers
+	get /users returns (Reply) // all users
+}
+`
+	cfg := &highlight.Config{Links: true}
+	if err := cfg.HTML(os.Stdout, "user.api", []byte(src)); err != nil {
+		panic(err)
+	}
+
+	// Output:
+	// <pre class="api"><code><span class="keyword">type</span> <span class="type" id="type-Reply">Reply</span> <span class="operator">{</span>
+	// 	Users <span class="operator">[]*</span><a class="type" href="#type-User">User</a> <span class="string">`json:&#34;users&#34;`</span>
+	// <span class="operator">}</span>
+	//
+	// <span class="keyword">type</span> <span class="type" id="type-User">User</span> <span class="operator">{</span>
+	// 	Name <span class="type">string</span>
+	// <span class="operator">}</span>
+	//
+	// <span class="keyword">service</span> user-api <span class="operator">{</span>
+	// 	<span class="keyword">@handler</span> listUsers
+	// 	<span class="method">get</span> <span class="path">/users</span> <span class="keyword">returns</span> <span class="operator">(</span><a class="type" href="#type-Reply">Reply</a><span class="operator">)</span> <span class="comment">// all users</span>
+	// <span class="operator">}</span>
+	// </code></pre>
+}
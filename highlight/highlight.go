@@ -0,0 +1,305 @@
+// Package highlight renders api source files with syntax highlighting,
+// as HTML or as text colored with ANSI escape sequences.
+//
+// Tokens are classified by the scanner and the token predicates:
+// keywords such as type, service, @server and returns, comments,
+// strings, numbers and operators. The syntax tree adds the classes the
+// tokens alone do not tell: type names, the keys of info and @server
+// declarations, and the methods and paths of routes. A source with
+// syntax errors is highlighted as far as it is understood.
+//
+// In HTML every classified token is a span with its class as CSS class;
+// see CSS for a default style sheet. The names of type declarations get
+// an id so that type references can link to them.
+package highlight
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"strings"
+
+	"github.com/zeromicro/api-ast/ast"
+	"github.com/zeromicro/api-ast/parser"
+	"github.com/zeromicro/api-ast/scanner"
+	"github.com/zeromicro/api-ast/token"
+)
+
+// A Class is the syntactic class of a token. In HTML, it is the CSS
+// class of the token.
+type Class string
+
+// Token classes.
+const (
+	Plain    Class = ""         // identifiers and white space
+	Comment  Class = "comment"  // comments
+	Keyword  Class = "keyword"  // keywords, including @server, @doc, @handler and returns
+	String   Class = "string"   // string literals, including tags
+	Number   Class = "number"   // numeric and character literals
+	Operator Class = "operator" // operators and delimiters
+	Type     Class = "type"     // type names, declared and referenced
+	Key      Class = "key"      // keys of info and @server declarations
+	Method   Class = "method"   // the HTTP method of a route
+	Path     Class = "path"     // the path of a route or the prefix of a service
+)
+
+// CSS is a default style sheet for the HTML output.
+const CSS = `pre.api { background: #f8f8f8; padding: 0.5em; }
+pre.api .comment { color: #6a737d; font-style: italic; }
+pre.api .keyword { color: #d73a49; font-weight: bold; }
+pre.api .string { color: #032f62; }
+pre.api .number { color: #005cc5; }
+pre.api .type { color: #6f42c1; }
+pre.api .key { color: #005cc5; }
+pre.api .method { color: #d73a49; }
+pre.api .path { color: #22863a; }
+pre.api a.type { text-decoration: none; }
+pre.api a.type:hover { text-decoration: underline; }
+`
+
+// A Config controls the rendering.
+type Config struct {
+	// Links enables links from type references to their declarations.
+	Links bool
+
+	// URL returns the link target of a reference to the named type,
+	// or "" for no link. If URL is nil, references to types declared in
+	// the file link to the anchor "#type-" + name; other references are
+	// not linked.
+	URL func(name string) string
+}
+
+// HTML writes src, the source of the file filename, to w as highlighted
+// HTML in a pre element, using the default configuration.
+func HTML(w io.Writer, filename string, src []byte) error {
+	return (&Config{}).HTML(w, filename, src)
+}
+
+// ANSI writes src, the source of the file filename, to w with ANSI
+// color escape sequences, using the default configuration.
+func ANSI(w io.Writer, filename string, src []byte) error {
+	return (&Config{}).ANSI(w, filename, src)
+}
+
+// HTML writes src, the source of the file filename, to w as highlighted
+// HTML in a pre element.
+func (cfg *Config) HTML(w io.Writer, filename string, src []byte) error {
+	h := newHighlighter(filename, src)
+	var b strings.Builder
+	b.WriteString(`<pre class="api"><code>`)
+	for _, s := range h.segments() {
+		text := html.EscapeString(s.text)
+		switch {
+		case s.class == Plain:
+			b.WriteString(text)
+		case s.decl:
+			fmt.Fprintf(&b, `<span class="%s" id="type-%s">%s</span>`, s.class, html.EscapeString(s.text), text)
+		case s.class == Type && cfg.Links && cfg.url(h, s.text) != "":
+			fmt.Fprintf(&b, `<a class="%s" href="%s">%s</a>`, s.class, html.EscapeString(cfg.url(h, s.text)), text)
+		default:
+			fmt.Fprintf(&b, `<span class="%s">%s</span>`, s.class, text)
+		}
+	}
+	b.WriteString("</code></pre>\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// ansiColors are the SGR parameters of the classes.
+var ansiColors = map[Class]string{
+	Comment: "90",
+	Keyword: "1;35",
+	String:  "32",
+	Number:  "34",
+	Type:    "33",
+	Key:     "36",
+	Method:  "1;31",
+	Path:    "32",
+}
+
+// ANSI writes src, the source of the file filename, to w with ANSI
+// color escape sequences. Type references are not linked.
+func (cfg *Config) ANSI(w io.Writer, filename string, src []byte) error {
+	var b strings.Builder
+	for _, s := range newHighlighter(filename, src).segments() {
+		if c := ansiColors[s.class]; c != "" {
+			fmt.Fprintf(&b, "\x1b[%sm%s\x1b[0m", c, s.text)
+		} else {
+			b.WriteString(s.text)
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// url returns the link target of a reference to the named type.
+func (cfg *Config) url(h *highlighter, name string) string {
+	if cfg.URL != nil {
+		return cfg.URL(name)
+	}
+	if h.declared[name] {
+		return "#type-" + name
+	}
+	return ""
+}
+
+// A segment is a piece of the source with a class.
+type segment struct {
+	text  string
+	class Class
+	decl  bool // the name of a type declaration
+}
+
+// A textRange is a range of the source whose tokens have one class.
+type textRange struct {
+	start, end int
+	class      Class
+}
+
+// A highlighter classifies the tokens of a source.
+type highlighter struct {
+	filename string
+	src      []byte
+	classes  map[int]Class // classes of identifiers by offset
+	decls    map[int]bool  // offsets of the names of type declarations
+	declared map[string]bool
+	ranges   []textRange // multi-token ranges: paths, prefixes and service names
+}
+
+func newHighlighter(filename string, src []byte) *highlighter {
+	h := &highlighter{
+		filename: filename,
+		src:      src,
+		classes:  make(map[int]Class),
+		decls:    make(map[int]bool),
+		declared: make(map[string]bool),
+	}
+	fset := token.NewFileSet()
+	f, _ := parser.ParseFile(fset, filename, src, 0)
+	if f == nil {
+		return h
+	}
+	off := func(pos token.Pos) int { return fset.Position(pos).Offset }
+	refs := func(id *ast.Ident) { h.classes[off(id.Pos())] = Type }
+	ast.Inspect(f, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.TypeSpec:
+			h.classes[off(n.Name.Pos())] = Type
+			h.decls[off(n.Name.Pos())] = true
+			h.declared[n.Name.Name] = true
+			ast.TypeRefs(n.Type, refs)
+			return false
+		case *ast.InfoType:
+			h.keys(n.Kvs, off)
+		case *ast.AtServer:
+			h.keys(n.Kvs, off)
+			for _, kv := range n.Kvs {
+				if kv.Key != nil && kv.Key.Name == "prefix" && kv.Value != nil {
+					h.ranges = append(h.ranges, textRange{off(kv.Value.Pos()), off(kv.Value.End()), Path})
+				}
+			}
+		case *ast.ServiceApi:
+			if n.Name != nil && n.Name.Pos().IsValid() {
+				// names such as user-api are scanned as several tokens
+				h.ranges = append(h.ranges, textRange{off(n.Name.Pos()), off(n.Name.End()), Plain})
+			}
+		case *ast.Route:
+			if n.Method != nil {
+				h.classes[off(n.Method.Pos())] = Method
+			}
+			if n.Path != nil && n.Path.Pos().IsValid() {
+				h.ranges = append(h.ranges, textRange{off(n.Path.Pos()), off(n.Path.End()), Path})
+			}
+			if n.Req != nil {
+				ast.TypeRefs(n.Req, refs)
+			}
+			if n.Resp != nil {
+				ast.TypeRefs(n.Resp, refs)
+			}
+			return false
+		}
+		return true
+	})
+	return h
+}
+
+func (h *highlighter) keys(kvs []*ast.KeyValueExpr, off func(token.Pos) int) {
+	for _, kv := range kvs {
+		if kv.Key != nil {
+			h.classes[off(kv.Key.Pos())] = Key
+		}
+	}
+}
+
+// segments splits the source into classified tokens and the plain text
+// between them.
+func (h *highlighter) segments() []segment {
+	fset := token.NewFileSet()
+	file := fset.AddFile(h.filename, -1, len(h.src))
+	var s scanner.Scanner
+	s.Init(file, h.src, nil, scanner.ScanComments)
+
+	var list []segment
+	last := 0
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		start := file.Offset(pos)
+		class := h.classify(start, tok, lit)
+		if class == Plain {
+			continue // part of the next plain segment
+		}
+		text := lit
+		if tok.IsOperator() && tok != token.SEMICOLON {
+			text = tok.String()
+		}
+		end := start + len(text)
+		if end > len(h.src) || string(h.src[start:end]) != text {
+			continue
+		}
+		if last < start {
+			list = append(list, segment{text: string(h.src[last:start])})
+		}
+		if n := len(list); n > 0 && last == start && list[n-1].class == class && class != Type {
+			list[n-1].text += text // join the tokens of a path
+		} else {
+			list = append(list, segment{text: text, class: class, decl: h.decls[start]})
+		}
+		last = end
+	}
+	if last < len(h.src) {
+		list = append(list, segment{text: string(h.src[last:])})
+	}
+	return list
+}
+
+// classify returns the class of the token tok with the literal lit at
+// the byte offset of the source.
+func (h *highlighter) classify(offset int, tok token.Token, lit string) Class {
+	for _, r := range h.ranges {
+		if r.start <= offset && offset < r.end {
+			return r.class
+		}
+	}
+	switch {
+	case tok == token.COMMENT:
+		return Comment
+	case tok.IsKeyword():
+		return Keyword
+	case tok == token.IDENT:
+		return h.classes[offset]
+	case tok == token.STRING:
+		return String
+	case tok.IsLiteral():
+		return Number
+	case tok == token.SEMICOLON:
+		if lit == ";" {
+			return Operator
+		}
+	case tok.IsOperator():
+		return Operator
+	}
+	return Plain
+}
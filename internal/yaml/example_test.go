@@ -0,0 +1,42 @@
+package yaml_test
+
+import (
+	"fmt"
+
+	"github.com/zeromicro/api-ast/internal/yaml"
+)
+
+func ExampleFromJSON() {
+	// Strings that YAML would read as booleans, numbers, indicators or
+	// several lines are quoted.
+	data := []byte(`{
+  "title": "User API",
+  "version": "1.0",
+  "yes": "no",
+  ":": "-1",
+  "-flag": "a: b",
+  "description": "first line\nsecond line",
+  "tags": [{"name": "user"}, "#admin"],
+  "count": 3,
+  "empty": {}
+}`)
+	out, err := yaml.FromJSON(data)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Print(string(out))
+
+	// output:
+	// title: User API
+	// version: "1.0"
+	// "yes": "no"
+	// ":": "-1"
+	// "-flag": "a: b"
+	// description: "first line\nsecond line"
+	// tags:
+	//   - name: user
+	//   - "#admin"
+	// count: 3
+	// empty: {}
+}
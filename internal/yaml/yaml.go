@@ -0,0 +1,151 @@
+// Package yaml converts between JSON and a block style subset of YAML
+// sufficient for API description documents.
+package yaml
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// A node is a JSON value with object keys in document order.
+type node struct {
+	kind   byte   // '{', '[' or 0 for scalars
+	scalar string // JSON text of a scalar
+	keys   []string
+	elems  []*node
+}
+
+// FromJSON converts the JSON document data to YAML. Object keys keep
+// their order.
+func FromJSON(data []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	n, err := decode(dec)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, fmt.Errorf("yaml: invalid JSON: trailing data")
+	}
+	var buf bytes.Buffer
+	switch {
+	case n.kind != 0 && len(n.elems) > 0:
+		encodeBlock(&buf, n, 0)
+	default:
+		buf.WriteString(inline(n))
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes(), nil
+}
+
+func decode(dec *json.Decoder) (*node, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, fmt.Errorf("yaml: invalid JSON: %v", err)
+	}
+	switch tok := tok.(type) {
+	case json.Delim:
+		n := &node{kind: byte(tok)}
+		for dec.More() {
+			if n.kind == '{' {
+				key, err := dec.Token()
+				if err != nil {
+					return nil, fmt.Errorf("yaml: invalid JSON: %v", err)
+				}
+				n.keys = append(n.keys, key.(string))
+			}
+			elem, err := decode(dec)
+			if err != nil {
+				return nil, err
+			}
+			n.elems = append(n.elems, elem)
+		}
+		if _, err := dec.Token(); err != nil { // closing delimiter
+			return nil, fmt.Errorf("yaml: invalid JSON: %v", err)
+		}
+		return n, nil
+	case string:
+		return &node{scalar: str(tok)}, nil
+	case json.Number:
+		return &node{scalar: tok.String()}, nil
+	case bool:
+		return &node{scalar: strconv.FormatBool(tok)}, nil
+	case nil:
+		return &node{scalar: "null"}, nil
+	}
+	return nil, fmt.Errorf("yaml: unexpected JSON token %v", tok)
+}
+
+// encodeBlock writes the non-empty collection n in block style, with
+// all lines indented by indent spaces.
+func encodeBlock(w *bytes.Buffer, n *node, indent int) {
+	prefix := strings.Repeat(" ", indent)
+	for i, elem := range n.elems {
+		if n.kind == '{' {
+			w.WriteString(prefix + str(n.keys[i]) + ":")
+		} else {
+			w.WriteString(prefix + "-")
+		}
+		switch {
+		case elem.kind == 0 || len(elem.elems) == 0:
+			w.WriteString(" " + inline(elem) + "\n")
+		case n.kind == '[':
+			// start the nested collection on the line of the "-"
+			var sub bytes.Buffer
+			encodeBlock(&sub, elem, indent+2)
+			w.WriteString(" ")
+			w.Write(sub.Bytes()[indent+2:])
+		default:
+			w.WriteString("\n")
+			encodeBlock(w, elem, indent+2)
+		}
+	}
+}
+
+// inline returns scalars and empty collections in flow style.
+func inline(n *node) string {
+	switch n.kind {
+	case '{':
+		return "{}"
+	case '[':
+		return "[]"
+	}
+	return n.scalar
+}
+
+// str returns s as YAML scalar: as a plain scalar if that is read back
+// as the same string, and as a double-quoted scalar otherwise.
+func str(s string) string {
+	if isPlain(s) {
+		return s
+	}
+	// JSON strings are valid YAML double-quoted scalars
+	b, _ := json.Marshal(s)
+	return string(b)
+}
+
+func isPlain(s string) bool {
+	if s == "" || s != strings.TrimSpace(s) {
+		return false
+	}
+	switch strings.ToLower(s) {
+	case "~", "null", "true", "false", "yes", "no", "on", "off", "y", "n":
+		return false
+	}
+	if strings.ContainsRune("-?:,[]{}#&*!|>'\"%@`.+0123456789", rune(s[0])) {
+		return false // indicator, or possibly a number
+	}
+	if strings.Contains(s, ": ") || strings.Contains(s, " #") || strings.HasSuffix(s, ":") {
+		return false
+	}
+	for _, r := range s {
+		if r < ' ' || r == 0x7f || r == '\ufeff' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,116 @@
+package jsonschema_test
+
+import (
+	"fmt"
+
+	"github.com/zeromicro/api-ast/jsonschema"
+	"github.com/zeromicro/api-ast/parser"
+	"github.com/zeromicro/api-ast/token"
+)
+
+func ExampleConfig_Documents() {
+	fset := token.NewFileSet() // positions are relative to fset
+
+	src := `syntax = "v1"
+
+type Address {
+	City string ` + "`json:\"city\"`" + `
+}
+
+// Signup is a signup form.
+type Signup {
+	Name  string            ` + "`json:\"name\"`" + `
+	Age   int               ` + "`json:\"age,range=[18:130)\"`" + `
+	Plan  string            ` + "`json:\"plan,options=free|pro,default=free\"`" + `
+	Home  *Address          ` + "`json:\"home,optional\"`" + `
+	Tags  []string          ` + "`json:\"tags,optional\"`" + `
+	Extra map[string]string ` + "`json:\"extra,optional\"`" + `
+}
+`
+
+	f, err := parser.ParseFile(fset, "signup.api", src, parser.ParseComments)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	cfg := &jsonschema.Config{BaseURI: "https://example.com/schemas/"}
+	docs, err := cfg.Documents(f)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	data, err := docs["Signup"].JSON()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Print(string(data))
+
+	// output:
+	// {
+	//   "$schema": "https://json-schema.org/draft/2020-12/schema",
+	//   "$id": "https://example.com/schemas/Signup.json",
+	//   "title": "Signup",
+	//   "description": "Signup is a signup form.",
+	//   "type": "object",
+	//   "properties": {
+	//     "age": {
+	//       "type": "integer",
+	//       "minimum": 18,
+	//       "exclusiveMaximum": 130
+	//     },
+	//     "extra": {
+	//       "type": "object",
+	//       "additionalProperties": {
+	//         "type": "string"
+	//       }
+	//     },
+	//     "home": {
+	//       "anyOf": [
+	//         {
+	//           "$ref": "#/$defs/Address"
+	//         },
+	//         {
+	//           "type": "null"
+	//         }
+	//       ]
+	//     },
+	//     "name": {
+	//       "type": "string"
+	//     },
+	//     "plan": {
+	//       "type": "string",
+	//       "enum": [
+	//         "free",
+	//         "pro"
+	//       ],
+	//       "default": "free"
+	//     },
+	//     "tags": {
+	//       "type": "array",
+	//       "items": {
+	//         "type": "string"
+	//       }
+	//     }
+	//   },
+	//   "required": [
+	//     "name",
+	//     "age"
+	//   ],
+	//   "$defs": {
+	//     "Address": {
+	//       "title": "Address",
+	//       "type": "object",
+	//       "properties": {
+	//         "city": {
+	//           "type": "string"
+	//         }
+	//       },
+	//       "required": [
+	//         "city"
+	//       ]
+	//     }
+	//   }
+	// }
+}
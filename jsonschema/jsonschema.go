@@ -0,0 +1,331 @@
+// Package jsonschema converts the types of api files to JSON Schema
+// (draft 2020-12) documents; see https://json-schema.org/draft/2020-12.
+//
+// A struct becomes an object schema whose properties are named after
+// the json tags of its fields, or, for request fields without json tag,
+// after their form, path or header tag; fields tagged "-" are left out.
+// Fields are required unless they are optional (see tag.Tags.Optional),
+// and the go-zero tag options are translated as follows:
+//
+//	default=value        default
+//	options=a|b|c        enum
+//	range=[min:max]      minimum and maximum; exclusiveMinimum and
+//	                     exclusiveMaximum for "(" and ")"
+//
+// Slices become arrays, maps become objects with additionalProperties,
+// and pointers allow null.
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/zeromicro/api-ast/ast"
+	"github.com/zeromicro/api-ast/types"
+)
+
+// Draft is the dialect of generated documents.
+const Draft = "https://json-schema.org/draft/2020-12/schema"
+
+// A Schema is a JSON Schema.
+type Schema struct {
+	Schema               string             `json:"$schema,omitempty"`
+	ID                   string             `json:"$id,omitempty"`
+	Ref                  string             `json:"$ref,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	ContentEncoding      string             `json:"contentEncoding,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Default              interface{}        `json:"default,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	ExclusiveMinimum     *float64           `json:"exclusiveMinimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	ExclusiveMaximum     *float64           `json:"exclusiveMaximum,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	AllOf                []*Schema          `json:"allOf,omitempty"`
+	AnyOf                []*Schema          `json:"anyOf,omitempty"`
+	Defs                 map[string]*Schema `json:"$defs,omitempty"`
+}
+
+// JSON returns the indented JSON encoding of s.
+func (s *Schema) JSON() ([]byte, error) {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// A Config controls the generated documents.
+type Config struct {
+	// BaseURI, if set, is the base of the $id of documents: a bundle
+	// gets the id BaseURI, and the document of type T the id
+	// BaseURI + "T.json".
+	BaseURI string
+}
+
+// Bundle returns a document with default settings that defines every
+// type declared in files under $defs.
+func Bundle(files ...*ast.File) (*Schema, error) {
+	return (&Config{}).Bundle(files...)
+}
+
+// Documents returns a document with default settings for every type
+// declared in files, keyed by type name.
+func Documents(files ...*ast.File) (map[string]*Schema, error) {
+	return (&Config{}).Documents(files...)
+}
+
+// Bundle returns a document that defines every type declared in files
+// under $defs. References between types have the form "#/$defs/T".
+func (cfg *Config) Bundle(files ...*ast.File) (*Schema, error) {
+	g, err := newGenerator(files)
+	if err != nil {
+		return nil, err
+	}
+	doc := &Schema{Schema: Draft, ID: cfg.BaseURI, Defs: make(map[string]*Schema)}
+	for _, s := range g.info.Specs {
+		doc.Defs[s.Name.Name] = g.typeSpec(s)
+	}
+	if g.err != nil {
+		return nil, g.err
+	}
+	return doc, nil
+}
+
+// Documents returns a document for every type declared in files, keyed
+// by type name. Each document is self-contained: it describes its type
+// at the top level and the types it depends on under $defs.
+func (cfg *Config) Documents(files ...*ast.File) (map[string]*Schema, error) {
+	g, err := newGenerator(files)
+	if err != nil {
+		return nil, err
+	}
+	docs := make(map[string]*Schema)
+	for _, s := range g.info.Specs {
+		g.root = s.Name.Name
+		g.refs = make(map[string]bool)
+		doc := g.typeSpec(s)
+		doc.Schema = Draft
+		if cfg.BaseURI != "" {
+			doc.ID = cfg.BaseURI + s.Name.Name + ".json"
+		}
+		// add the definitions of referenced types until there are no
+		// new references
+		for {
+			names := make([]string, 0, len(g.refs))
+			for name := range g.refs {
+				if doc.Defs[name] == nil {
+					names = append(names, name)
+				}
+			}
+			if len(names) == 0 {
+				break
+			}
+			sort.Strings(names)
+			if doc.Defs == nil {
+				doc.Defs = make(map[string]*Schema)
+			}
+			for _, name := range names {
+				doc.Defs[name] = g.typeSpec(g.info.Lookup(name))
+			}
+		}
+		docs[s.Name.Name] = doc
+	}
+	if g.err != nil {
+		return nil, g.err
+	}
+	return docs, nil
+}
+
+type generator struct {
+	info *types.Info
+	root string          // type described at the top level, or ""
+	refs map[string]bool // referenced types
+	err  error           // first error
+}
+
+func newGenerator(files []*ast.File) (*generator, error) {
+	info, err := types.NewInfo(files...)
+	if err != nil {
+		return nil, fmt.Errorf("jsonschema: %v", err)
+	}
+	return &generator{info: info, refs: make(map[string]bool)}, nil
+}
+
+func (g *generator) errorf(format string, args ...interface{}) {
+	if g.err == nil {
+		g.err = fmt.Errorf("jsonschema: "+format, args...)
+	}
+}
+
+func (g *generator) typeSpec(s *ast.TypeSpec) *Schema {
+	schema := g.schema(s.Type)
+	if schema.Ref != "" {
+		// keep the title and description apart from the reference
+		schema = &Schema{AllOf: []*Schema{schema}}
+	}
+	schema.Title = s.Name.Name
+	schema.Description = doc(s.Doc, s.Comment)
+	return schema
+}
+
+// schema returns the schema of values of type x.
+func (g *generator) schema(x ast.Expr) *Schema {
+	switch x := x.(type) {
+	case *ast.Ident:
+		if s := basicSchema(x.Name); s != nil {
+			return s
+		}
+		if g.info.Lookup(x.Name) == nil {
+			g.errorf("undeclared type %s", x.Name)
+			break
+		}
+		if x.Name == g.root {
+			return &Schema{Ref: "#"}
+		}
+		g.refs[x.Name] = true
+		return &Schema{Ref: "#/$defs/" + x.Name}
+
+	case *ast.SelectorExpr:
+		if pkg, ok := x.X.(*ast.Ident); ok && pkg.Name == "time" && x.Sel.Name == "Time" {
+			return &Schema{Type: "string", Format: "date-time"}
+		}
+		return &Schema{} // any value
+
+	case *ast.StarExpr:
+		return &Schema{AnyOf: []*Schema{g.schema(x.X), {Type: "null"}}}
+
+	case *ast.ParenExpr:
+		return g.schema(x.X)
+
+	case *ast.ArrayType:
+		if elt, ok := x.Elt.(*ast.Ident); ok && (elt.Name == "byte" || elt.Name == "uint8") {
+			return &Schema{Type: "string", ContentEncoding: "base64"}
+		}
+		return &Schema{Type: "array", Items: g.schema(x.Elt)}
+
+	case *ast.MapType:
+		return &Schema{Type: "object", AdditionalProperties: g.schema(x.Value)}
+
+	case *ast.StructType:
+		fields, err := g.info.Fields(x)
+		if err != nil {
+			g.errorf("%v", err)
+			break
+		}
+		s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+		for _, f := range fields {
+			name := f.PropertyName()
+			if name == "" {
+				continue
+			}
+			s.Properties[name] = g.fieldSchema(f)
+			if !f.Tags.Optional() {
+				s.Required = append(s.Required, name)
+			}
+		}
+		return s
+
+	case nil:
+		g.errorf("missing type")
+
+	default:
+		g.errorf("unsupported type %T", x)
+	}
+	return &Schema{}
+}
+
+func basicSchema(name string) *Schema {
+	var zero float64
+	switch name {
+	case "bool":
+		return &Schema{Type: "boolean"}
+	case "string":
+		return &Schema{Type: "string"}
+	case "int", "int8", "int16", "int32", "int64", "rune":
+		return &Schema{Type: "integer"}
+	case "uint", "uint8", "uint16", "uint32", "uint64", "uintptr", "byte":
+		return &Schema{Type: "integer", Minimum: &zero}
+	case "float32", "float64":
+		return &Schema{Type: "number"}
+	case "any", "interface{}":
+		return &Schema{}
+	}
+	return nil
+}
+
+// fieldSchema returns the schema of field f, including its description
+// and the constraints of its tag options.
+func (g *generator) fieldSchema(f *types.Field) *Schema {
+	s := g.schema(f.Type)
+	desc := doc(f.Field.Doc, f.Field.Comment)
+	def, hasDefault := f.Tags.Default()
+	enum, hasEnum := f.Tags.Enum()
+	r, hasRange := f.Tags.Range()
+	if s.Ref != "" && (desc != "" || hasDefault || hasEnum || hasRange) {
+		s = &Schema{AllOf: []*Schema{s}}
+	}
+	s.Description = desc
+
+	if hasDefault {
+		s.Default = s.value(def)
+	}
+	for _, v := range enum {
+		s.Enum = append(s.Enum, s.value(v))
+	}
+	if hasRange {
+		if r.HasMin {
+			if r.MinExcl {
+				s.ExclusiveMinimum = &r.Min
+			} else {
+				s.Minimum = &r.Min
+			}
+		}
+		if r.HasMax {
+			if r.MaxExcl {
+				s.ExclusiveMaximum = &r.Max
+			} else {
+				s.Maximum = &r.Max
+			}
+		}
+	}
+	return s
+}
+
+// value converts the tag option value v to a value of type s.
+func (s *Schema) value(v string) interface{} {
+	switch s.Type {
+	case "integer":
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return n
+		}
+	case "number":
+		if n, err := strconv.ParseFloat(v, 64); err == nil {
+			return n
+		}
+	case "boolean":
+		if b, err := strconv.ParseBool(v); err == nil {
+			return b
+		}
+	}
+	return v
+}
+
+// doc returns the text of the first non-empty comment group.
+func doc(list ...*ast.CommentGroup) string {
+	for _, cg := range list {
+		if text := strings.TrimSpace(cg.Text()); text != "" {
+			return text
+		}
+	}
+	return ""
+}
@@ -0,0 +1,67 @@
+package loader_test
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing/fstest"
+
+	"github.com/zeromicro/api-ast/ast"
+	"github.com/zeromicro/api-ast/loader"
+)
+
+func ExampleLoad() {
+	api, err := loader.Load(filepath.Join("testdata", "main.api"))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	for i, f := range api.Files {
+		fmt.Printf("%s: %d declarations\n", filepath.ToSlash(api.Filenames[i]), len(f.Decls))
+	}
+
+	// output:
+	// testdata/main.api: 2 declarations
+	// testdata/types.api: 3 declarations
+}
+
+func ExampleConfig_Load() {
+	fsys := fstest.MapFS{
+		"api/main.api": {Data: []byte(`import "types/user.api"
+
+service user-api {
+	@handler getUser
+	get /users/:id returns (User)
+}
+`)},
+		"api/types/user.api": {Data: []byte(`type User {
+	Name string
+}
+`)},
+	}
+	cfg := &loader.Config{
+		FS: fsys,
+		// an unsaved edit of user.api
+		Overlay: map[string][]byte{
+			"api/types/user.api": []byte(`type User {
+	Id   int64
+	Name string
+}
+`),
+		},
+	}
+	api, err := cfg.Load("api/main.api")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	for i, f := range api.Files {
+		fmt.Printf("%s: %d declarations\n", api.Filenames[i], len(f.Decls))
+	}
+	user := api.Files[1].Decls[0].(*ast.GenDecl).Specs[0].(*ast.TypeSpec)
+	fmt.Println(len(user.Type.(*ast.StructType).Fields.List), "fields")
+
+	// output:
+	// api/main.api: 2 declarations
+	// api/types/user.api: 1 declarations
+	// 2 fields
+}
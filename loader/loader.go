@@ -0,0 +1,170 @@
+// Package loader loads an api file together with all files it imports,
+// directly or indirectly.
+//
+// Import paths are file names. A relative path is interpreted relative
+// to the directory of the importing file. Every file is loaded once, no
+// matter how often it is imported; import cycles are thus harmless.
+//
+// Files are read from the operating system's file system or from an
+// fs.FS, such as an embed.FS, and an overlay of in-memory contents, such
+// as the unsaved buffers of an editor, can replace those of files.
+package loader
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/zeromicro/api-ast/ast"
+	"github.com/zeromicro/api-ast/diag"
+	"github.com/zeromicro/api-ast/parser"
+	"github.com/zeromicro/api-ast/scanner"
+	"github.com/zeromicro/api-ast/token"
+)
+
+// A Config controls how files are loaded.
+type Config struct {
+	Fset *token.FileSet // file set for position information; if nil, a new one is created
+	Mode parser.Mode    // parser mode
+
+	// FS is the file system the files are read from; if nil, the
+	// operating system's. With an FS, file names are slash-separated
+	// paths as accepted by fs.ValidPath, and absolute import paths are
+	// interpreted relative to the root of FS.
+	FS fs.FS
+
+	// Overlay maps file names to contents that replace those of the
+	// files, or that are used for files that do not exist. The names
+	// are cleaned file names, as in API.Filenames.
+	Overlay map[string][]byte
+
+	// Diagnostics, if set, receives the problems as diagnostics: the
+	// syntax errors of every file and the imports that failed.
+	Diagnostics func(d *diag.Diagnostic)
+}
+
+// An API is a set of loaded files.
+type API struct {
+	Fset      *token.FileSet
+	Files     []*ast.File // root file first, then imported files in depth-first order
+	Filenames []string    // Filenames[i] is the (cleaned) file name of Files[i]
+}
+
+// Load loads the api file filename and its imports with default settings
+// and comments.
+func Load(filename string) (*API, error) {
+	return (&Config{Mode: parser.ParseComments}).Load(filename)
+}
+
+// Load loads the api file filename and its imports. If files cannot be
+// read or contain syntax errors, Load returns the files it could parse
+// together with a scanner.ErrorList describing all problems.
+func (cfg *Config) Load(filename string) (*API, error) {
+	fset := cfg.Fset
+	if fset == nil {
+		fset = token.NewFileSet()
+	}
+	l := &loader{
+		cfg:  cfg,
+		api:  &API{Fset: fset},
+		seen: make(map[string]bool),
+	}
+	l.load(l.clean(filename), token.NoPos)
+	l.errors.Sort()
+	return l.api, l.errors.Err()
+}
+
+type loader struct {
+	cfg    *Config
+	api    *API
+	seen   map[string]bool // loaded file names
+	errors scanner.ErrorList
+}
+
+// load loads filename, which is imported at pos (or the root file if pos
+// is not valid), and then its imports.
+func (l *loader) load(filename string, pos token.Pos) {
+	if l.seen[filename] {
+		return
+	}
+	l.seen[filename] = true
+
+	src, err := l.read(filename)
+	if err != nil {
+		l.error(pos, err)
+		return
+	}
+	pcfg := &parser.Config{Mode: l.cfg.Mode, Diagnostics: l.cfg.Diagnostics}
+	f, err := pcfg.ParseFile(l.api.Fset, filename, src)
+	if f == nil {
+		l.error(pos, err)
+		return
+	}
+	if list, ok := err.(scanner.ErrorList); ok {
+		l.errors = append(l.errors, list...)
+	} else if err != nil {
+		l.error(pos, err)
+	}
+	l.api.Files = append(l.api.Files, f)
+	l.api.Filenames = append(l.api.Filenames, filename)
+
+	for _, spec := range f.Imports {
+		imp, err := strconv.Unquote(spec.Path.Value)
+		if err != nil || imp == "" {
+			continue // reported by the parser
+		}
+		l.load(l.resolve(filename, imp), spec.Pos())
+	}
+}
+
+// clean returns the cleaned form of the file name filename.
+func (l *loader) clean(filename string) string {
+	if l.cfg.FS != nil {
+		return path.Clean(filename)
+	}
+	return filepath.Clean(filename)
+}
+
+// resolve returns the name of the file imported as imp by the file
+// filename.
+func (l *loader) resolve(filename, imp string) string {
+	if l.cfg.FS != nil {
+		if strings.HasPrefix(imp, "/") {
+			return path.Clean(imp[1:])
+		}
+		return path.Join(path.Dir(filename), imp)
+	}
+	if !filepath.IsAbs(imp) {
+		imp = filepath.Join(filepath.Dir(filename), filepath.FromSlash(imp))
+	}
+	return filepath.Clean(imp)
+}
+
+// read returns the contents of the file filename.
+func (l *loader) read(filename string) ([]byte, error) {
+	if src, ok := l.cfg.Overlay[filename]; ok {
+		return src, nil
+	}
+	if l.cfg.FS != nil {
+		return fs.ReadFile(l.cfg.FS, filename)
+	}
+	return os.ReadFile(filename)
+}
+
+// error records the failure to load a file imported at pos.
+func (l *loader) error(pos token.Pos, err error) {
+	var epos token.Position
+	msg := err.Error()
+	if pos.IsValid() {
+		epos = l.api.Fset.Position(pos)
+		msg = fmt.Sprintf("cannot import: %v", err)
+	}
+	l.errors.Add(epos, msg)
+	if l.cfg.Diagnostics != nil {
+		l.cfg.Diagnostics(diag.New(diag.ImportFailed, diag.NewSpan(epos, token.Position{}), msg))
+	}
+}
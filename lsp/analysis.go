@@ -0,0 +1,152 @@
+package lsp
+
+import (
+	"os"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/zeromicro/api-ast/ast"
+	"github.com/zeromicro/api-ast/check"
+	"github.com/zeromicro/api-ast/diag"
+	"github.com/zeromicro/api-ast/loader"
+	"github.com/zeromicro/api-ast/parser"
+	"github.com/zeromicro/api-ast/token"
+)
+
+// A view is the analysis of a document: its syntax tree, those of the
+// files it imports, directly or indirectly, and the problems found.
+type view struct {
+	fset    *token.FileSet
+	file    *ast.File                // the document
+	tfile   *token.File              // the document's file in fset
+	invalid bool                     // set if the document has syntax errors
+	files   []*ast.File              // the document first, then the imported files
+	sources map[string][]byte        // sources by file name
+	decls   map[string]*ast.TypeSpec // type declarations by name; the first one wins
+	diags   diag.List                // problems, in any file
+}
+
+// analyze parses the document filename and the files it imports. The
+// sources of the open documents, which include filename, are taken from
+// overlay; those of other files are read from disk.
+func analyze(filename string, overlay map[string][]byte) *view {
+	v := &view{
+		fset:    token.NewFileSet(),
+		sources: make(map[string][]byte),
+		decls:   make(map[string]*ast.TypeSpec),
+	}
+	base := v.fset.Base()
+	cfg := &loader.Config{
+		Fset:    v.fset,
+		Mode:    parser.ParseComments | parser.AllErrors,
+		Overlay: overlay,
+		Diagnostics: func(d *diag.Diagnostic) {
+			v.diags.Add(d)
+			if d.Span.Filename == filename && d.Code != diag.ImportFailed {
+				v.invalid = true
+			}
+		},
+	}
+	api, _ := cfg.Load(filename)
+	v.files = api.Files
+	for _, name := range api.Filenames {
+		src, ok := overlay[name]
+		if !ok {
+			src, _ = os.ReadFile(name)
+		}
+		v.sources[name] = src
+	}
+	v.file = v.files[0]
+	v.tfile = v.fset.File(token.Pos(base))
+
+	for _, f := range v.files {
+		for _, s := range f.TypeSpecs() {
+			if v.decls[s.Name.Name] == nil {
+				v.decls[s.Name.Name] = s
+			}
+		}
+	}
+	v.diags = append(v.diags, check.Files(v.fset, v.files)...)
+	v.diags.Sort()
+	return v
+}
+
+// typeAt returns the identifier at the byte offset of the document if it
+// is a type reference or the name of a type declaration, together with
+// the declaration of the type, if any.
+func (v *view) typeAt(offset int) (*ast.Ident, *ast.TypeSpec) {
+	var found *ast.Ident
+	at := func(id *ast.Ident) {
+		start := v.fset.Position(id.Pos()).Offset
+		if found == nil && start <= offset && offset <= start+len(id.Name) {
+			found = id
+		}
+	}
+	v.file.TypeRefs(at)
+	for _, s := range v.file.TypeSpecs() {
+		at(s.Name)
+	}
+	if found == nil {
+		return nil, nil
+	}
+	return found, v.decls[found.Name]
+}
+
+// ----------------------------------------------------------------------------
+// Positions
+
+// position returns the LSP position of the byte offset in src.
+func position(src []byte, offset int) Position {
+	if offset > len(src) {
+		offset = len(src)
+	}
+	var p Position
+	for i := 0; i < offset; {
+		r, size := utf8.DecodeRune(src[i:])
+		switch {
+		case r == '\n':
+			p.Line++
+			p.Character = 0
+		case r >= 0x10000:
+			p.Character += 2 // surrogate pair
+		default:
+			p.Character++
+		}
+		i += size
+	}
+	return p
+}
+
+// offset returns the byte offset of the LSP position p in src. Positions
+// beyond the end of a line or of src are clamped.
+func offset(src []byte, p Position) int {
+	i := 0
+	for line := 0; line < p.Line; line++ {
+		j := strings.IndexByte(string(src[i:]), '\n')
+		if j < 0 {
+			return len(src)
+		}
+		i += j + 1
+	}
+	for n := 0; n < p.Character && i < len(src) && src[i] != '\n'; {
+		r, size := utf8.DecodeRune(src[i:])
+		n += len(utf16.Encode([]rune{r}))
+		i += size
+	}
+	return i
+}
+
+// rangeOf returns the LSP range from pos to end, which must be positions
+// in the same file.
+func (v *view) rangeOf(pos, end token.Pos) Range {
+	start := v.fset.Position(pos)
+	src := v.sources[start.Filename]
+	r := Range{Start: position(src, start.Offset)}
+	if end.IsValid() {
+		r.End = position(src, v.fset.Position(end).Offset)
+	} else {
+		r.End = r.Start
+	}
+	return r
+}
@@ -0,0 +1,86 @@
+package lsp_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+
+	"github.com/zeromicro/api-ast/lsp"
+)
+
+func ExampleServer_Serve() {
+	const src = `syntax = "v1"
+
+// User is a registered user.
+type User {
+	Name string ` + "`json:\"name\"`" + `
+	Team Team ` + "`json:\"team\"`" + `
+}
+
+service user-api {
+	@handler getUser
+	get /users/:id returns (User)
+}
+`
+	var in bytes.Buffer
+	send := func(id int, method string, params interface{}) {
+		msg := map[string]interface{}{"jsonrpc": "2.0", "method": method, "params": params}
+		if id > 0 {
+			msg["id"] = id
+		}
+		data, _ := json.Marshal(msg)
+		fmt.Fprintf(&in, "Content-Length: %d\r\n\r\n%s", len(data), data)
+	}
+	doc := map[string]string{"uri": "file:///work/user.api"}
+	send(1, "initialize", map[string]interface{}{})
+	send(0, "textDocument/didOpen", map[string]interface{}{
+		"textDocument": map[string]interface{}{"uri": doc["uri"], "languageId": "api", "version": 1, "text": src},
+	})
+	send(2, "textDocument/hover", map[string]interface{}{
+		"textDocument": doc, "position": map[string]int{"line": 10, "character": 26},
+	})
+	send(3, "textDocument/definition", map[string]interface{}{
+		"textDocument": doc, "position": map[string]int{"line": 10, "character": 26},
+	})
+	send(4, "shutdown", nil)
+	send(0, "exit", nil)
+
+	var out bytes.Buffer
+	if err := lsp.NewServer().Serve(&in, &out); err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	r := bufio.NewReader(&out)
+	for {
+		header, err := textproto.NewReader(r).ReadMIMEHeader()
+		if err == io.EOF {
+			break
+		}
+		n, _ := strconv.Atoi(header.Get("Content-Length"))
+		data := make([]byte, n)
+		io.ReadFull(r, data)
+		var msg struct {
+			ID     int
+			Method string
+			Params json.RawMessage
+			Result json.RawMessage
+		}
+		json.Unmarshal(data, &msg)
+		switch {
+		case msg.Method == "textDocument/publishDiagnostics":
+			fmt.Printf("diagnostics: %s\n", msg.Params)
+		case msg.ID == 2 || msg.ID == 3:
+			fmt.Printf("%d: %s\n", msg.ID, msg.Result)
+		}
+	}
+
+	// Output:
+	// diagnostics: {"uri":"file:///work/user.api","diagnostics":[{"range":{"start":{"line":5,"character":6},"end":{"line":5,"character":10}},"severity":1,"code":"E0203","source":"api","message":"undeclared type Team"}]}
+	// 2: {"contents":{"kind":"markdown","value":"```api\ntype User {\n\tName string `json:\"name\"`\n\tTeam Team   `json:\"team\"`\n}\n```\n\nUser is a registered user."},"range":{"start":{"line":10,"character":25},"end":{"line":10,"character":29}}}
+	// 3: {"uri":"file:///work/user.api","range":{"start":{"line":3,"character":5},"end":{"line":3,"character":9}}}
+}
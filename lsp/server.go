@@ -0,0 +1,591 @@
+// Package lsp implements a language server for api files, speaking the
+// Language Server Protocol over a pair of streams such as standard input
+// and output.
+//
+// The server keeps the open documents in memory and analyzes a document,
+// together with the files it imports, whenever it changes. It provides
+//
+//   - diagnostics: syntax errors, failed imports, references to
+//     undeclared types, redeclared types, and duplicate handlers and
+//     routes, with their codes and related declarations;
+//   - quick fixes for the diagnostics that have fixes;
+//   - formatting with the printer package;
+//   - hover, showing the declaration and doc comment of a type;
+//   - go to definition for type references in fields and routes;
+//   - completion of type names, keywords, annotations and the keys of
+//     info and @server declarations;
+//   - document symbols for types and their fields, and for services and
+//     their routes.
+//
+// Imported files that are not open are read from disk.
+package lsp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/zeromicro/api-ast/ast"
+	"github.com/zeromicro/api-ast/diag"
+	"github.com/zeromicro/api-ast/printer"
+	"github.com/zeromicro/api-ast/token"
+	"github.com/zeromicro/api-ast/types"
+)
+
+// A Server is a language server for api files.
+type Server struct {
+	// Log, if set, receives a line for every failed request.
+	Log io.Writer
+
+	out      io.Writer
+	docs     map[string][]byte // open documents by URI
+	shutdown bool              // set by the shutdown request
+}
+
+// NewServer returns a new server.
+func NewServer() *Server {
+	return &Server{docs: make(map[string][]byte)}
+}
+
+// Serve reads requests and notifications from r and writes responses and
+// notifications to w until the client sends the exit notification or r
+// reaches EOF. Messages are handled one at a time, in order.
+func (s *Server) Serve(r io.Reader, w io.Writer) error {
+	in := bufio.NewReader(r)
+	s.out = w
+	for {
+		msg, err := readMessage(in)
+		if err == io.EOF {
+			return nil
+		}
+		var rerr *responseError
+		if errors.As(err, &rerr) {
+			if err := writeMessage(w, &errorResponse{JSONRPC: "2.0", Error: rerr}); err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if msg.Method == "exit" {
+			if !s.shutdown {
+				return errors.New("lsp: exit without shutdown")
+			}
+			return nil
+		}
+
+		result, err := s.handle(msg)
+		if msg.ID == nil {
+			// a notification has no response
+			if err != nil {
+				s.logf("%s: %v", msg.Method, err)
+			}
+			continue
+		}
+		var resp interface{} = &response{JSONRPC: "2.0", ID: msg.ID, Result: result}
+		if err != nil {
+			s.logf("%s: %v", msg.Method, err)
+			if !errors.As(err, &rerr) {
+				rerr = &responseError{codeInternalError, err.Error()}
+			}
+			resp = &errorResponse{JSONRPC: "2.0", ID: msg.ID, Error: rerr}
+		}
+		if err := writeMessage(w, resp); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *Server) logf(format string, args ...interface{}) {
+	if s.Log != nil {
+		fmt.Fprintf(s.Log, "lsp: "+format+"\n", args...)
+	}
+}
+
+// notify sends a notification to the client.
+func (s *Server) notify(method string, params interface{}) {
+	data, err := json.Marshal(params)
+	if err != nil {
+		s.logf("%s: %v", method, err)
+		return
+	}
+	if err := writeMessage(s.out, &message{JSONRPC: "2.0", Method: method, Params: data}); err != nil {
+		s.logf("%s: %v", method, err)
+	}
+}
+
+// handle handles the request or notification msg and returns the result
+// of a request.
+func (s *Server) handle(msg *message) (interface{}, error) {
+	decode := func(params interface{}) error {
+		if err := json.Unmarshal(msg.Params, params); err != nil {
+			return &responseError{codeInvalidParams, err.Error()}
+		}
+		return nil
+	}
+
+	switch msg.Method {
+	case "initialize":
+		var res initializeResult
+		res.Capabilities = serverCapabilities{
+			TextDocumentSync:           1,
+			HoverProvider:              true,
+			DefinitionProvider:         true,
+			DocumentFormattingProvider: true,
+			DocumentSymbolProvider:     true,
+			CodeActionProvider:         true,
+			CompletionProvider:         &completionOptions{TriggerCharacters: []string{"@"}},
+		}
+		res.ServerInfo.Name = "apilsp"
+		return &res, nil
+
+	case "initialized":
+		return nil, nil
+
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+
+	case "textDocument/didOpen":
+		var params didOpenParams
+		if err := decode(&params); err != nil {
+			return nil, err
+		}
+		s.docs[params.TextDocument.URI] = []byte(params.TextDocument.Text)
+		s.publishDiagnostics()
+		return nil, nil
+
+	case "textDocument/didChange":
+		var params didChangeParams
+		if err := decode(&params); err != nil {
+			return nil, err
+		}
+		// the server requests full document sync
+		if n := len(params.ContentChanges); n > 0 {
+			s.docs[params.TextDocument.URI] = []byte(params.ContentChanges[n-1].Text)
+		}
+		s.publishDiagnostics()
+		return nil, nil
+
+	case "textDocument/didClose":
+		var params didCloseParams
+		if err := decode(&params); err != nil {
+			return nil, err
+		}
+		delete(s.docs, params.TextDocument.URI)
+		s.notify("textDocument/publishDiagnostics", &publishDiagnosticsParams{URI: params.TextDocument.URI, Diagnostics: []*Diagnostic{}})
+		s.publishDiagnostics()
+		return nil, nil
+
+	case "textDocument/formatting":
+		var params documentParams
+		if err := decode(&params); err != nil {
+			return nil, err
+		}
+		return s.withView(params.TextDocument.URI, s.formatting)
+
+	case "textDocument/documentSymbol":
+		var params documentParams
+		if err := decode(&params); err != nil {
+			return nil, err
+		}
+		return s.withView(params.TextDocument.URI, s.documentSymbols)
+
+	case "textDocument/codeAction":
+		var params codeActionParams
+		if err := decode(&params); err != nil {
+			return nil, err
+		}
+		uri := params.TextDocument.URI
+		return s.withView(uri, func(v *view) (interface{}, error) {
+			return s.codeActions(uri, params.Range, v)
+		})
+
+	case "textDocument/hover", "textDocument/definition", "textDocument/completion":
+		var params textDocumentPositionParams
+		if err := decode(&params); err != nil {
+			return nil, err
+		}
+		uri := params.TextDocument.URI
+		return s.withView(uri, func(v *view) (interface{}, error) {
+			off := offset(s.docs[uri], params.Position)
+			switch msg.Method {
+			case "textDocument/hover":
+				return s.hover(v, off)
+			case "textDocument/definition":
+				return s.definition(uri, v, off)
+			}
+			return s.completion(v, off)
+		})
+	}
+
+	if msg.ID != nil {
+		return nil, &responseError{codeMethodNotFound, "method not supported: " + msg.Method}
+	}
+	return nil, nil // ignore unknown notifications
+}
+
+// withView calls fn with the analysis of the open document uri.
+func (s *Server) withView(uri string, fn func(*view) (interface{}, error)) (interface{}, error) {
+	if _, ok := s.docs[uri]; !ok {
+		return nil, &responseError{codeInvalidParams, "document not open: " + uri}
+	}
+	return fn(analyze(uriToPath(uri), s.overlay()))
+}
+
+// overlay returns the sources of the open documents by file name.
+func (s *Server) overlay() map[string][]byte {
+	m := make(map[string][]byte, len(s.docs))
+	for uri, src := range s.docs {
+		m[uriToPath(uri)] = src
+	}
+	return m
+}
+
+func uriToPath(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return uri
+	}
+	return filepath.Clean(filepath.FromSlash(u.Path))
+}
+
+func pathToURI(path string) string {
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String()
+}
+
+// ----------------------------------------------------------------------------
+// Diagnostics
+
+// publishDiagnostics publishes the diagnostics of all open documents,
+// which may import each other.
+func (s *Server) publishDiagnostics() {
+	uris := make([]string, 0, len(s.docs))
+	for uri := range s.docs {
+		uris = append(uris, uri)
+	}
+	sort.Strings(uris)
+	for _, uri := range uris {
+		filename := uriToPath(uri)
+		v := analyze(filename, s.overlay())
+		diags := []*Diagnostic{}
+		for _, d := range v.diags {
+			if d.Span.Filename == filename {
+				diags = append(diags, v.diagnostic(d))
+			}
+		}
+		s.notify("textDocument/publishDiagnostics", &publishDiagnosticsParams{URI: uri, Diagnostics: diags})
+	}
+}
+
+// diagnostic returns the LSP diagnostic of d.
+func (v *view) diagnostic(d *diag.Diagnostic) *Diagnostic {
+	severity := SeverityError
+	if d.Severity != diag.Error {
+		severity = SeverityWarning
+	}
+	ld := &Diagnostic{
+		Range:    v.spanRange(d.Span),
+		Severity: severity,
+		Code:     string(d.Code),
+		Source:   "api",
+		Message:  d.Message,
+	}
+	for _, r := range d.Related {
+		ld.RelatedInformation = append(ld.RelatedInformation, &DiagnosticRelatedInformation{
+			Location: Location{URI: pathToURI(r.Span.Filename), Range: v.spanRange(r.Span)},
+			Message:  r.Message,
+		})
+	}
+	return ld
+}
+
+// spanRange returns the LSP range of the span. An empty span is widened
+// to the word, or else the character, at its start.
+func (v *view) spanRange(span diag.Span) Range {
+	src := v.sources[span.Filename]
+	start, end := span.Start.Offset, span.End.Offset
+	if end <= start {
+		end = start
+		for end < len(src) && isWordChar(src[end]) {
+			end++
+		}
+		if end == start && end < len(src) && src[end] != '\n' {
+			end++
+		}
+	}
+	return Range{position(src, start), position(src, end)}
+}
+
+func isWordChar(c byte) bool {
+	return c == '_' || c == '@' || '0' <= c && c <= '9' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || c >= 0x80
+}
+
+// codeActions returns the quick fixes of the diagnostics of the document
+// uri that overlap the range r.
+func (s *Server) codeActions(uri string, r Range, v *view) (interface{}, error) {
+	filename := uriToPath(uri)
+	src := s.docs[uri]
+	actions := []*CodeAction{}
+	for _, d := range v.diags {
+		if d.Span.Filename != filename || len(d.Fixes) == 0 {
+			continue
+		}
+		ld := v.diagnostic(d)
+		if less(r.End, ld.Range.Start) || less(ld.Range.End, r.Start) {
+			continue
+		}
+		for _, fix := range d.Fixes {
+			var edits []*TextEdit
+			for _, e := range fix.Edits {
+				edits = append(edits, &TextEdit{
+					Range:   Range{position(src, e.Span.Start.Offset), position(src, e.Span.End.Offset)},
+					NewText: e.NewText,
+				})
+			}
+			actions = append(actions, &CodeAction{
+				Title:       fix.Message,
+				Kind:        "quickfix",
+				Diagnostics: []*Diagnostic{ld},
+				Edit:        &WorkspaceEdit{Changes: map[string][]*TextEdit{uri: edits}},
+			})
+		}
+	}
+	return actions, nil
+}
+
+// less reports whether the position p is before q.
+func less(p, q Position) bool {
+	return p.Line < q.Line || p.Line == q.Line && p.Character < q.Character
+}
+
+// ----------------------------------------------------------------------------
+// Formatting
+
+// printConfig is the configuration used for formatting, as by apifmt.
+var printConfig = printer.Config{Mode: printer.UseSpaces | printer.TabIndent, TabWidth: 8}
+
+func (s *Server) formatting(v *view) (interface{}, error) {
+	if v.invalid {
+		return nil, nil // printing would lose the erroneous parts
+	}
+	var buf bytes.Buffer
+	if err := printConfig.Fprint(&buf, v.fset, v.file); err != nil {
+		return nil, err
+	}
+	src := v.sources[v.tfile.Name()]
+	if bytes.Equal(buf.Bytes(), src) {
+		return []*TextEdit{}, nil
+	}
+	return []*TextEdit{{
+		Range:   Range{Position{}, position(src, len(src))},
+		NewText: buf.String(),
+	}}, nil
+}
+
+// ----------------------------------------------------------------------------
+// Hover and definition
+
+func (s *Server) hover(v *view, off int) (interface{}, error) {
+	id, spec := v.typeAt(off)
+	if spec == nil {
+		return nil, nil
+	}
+	decl := *spec
+	decl.Doc = nil
+	var buf bytes.Buffer
+	buf.WriteString("```api\ntype ")
+	if err := printConfig.Fprint(&buf, v.fset, &decl); err != nil {
+		return nil, err
+	}
+	buf.WriteString("\n```")
+	doc := spec.Doc
+	if doc == nil {
+		doc = spec.Comment
+	}
+	if text := strings.TrimSpace(doc.Text()); text != "" {
+		buf.WriteString("\n\n" + text)
+	}
+	r := v.rangeOf(id.Pos(), id.End())
+	return &Hover{Contents: MarkupContent{Kind: "markdown", Value: buf.String()}, Range: &r}, nil
+}
+
+func (s *Server) definition(uri string, v *view, off int) (interface{}, error) {
+	_, spec := v.typeAt(off)
+	if spec == nil {
+		return nil, nil
+	}
+	filename := v.fset.Position(spec.Name.Pos()).Filename
+	if filename != uriToPath(uri) {
+		uri = pathToURI(filename)
+	}
+	return &Location{URI: uri, Range: v.rangeOf(spec.Name.Pos(), spec.Name.End())}, nil
+}
+
+// ----------------------------------------------------------------------------
+// Completion
+
+var (
+	topLevelKeywords = []string{"syntax", "info", "import", "type", "service", "@server"}
+	routeKeywords    = []string{"@doc", "@handler", "get", "post", "put", "delete", "patch", "head", "options", "returns"}
+	infoKeys         = []string{"title", "desc", "author", "email", "version"}
+	serverKeys       = []string{"jwt", "group", "prefix", "middleware", "timeout", "maxBytes", "signature"}
+)
+
+func (s *Server) completion(v *view, off int) (interface{}, error) {
+	list := &CompletionList{Items: []*CompletionItem{}}
+	keywords := func(kind int, words ...string) {
+		for _, w := range words {
+			list.Items = append(list.Items, &CompletionItem{Label: w, Kind: kind})
+		}
+	}
+	typeNames := func() {
+		names := make([]string, 0, len(v.decls))
+		for name := range v.decls {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			item := &CompletionItem{Label: name, Kind: CompletionStruct}
+			if text := strings.TrimSpace(v.decls[name].Doc.Text()); text != "" {
+				item.Documentation = &MarkupContent{Kind: "markdown", Value: text}
+			}
+			list.Items = append(list.Items, item)
+		}
+		builtin := types.PredeclaredNames()
+		sort.Strings(builtin)
+		keywords(CompletionTypeName, builtin...)
+	}
+
+	pos := v.tfile.Pos(off)
+	within := func(n ast.Node) bool { return n.Pos() <= pos && pos <= n.End() }
+	for _, d := range v.file.Decls {
+		switch d := d.(type) {
+		case *ast.InfoType:
+			if within(d) {
+				keywords(CompletionProperty, infoKeys...)
+				return list, nil
+			}
+		case *ast.Service:
+			if d.AtServer != nil && within(d.AtServer) {
+				keywords(CompletionProperty, serverKeys...)
+				return list, nil
+			}
+			if within(d.ServiceApi) {
+				keywords(CompletionKeyword, routeKeywords...)
+				typeNames()
+				return list, nil
+			}
+		case *ast.GenDecl:
+			if d.Tok == token.TYPE && within(d) {
+				typeNames()
+				return list, nil
+			}
+		}
+	}
+	keywords(CompletionKeyword, topLevelKeywords...)
+	typeNames()
+	return list, nil
+}
+
+// ----------------------------------------------------------------------------
+// Document symbols
+
+func (s *Server) documentSymbols(v *view) (interface{}, error) {
+	symbols := []*DocumentSymbol{}
+	for _, d := range v.file.Decls {
+		switch d := d.(type) {
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				if spec, ok := spec.(*ast.TypeSpec); ok {
+					symbols = append(symbols, v.typeSymbol(d, spec))
+				}
+			}
+		case *ast.Service:
+			symbols = append(symbols, v.serviceSymbol(d))
+		}
+	}
+	return symbols, nil
+}
+
+func (v *view) typeSymbol(d *ast.GenDecl, spec *ast.TypeSpec) *DocumentSymbol {
+	sym := &DocumentSymbol{
+		Name:           spec.Name.Name,
+		Kind:           SymbolClass,
+		Range:          v.rangeOf(spec.Pos(), spec.End()),
+		SelectionRange: v.rangeOf(spec.Name.Pos(), spec.Name.End()),
+	}
+	if len(d.Specs) == 1 && !d.Lparen.IsValid() {
+		sym.Range = v.rangeOf(d.Pos(), d.End())
+	}
+	st, ok := spec.Type.(*ast.StructType)
+	if !ok {
+		sym.Detail = v.exprString(spec.Type)
+		return sym
+	}
+	sym.Kind = SymbolStruct
+	for _, f := range st.Fields.List {
+		names := f.Names
+		if len(names) == 0 {
+			// embedded field
+			if id, ok := f.Type.(*ast.Ident); ok {
+				names = []*ast.Ident{id}
+			}
+		}
+		for _, name := range names {
+			sym.Children = append(sym.Children, &DocumentSymbol{
+				Name:           name.Name,
+				Detail:         v.exprString(f.Type),
+				Kind:           SymbolField,
+				Range:          v.rangeOf(f.Pos(), f.End()),
+				SelectionRange: v.rangeOf(name.Pos(), name.End()),
+			})
+		}
+	}
+	return sym
+}
+
+func (v *view) serviceSymbol(d *ast.Service) *DocumentSymbol {
+	api := d.ServiceApi
+	sym := &DocumentSymbol{
+		Name:           api.Name.Name,
+		Kind:           SymbolInterface,
+		Range:          v.rangeOf(d.Pos(), d.End()),
+		SelectionRange: v.rangeOf(api.Name.Pos(), api.Name.End()),
+	}
+	if group := d.AtServer.Value("group"); group != "" {
+		sym.Detail = "group " + group
+	}
+	prefix := d.AtServer.Value("prefix")
+	for _, sr := range api.ServiceRoute {
+		rt := sr.Route
+		if rt == nil || rt.Method == nil || rt.Path == nil {
+			continue
+		}
+		sym.Children = append(sym.Children, &DocumentSymbol{
+			Name:           rt.Method.Name + " " + prefix + rt.Path.Name,
+			Detail:         sr.AtHandler.Text(),
+			Kind:           SymbolMethod,
+			Range:          v.rangeOf(sr.Pos(), sr.End()),
+			SelectionRange: v.rangeOf(rt.Method.Pos(), rt.Path.End()),
+		})
+	}
+	return sym
+}
+
+// exprString returns the source of the type x.
+func (v *view) exprString(x ast.Expr) string {
+	var buf bytes.Buffer
+	if err := printConfig.Fprint(&buf, v.fset, x); err != nil {
+		return ""
+	}
+	return buf.String()
+}
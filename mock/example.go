@@ -0,0 +1,260 @@
+package mock
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/zeromicro/api-ast/ast"
+	"github.com/zeromicro/api-ast/tag"
+	"github.com/zeromicro/api-ast/types"
+)
+
+// An object is a JSON object that keeps its keys in insertion order.
+type object struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func newObject() *object { return &object{values: make(map[string]interface{})} }
+
+func (o *object) set(key string, v interface{}) {
+	if _, ok := o.values[key]; !ok {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = v
+}
+
+func (o *object) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, k := range o.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(k)
+		val, err := json.Marshal(o.values[k])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(val)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// An exampler synthesizes example values.
+type exampler struct {
+	info     *types.Info
+	fixtures fs.FS
+	stack    map[string]bool // declared types being synthesized, against recursion
+	err      error           // first fixture error
+}
+
+// field returns an example value of type x for a field named name with
+// the tags tags; name is empty for a whole body.
+func (e *exampler) field(x ast.Expr, name string, tags tag.Tags) interface{} {
+	switch t := x.(type) {
+	case *ast.Ident:
+		if s := e.info.Lookup(t.Name); s != nil {
+			if e.stack[t.Name] {
+				return nil // recursive type
+			}
+			e.stack[t.Name] = true
+			v := e.field(s.Type, name, tags)
+			delete(e.stack, t.Name)
+			return e.fixture(t.Name, v)
+		}
+		return basicValue(t.Name, name, tags)
+	case *ast.SelectorExpr:
+		if pkg, ok := t.X.(*ast.Ident); ok && pkg.Name == "time" && t.Sel.Name == "Time" {
+			return "2006-01-02T15:04:05Z"
+		}
+		return nil
+	case *ast.StarExpr:
+		return e.field(t.X, name, tags)
+	case *ast.ParenExpr:
+		return e.field(t.X, name, tags)
+	case *ast.ArrayType:
+		if elt, ok := t.Elt.(*ast.Ident); ok && (elt.Name == "byte" || elt.Name == "uint8") {
+			return "" // base64
+		}
+		if v := e.field(t.Elt, name, tags); v != nil {
+			return []interface{}{v}
+		}
+		return []interface{}{}
+	case *ast.MapType:
+		o := newObject()
+		if v := e.field(t.Value, name, nil); v != nil {
+			key := "key"
+			if k, ok := basicValue(e.info.UnderlyingName(t.Key), "", nil).(int64); ok {
+				key = strconv.FormatInt(k, 10)
+			}
+			o.set(key, v)
+		}
+		return o
+	case *ast.StructType:
+		fields, err := e.info.Fields(t)
+		if err != nil {
+			return nil
+		}
+		o := newObject()
+		for _, f := range fields {
+			// responses are encoded as JSON
+			key := f.Name
+			if jt := f.Tags.Get(tag.JSON); jt != nil {
+				if jt.Name == "-" {
+					continue
+				}
+				if jt.Name != "" {
+					key = jt.Name
+				}
+			}
+			o.set(key, e.field(f.Type, key, f.Tags))
+		}
+		return o
+	}
+	return nil
+}
+
+// basicValue returns an example value of the predeclared type typ for a
+// field named name with the tags tags, or nil if typ is not a scalar.
+func basicValue(typ, name string, tags tag.Tags) interface{} {
+	kind := types.Basic(typ)
+	if kind == types.Invalid {
+		return nil
+	}
+	if v, ok := tags.Default(); ok {
+		if x, err := kind.Parse(v); err == nil {
+			return x
+		}
+	}
+	if opts, ok := tags.Enum(); ok {
+		if x, err := kind.Parse(opts[0]); err == nil {
+			return x
+		}
+	}
+	if r, ok := tags.Range(); ok {
+		if x, ok := rangeValue(kind, r); ok {
+			return x
+		}
+	}
+	switch kind {
+	case types.Bool:
+		return true
+	case types.Int, types.Uint:
+		return int64(1)
+	case types.Float:
+		return 1.5
+	}
+	if name == "" {
+		return "string"
+	}
+	return name
+}
+
+// rangeValue returns a number of the kind within r, preferably its lower
+// bound or the next number above an exclusive one, and reports whether
+// there is one.
+func rangeValue(kind types.BasicKind, r tag.Range) (interface{}, bool) {
+	var n float64
+	switch {
+	case kind != types.Int && kind != types.Uint && kind != types.Float:
+		return nil, false
+	case r.HasMin:
+		n = r.Min
+		if kind != types.Float {
+			n = math.Ceil(n)
+		}
+		if r.MinExcl && n == r.Min {
+			n++
+			if kind == types.Float && r.HasMax {
+				n = r.Min + (r.Max-r.Min)/2
+			}
+		}
+	case r.HasMax:
+		n = r.Max
+		if kind != types.Float {
+			n = math.Floor(n)
+		}
+		if r.MaxExcl && n == r.Max {
+			n--
+		}
+	default:
+		return nil, false
+	}
+	if !r.Contains(n) {
+		return nil, false
+	}
+	switch kind {
+	case types.Int:
+		return int64(n), true
+	case types.Uint:
+		if n < 0 {
+			return nil, false
+		}
+		return uint64(n), true
+	}
+	return n, true
+}
+
+// fixture merges the fixture name.json, if any, into v.
+func (e *exampler) fixture(name string, v interface{}) interface{} {
+	if e.fixtures == nil {
+		return v
+	}
+	data, err := fs.ReadFile(e.fixtures, name+".json")
+	if errors.Is(err, fs.ErrNotExist) {
+		return v
+	}
+	if err != nil {
+		e.setErr(err)
+		return v
+	}
+	var fix interface{}
+	if err := json.Unmarshal(data, &fix); err != nil {
+		e.setErr(fmt.Errorf("fixture %s.json: %v", name, err))
+		return v
+	}
+	return merge(v, fix)
+}
+
+func (e *exampler) setErr(err error) {
+	if e.err == nil {
+		e.err = fmt.Errorf("mock: %v", err)
+	}
+}
+
+// merge returns the example v overridden by the fixture value fix.
+func merge(v, fix interface{}) interface{} {
+	o, ok := v.(*object)
+	m, ok2 := fix.(map[string]interface{})
+	if !ok || !ok2 {
+		return fix
+	}
+	res := newObject()
+	for _, k := range o.keys {
+		res.set(k, o.values[k])
+		if fv, ok := m[k]; ok {
+			res.set(k, merge(o.values[k], fv))
+		}
+	}
+	extra := make([]string, 0, len(m))
+	for k := range m {
+		if _, ok := o.values[k]; !ok {
+			extra = append(extra, k)
+		}
+	}
+	sort.Strings(extra)
+	for _, k := range extra {
+		res.set(k, m[k])
+	}
+	return res
+}
@@ -0,0 +1,73 @@
+package mock_test
+
+import (
+	"fmt"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing/fstest"
+
+	"github.com/zeromicro/api-ast/mock"
+	"github.com/zeromicro/api-ast/parser"
+	"github.com/zeromicro/api-ast/token"
+)
+
+func ExampleConfig_NewHandler() {
+	const src = `
+type UserReq {
+	ID int64 ` + "`path:\"id\"`" + `
+}
+
+type User {
+	ID     int64    ` + "`json:\"id\"`" + `
+	Name   string   ` + "`json:\"name\"`" + `
+	Status string   ` + "`json:\"status,options=active|banned\"`" + `
+	Roles  []string ` + "`json:\"roles\"`" + `
+	Level  int      ` + "`json:\"level,range=(5:10]\"`" + `
+	Score  float64  ` + "`json:\"score,range=(0:1)\"`" + `
+}
+
+service user-api {
+	@handler getUser
+	get /users/:id (UserReq) returns (User)
+}
+`
+	f, err := parser.ParseFile(token.NewFileSet(), "user.api", src, 0)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	cfg := &mock.Config{
+		Fixtures: fstest.MapFS{
+			"getUser.json": {Data: []byte(`{"name": "Alice"}`)},
+		},
+		Validate: true,
+	}
+	h, err := cfg.NewHandler(f)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	for _, url := range []string{"/users/42", "/users/alice"} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", url, nil))
+		body, _ := io.ReadAll(w.Body)
+		fmt.Printf("%s: %d\n%s\n", url, w.Code, strings.TrimSpace(string(body)))
+	}
+
+	// Output:
+	// /users/42: 200
+	// {
+	//   "id": 1,
+	//   "name": "Alice",
+	//   "status": "active",
+	//   "roles": [
+	//     "roles"
+	//   ],
+	//   "level": 6,
+	//   "score": 0.5
+	// }
+	// /users/alice: 400
+	// {"errors":[{"in":"path","field":"id","rule":"type","message":"path \"id\": invalid integer \"alice\""}]}
+}
@@ -0,0 +1,141 @@
+// Package mock serves mock responses for the routes of an API, so that
+// clients can be developed before the server exists.
+//
+// The handler answers every route with the method and path of the route,
+// where a path parameter such as :id matches any path segment. The
+// response body is an example value of the response type: structs are
+// JSON objects with their fields in source order, slices hold a single
+// element and maps a single entry. Scalar fields take the value of their
+// default option, else their first option, else the lower bound of their
+// range; strings default to the name of their field.
+//
+// Fixtures override the synthesized values. For a route with the handler
+// h, the fixture h.json is merged into the response body; for a declared
+// type T, the fixture T.json is merged into every example value of T.
+// Objects are merged key by key, recursively; other values replace the
+// example. Fixtures are read on every request, so edits take effect
+// immediately.
+//
+// If validation is enabled, requests are checked against the request
+// type of their route by package validate, and those that do not match
+// are answered with 400 Bad Request and the list of problems.
+package mock
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/zeromicro/api-ast/ast"
+	"github.com/zeromicro/api-ast/types"
+	"github.com/zeromicro/api-ast/validate"
+)
+
+// A Config controls the mock handler.
+type Config struct {
+	// Fixtures holds the fixtures that override example values, as
+	// files named after handlers and types; may be nil.
+	Fixtures fs.FS
+
+	// Validate enables checking requests against the request types.
+	Validate bool
+}
+
+// NewHandler returns a handler serving the routes declared in files,
+// using the default configuration.
+func NewHandler(files ...*ast.File) (http.Handler, error) {
+	return (&Config{}).NewHandler(files...)
+}
+
+// NewHandler returns a handler serving the routes declared in files.
+func (cfg *Config) NewHandler(files ...*ast.File) (http.Handler, error) {
+	info, err := types.NewInfo(files...)
+	if err != nil {
+		return nil, fmt.Errorf("mock: %v", err)
+	}
+	h := &handler{cfg: cfg, info: info}
+	if cfg.Validate {
+		if h.validator, err = validate.New(files...); err != nil {
+			return nil, fmt.Errorf("mock: %v", err)
+		}
+	}
+	for _, r := range types.Routes(files...) {
+		for _, x := range []ast.Expr{r.Req, r.Resp} {
+			if id, ok := x.(*ast.Ident); ok && info.Lookup(id.Name) == nil {
+				return nil, fmt.Errorf("mock: %s: undeclared type %s", r, id.Name)
+			}
+		}
+		h.routes = append(h.routes, r)
+	}
+	return h, nil
+}
+
+type handler struct {
+	cfg       *Config
+	info      *types.Info
+	validator *validate.Validator // nil if validation is disabled
+	routes    []*types.Route
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	var allow []string
+	for _, r := range h.routes {
+		if _, ok := r.Match(req.URL.Path); !ok {
+			continue
+		}
+		if r.Method != req.Method {
+			allow = append(allow, r.Method)
+			continue
+		}
+		h.serve(w, req, r)
+		return
+	}
+	if allow != nil {
+		sort.Strings(allow)
+		w.Header().Set("Allow", strings.Join(allow, ", "))
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	http.NotFound(w, req)
+}
+
+// serve answers the request req of the route r.
+func (h *handler) serve(w http.ResponseWriter, req *http.Request, r *types.Route) {
+	if h.validator != nil {
+		if _, err := h.validator.Validate(req); err != nil {
+			if err, ok := err.(*validate.Error); ok {
+				validate.WriteError(w, err)
+			} else {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+			}
+			return
+		}
+	}
+	if r.Resp == nil {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	e := &exampler{info: h.info, fixtures: h.cfg.Fixtures, stack: make(map[string]bool)}
+	v := e.field(r.Resp, "", nil)
+	if r.Handler != "" {
+		v = e.fixture(r.Handler, v)
+	}
+	if e.err != nil {
+		http.Error(w, e.err.Error(), http.StatusInternalServerError)
+		return
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Write(buf.Bytes())
+}
@@ -0,0 +1,186 @@
+package openapi_test
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/zeromicro/api-ast/openapi"
+	"github.com/zeromicro/api-ast/parser"
+	"github.com/zeromicro/api-ast/printer"
+	"github.com/zeromicro/api-ast/token"
+)
+
+func ExampleExport() {
+	fset := token.NewFileSet() // positions are relative to fset
+
+	src := `syntax = "v1"
+
+info (
+	title: "User API"
+	version: "1.0"
+)
+
+type Req {
+	Id int64 ` + "`path:\"id\"`" + `
+}
+
+type User {
+	Name string ` + "`json:\"name\"`" + `
+}
+
+@server(
+	group: user
+)
+service user-api {
+	@doc "get a user"
+	@handler getUser
+	get /user/:id (Req) returns (User)
+}
+`
+
+	f, err := parser.ParseFile(fset, "user.api", src, parser.ParseComments)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	doc, err := openapi.Export(f)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	out, err := doc.YAML()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Print(string(out))
+
+	// output:
+	// openapi: "3.0.3"
+	// info:
+	//   title: User API
+	//   version: "1.0"
+	// tags:
+	//   - name: user
+	// paths:
+	//   /user/{id}:
+	//     get:
+	//       tags:
+	//         - user
+	//       summary: get a user
+	//       operationId: getUser
+	//       parameters:
+	//         - name: id
+	//           in: path
+	//           required: true
+	//           schema:
+	//             type: integer
+	//             format: int64
+	//       responses:
+	//         "200":
+	//           description: OK
+	//           content:
+	//             application/json:
+	//               schema:
+	//                 $ref: "#/components/schemas/User"
+	// components:
+	//   schemas:
+	//     Req:
+	//       type: object
+	//     User:
+	//       type: object
+	//       properties:
+	//         name:
+	//           type: string
+	//       required:
+	//         - name
+}
+
+func ExampleImport() {
+	spec := `{
+  "swagger": "2.0",
+  "info": {"title": "Pet Store", "version": "1.0"},
+  "paths": {
+    "/pets/{id}": {
+      "get": {
+        "tags": ["pets"],
+        "summary": "get a pet",
+        "operationId": "getPet",
+        "parameters": [
+          {"name": "id", "in": "path", "required": true, "type": "integer", "format": "int64"},
+          {"name": "fields", "in": "query", "type": "string", "enum": ["all", "short"]}
+        ],
+        "responses": {"200": {"description": "OK", "schema": {"$ref": "#/definitions/Pet"}}}
+      }
+    }
+  },
+  "definitions": {
+    "Pet": {
+      "description": "Pet is a pet.",
+      "type": "object",
+      "required": ["name", "owner"],
+      "properties": {
+        "name": {"type": "string", "description": "the name"},
+        "tags": {"type": "array", "items": {"type": "string"}},
+        "owner": {"$ref": "#/definitions/Owner"},
+        "mother": {"$ref": "#/definitions/Pet"}
+      }
+    },
+    "Owner": {
+      "type": "object",
+      "required": ["name", "boss"],
+      "properties": {
+        "name": {"type": "string"},
+        "boss": {"$ref": "#/definitions/Owner"}
+      }
+    }
+  }
+}`
+
+	f, err := openapi.Import([]byte(spec))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	cfg := printer.Config{Mode: printer.UseSpaces | printer.TabIndent, TabWidth: 8}
+	if err := cfg.Fprint(os.Stdout, token.NewFileSet(), f); err != nil {
+		fmt.Println(err)
+	}
+
+	// output:
+	// syntax = "v1"
+	//
+	// info(
+	// 	title:   "Pet Store"
+	// 	version: "1.0"
+	// )
+	//
+	// type Owner {
+	// 	Boss *Owner `json:"boss"`
+	// 	Name string `json:"name"`
+	// }
+	//
+	// // Pet is a pet.
+	// type Pet {
+	// 	Mother *Pet `json:"mother,optional"`
+	// 	// the name
+	// 	Name  string   `json:"name"`
+	// 	Owner Owner    `json:"owner"`
+	// 	Tags  []string `json:"tags,optional"`
+	// }
+	//
+	// type GetPetReq {
+	// 	Id     int64  `path:"id"`
+	// 	Fields string `form:"fields,optional,options=all|short"`
+	// }
+	//
+	// @server(
+	// 	group: pets
+	// )
+	// service pet-store-api {
+	// 	@doc "get a pet"
+	// 	@handler getPet
+	// 	get /pets/:id (GetPetReq) returns (Pet)
+	// }
+}
@@ -0,0 +1,443 @@
+package openapi
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/zeromicro/api-ast/ast"
+	"github.com/zeromicro/api-ast/tag"
+	"github.com/zeromicro/api-ast/types"
+)
+
+// Export converts files, such as the files of a loader.API, into an
+// OpenAPI document:
+//
+//   - the info declaration becomes the document info;
+//   - every declared type becomes a component schema describing its
+//     JSON encoding;
+//   - every route becomes an operation, with the @doc value as summary,
+//     the @handler name as operationId and the @server group as tag;
+//   - fields of request structs with path, form and header tags become
+//     path, query and header parameters, the remaining fields make up
+//     the request body;
+//   - a @server jwt setting becomes a bearer security scheme required
+//     by the operations of that service block.
+//
+// Fields are required unless they are optional (see tag.Tags.Optional).
+func Export(files ...*ast.File) (*Document, error) {
+	info, err := types.NewInfo(files...)
+	if err != nil {
+		return nil, fmt.Errorf("openapi: %v", err)
+	}
+	e := &exporter{
+		info: info,
+		doc: &Document{
+			OpenAPI: Version,
+			Info:    &Info{},
+			Paths:   make(map[string]*PathItem),
+		},
+		tags: make(map[string]bool),
+	}
+
+	for _, f := range files {
+		for _, d := range f.Decls {
+			switch d := d.(type) {
+			case *ast.InfoType:
+				e.infoDecl(d)
+			case *ast.Service:
+				e.service(d)
+			}
+		}
+	}
+	for _, s := range info.Specs {
+		e.component(s)
+	}
+	if e.err != nil {
+		return nil, e.err
+	}
+
+	if e.doc.Info.Title == "" {
+		e.doc.Info.Title = e.name
+	}
+	if e.doc.Info.Title == "" {
+		e.doc.Info.Title = "API"
+	}
+	if e.doc.Info.Version == "" {
+		e.doc.Info.Version = "1.0"
+	}
+	for name := range e.tags {
+		e.doc.Tags = append(e.doc.Tags, &Tag{Name: name})
+	}
+	sort.Slice(e.doc.Tags, func(i, j int) bool { return e.doc.Tags[i].Name < e.doc.Tags[j].Name })
+	return e.doc, nil
+}
+
+type exporter struct {
+	info *types.Info
+	doc  *Document
+	tags map[string]bool
+	name string // name of the first service
+	err  error  // first error
+}
+
+func (e *exporter) errorf(format string, args ...interface{}) {
+	if e.err == nil {
+		e.err = fmt.Errorf("openapi: "+format, args...)
+	}
+}
+
+func (e *exporter) components() *Components {
+	if e.doc.Components == nil {
+		e.doc.Components = &Components{}
+	}
+	return e.doc.Components
+}
+
+func (e *exporter) infoDecl(d *ast.InfoType) {
+	info := e.doc.Info
+	if v := d.Value("title"); v != "" {
+		info.Title = v
+	}
+	if v := d.Value("desc"); v != "" {
+		info.Description = v
+	}
+	if v := d.Value("version"); v != "" {
+		info.Version = v
+	}
+	author, email := d.Value("author"), d.Value("email")
+	if author != "" || email != "" {
+		info.Contact = &Contact{Name: author, Email: email}
+	}
+}
+
+func (e *exporter) component(s *ast.TypeSpec) {
+	c := e.components()
+	if c.Schemas == nil {
+		c.Schemas = make(map[string]*Schema)
+	}
+	schema := e.schema(s.Type)
+	if schema.Ref != "" {
+		// a description must not be a sibling of $ref
+		schema = &Schema{AllOf: []*Schema{schema}}
+	}
+	schema.Description = doc(s.Doc, s.Comment)
+	c.Schemas[s.Name.Name] = schema
+}
+
+// ----------------------------------------------------------------------------
+// Operations
+
+func (e *exporter) service(svc *ast.Service) {
+	if e.name == "" {
+		e.name = svc.ServiceApi.Name.Name
+	}
+	group := svc.AtServer.Value("group")
+	prefix := svc.AtServer.Value("prefix")
+	jwt := svc.AtServer.Value("jwt")
+	if jwt != "" {
+		c := e.components()
+		if c.SecuritySchemes == nil {
+			c.SecuritySchemes = make(map[string]*SecurityScheme)
+		}
+		c.SecuritySchemes[jwt] = &SecurityScheme{Type: "http", Scheme: "bearer", BearerFormat: "JWT"}
+	}
+
+	for _, sr := range svc.ServiceApi.ServiceRoute {
+		rt := sr.Route
+		path, params := pathTemplate(prefix + rt.Path.Name)
+		item := e.doc.Paths[path]
+		if item == nil {
+			item = &PathItem{}
+			e.doc.Paths[path] = item
+		}
+		method := strings.ToLower(rt.Method.Name)
+		slot := item.Operation(method)
+		if slot == nil {
+			e.errorf("unknown method %s for %s", rt.Method.Name, path)
+			continue
+		}
+		if *slot != nil {
+			e.errorf("duplicate route %s %s", rt.Method.Name, path)
+			continue
+		}
+
+		op := &Operation{
+			Summary:     sr.AtDoc.Text(),
+			OperationID: sr.AtHandler.Text(),
+			Responses:   make(map[string]*Response),
+		}
+		if group != "" {
+			op.Tags = []string{group}
+			e.tags[group] = true
+		}
+		if jwt != "" {
+			op.Security = []map[string][]string{{jwt: {}}}
+		}
+		if rt.Req != nil {
+			e.request(op, rt.Req.X)
+		}
+		for _, p := range params {
+			if !hasParam(op, p, "path") {
+				e.errorf("%s %s: no request field with tag path:%q", rt.Method.Name, path, p)
+			}
+		}
+		resp := &Response{Description: "OK"}
+		if rt.Resp != nil {
+			resp.Content = jsonContent(e.schema(rt.Resp.X))
+		}
+		op.Responses["200"] = resp
+		*slot = op
+	}
+}
+
+// pathTemplate converts the api path, such as "/user/:id", into an
+// OpenAPI path template, such as "/user/{id}", and returns the names of
+// the path parameters.
+func pathTemplate(path string) (string, []string) {
+	var params []string
+	segs := strings.Split(path, "/")
+	for i, seg := range segs {
+		if strings.HasPrefix(seg, ":") {
+			params = append(params, seg[1:])
+			segs[i] = "{" + seg[1:] + "}"
+		}
+	}
+	return strings.Join(segs, "/"), params
+}
+
+func hasParam(op *Operation, name, in string) bool {
+	for _, p := range op.Parameters {
+		if p.Name == name && p.In == in {
+			return true
+		}
+	}
+	return false
+}
+
+func jsonContent(s *Schema) map[string]*MediaType {
+	return map[string]*MediaType{"application/json": {Schema: s}}
+}
+
+// request adds the parameters and body for the request type x to op.
+func (e *exporter) request(op *Operation, x ast.Expr) {
+	if e.info.Struct(x) == nil {
+		op.RequestBody = &RequestBody{Required: true, Content: jsonContent(e.schema(x))}
+		return
+	}
+	fields, err := e.info.Fields(x)
+	if err != nil {
+		e.errorf("%v", err)
+		return
+	}
+
+	body := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	onlyBody := true // no parameters
+	for _, f := range fields {
+		for _, loc := range []struct{ key, in string }{
+			{tag.Path, "path"},
+			{tag.Form, "query"},
+			{tag.Header, "header"},
+		} {
+			t := f.Tags.Get(loc.key)
+			if t == nil || t.Name == "" || t.Name == "-" {
+				continue
+			}
+			onlyBody = false
+			op.Parameters = append(op.Parameters, &Parameter{
+				Name:        t.Name,
+				In:          loc.in,
+				Description: doc(f.Field.Doc, f.Field.Comment),
+				Required:    loc.in == "path" || !f.Tags.Optional(),
+				Schema:      e.fieldSchema(f),
+			})
+		}
+		if name := jsonName(f); name != "" {
+			body.Properties[name] = e.fieldSchema(f)
+			if !f.Tags.Optional() {
+				body.Required = append(body.Required, name)
+			}
+		}
+	}
+
+	switch {
+	case len(body.Properties) == 0:
+		// no body
+	case onlyBody:
+		op.RequestBody = &RequestBody{Required: true, Content: jsonContent(e.schema(x))}
+	default:
+		op.RequestBody = &RequestBody{Required: len(body.Required) > 0, Content: jsonContent(body)}
+	}
+}
+
+// ----------------------------------------------------------------------------
+// Schemas
+
+// jsonName returns the name of f in the JSON encoding of its struct, or
+// "" if it is not encoded. Fields without json tag that are request
+// parameters are not part of the JSON encoding either.
+func jsonName(f *types.Field) string {
+	if t := f.Tags.Get(tag.JSON); t != nil {
+		switch t.Name {
+		case "-":
+			return ""
+		case "":
+			return f.Name
+		}
+		return t.Name
+	}
+	for _, key := range []string{tag.Path, tag.Form, tag.Header} {
+		if f.Tags.Get(key) != nil {
+			return ""
+		}
+	}
+	return f.Name
+}
+
+// schema returns the schema of the JSON encoding of values of type x.
+func (e *exporter) schema(x ast.Expr) *Schema {
+	switch x := x.(type) {
+	case *ast.Ident:
+		if s := basicSchema(x.Name); s != nil {
+			return s
+		}
+		if e.info.Lookup(x.Name) != nil {
+			return &Schema{Ref: "#/components/schemas/" + x.Name}
+		}
+		e.errorf("undeclared type %s", x.Name)
+
+	case *ast.SelectorExpr:
+		if pkg, ok := x.X.(*ast.Ident); ok && pkg.Name == "time" && x.Sel.Name == "Time" {
+			return &Schema{Type: "string", Format: "date-time"}
+		}
+		return &Schema{} // any value
+
+	case *ast.StarExpr:
+		return e.schema(x.X)
+
+	case *ast.ParenExpr:
+		return e.schema(x.X)
+
+	case *ast.ArrayType:
+		if elt, ok := x.Elt.(*ast.Ident); ok && (elt.Name == "byte" || elt.Name == "uint8") {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: e.schema(x.Elt)}
+
+	case *ast.MapType:
+		return &Schema{Type: "object", AdditionalProperties: e.schema(x.Value)}
+
+	case *ast.StructType:
+		fields, err := e.info.Fields(x)
+		if err != nil {
+			e.errorf("%v", err)
+			break
+		}
+		s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+		for _, f := range fields {
+			name := jsonName(f)
+			if name == "" {
+				continue
+			}
+			s.Properties[name] = e.fieldSchema(f)
+			if !f.Tags.Optional() {
+				s.Required = append(s.Required, name)
+			}
+		}
+		return s
+
+	case nil:
+		e.errorf("missing type")
+
+	default:
+		e.errorf("unsupported type %T", x)
+	}
+	return &Schema{}
+}
+
+func basicSchema(name string) *Schema {
+	switch name {
+	case "bool":
+		return &Schema{Type: "boolean"}
+	case "string":
+		return &Schema{Type: "string"}
+	case "int", "int64", "uint", "uint64", "uintptr":
+		return &Schema{Type: "integer", Format: "int64"}
+	case "int8", "int16", "int32", "uint8", "uint16", "uint32", "byte", "rune":
+		return &Schema{Type: "integer", Format: "int32"}
+	case "float32":
+		return &Schema{Type: "number", Format: "float"}
+	case "float64":
+		return &Schema{Type: "number", Format: "double"}
+	case "any", "interface{}":
+		return &Schema{}
+	}
+	return nil
+}
+
+// fieldSchema returns the schema of field f, including its description
+// and the constraints of its go-zero tag options:
+//
+//	default=value        default value
+//	options=a|b|c        enumeration
+//	range=[min:max]      inclusive or exclusive, "(" and ")", bounds
+func (e *exporter) fieldSchema(f *types.Field) *Schema {
+	s := e.schema(f.Type)
+	desc := doc(f.Field.Doc, f.Field.Comment)
+	def, hasDefault := f.Tags.Default()
+	enum, hasEnum := f.Tags.Enum()
+	r, hasRange := f.Tags.Range()
+	if s.Ref != "" {
+		if desc == "" && !hasDefault && !hasEnum && !hasRange {
+			return s
+		}
+		s = &Schema{AllOf: []*Schema{s}}
+	}
+	s.Description = desc
+
+	if hasDefault {
+		s.Default = s.value(def)
+	}
+	for _, v := range enum {
+		s.Enum = append(s.Enum, s.value(v))
+	}
+	if hasRange {
+		if r.HasMin {
+			s.Minimum, s.ExclusiveMinimum = &r.Min, r.MinExcl
+		}
+		if r.HasMax {
+			s.Maximum, s.ExclusiveMaximum = &r.Max, r.MaxExcl
+		}
+	}
+	return s
+}
+
+// value converts the tag option value v to a value of type s.
+func (s *Schema) value(v string) interface{} {
+	switch s.Type {
+	case "integer":
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return n
+		}
+	case "number":
+		if n, err := strconv.ParseFloat(v, 64); err == nil {
+			return n
+		}
+	case "boolean":
+		if b, err := strconv.ParseBool(v); err == nil {
+			return b
+		}
+	}
+	return v
+}
+
+// doc returns the text of the first non-empty comment group.
+func doc(list ...*ast.CommentGroup) string {
+	for _, cg := range list {
+		if text := strings.TrimSpace(cg.Text()); text != "" {
+			return text
+		}
+	}
+	return ""
+}
@@ -0,0 +1,804 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/zeromicro/api-ast/ast"
+	"github.com/zeromicro/api-ast/tag"
+	"github.com/zeromicro/api-ast/token"
+	"github.com/zeromicro/api-ast/types"
+)
+
+// Import converts the OpenAPI 3 or Swagger 2 JSON document data into an
+// api file, roughly the reverse of Export:
+//
+//   - the document info becomes the info declaration;
+//   - every component schema (definition in Swagger 2) becomes a type;
+//     object schemas become structs with json tags, and nested object
+//     schemas are declared as types of their own;
+//   - every operation becomes a route, with the summary as @doc and the
+//     operationId as @handler name;
+//   - the parameters and the body of an operation make up its request
+//     struct, with path, form and header tags for path, query and header
+//     parameters and json (or form, for form bodies) tags for the body;
+//   - the JSON body of the first successful response is the response type;
+//   - routes are grouped into service blocks by their first tag, which
+//     becomes the @server group, and by whether the operation requires
+//     authentication, which becomes "jwt: Auth";
+//   - descriptions become doc comments.
+//
+// Fields not listed as required are optional, and defaults, enumerations
+// and bounds become the corresponding tag options. A field referring to
+// a struct is a pointer if it is optional or if a value would make the
+// struct type recursive. The file has no
+// position information; print it with the printer package.
+func Import(data []byte) (*ast.File, error) {
+	var version struct {
+		Swagger string `json:"swagger"`
+		OpenAPI string `json:"openapi"`
+	}
+	if err := json.Unmarshal(data, &version); err != nil {
+		return nil, fmt.Errorf("openapi: %v", err)
+	}
+
+	var doc *Document
+	switch {
+	case strings.HasPrefix(version.Swagger, "2."):
+		var s swagger
+		if err := json.Unmarshal(data, &s); err != nil {
+			return nil, fmt.Errorf("openapi: %v", err)
+		}
+		doc = s.upgrade()
+	case strings.HasPrefix(version.OpenAPI, "3."):
+		doc = new(Document)
+		if err := json.Unmarshal(data, doc); err != nil {
+			return nil, fmt.Errorf("openapi: %v", err)
+		}
+	default:
+		return nil, fmt.Errorf("openapi: not an OpenAPI 3 or Swagger 2 document")
+	}
+	if doc.Components == nil {
+		doc.Components = &Components{}
+	}
+
+	imp := &importer{
+		doc:      doc,
+		names:    make(map[string]string),
+		declared: make(map[string]bool),
+		handlers: make(map[string]bool),
+		services: make(map[serviceKey]*ast.Service),
+	}
+	return imp.file()
+}
+
+type importer struct {
+	doc      *Document
+	names    map[string]string // type names of component schemas
+	declared map[string]bool   // declared type names
+	handlers map[string]bool   // handler names in use
+	types    []ast.Decl        // type declarations, in declaration order
+	services map[serviceKey]*ast.Service
+	schema   string // key of the component schema being declared, if any
+	err      error  // first error
+}
+
+// A serviceKey identifies the service block of a route.
+type serviceKey struct {
+	group string
+	jwt   bool
+}
+
+func (imp *importer) errorf(format string, args ...interface{}) {
+	if imp.err == nil {
+		imp.err = fmt.Errorf("openapi: "+format, args...)
+	}
+}
+
+func (imp *importer) file() (*ast.File, error) {
+	f := &ast.File{
+		Syntax: &ast.SyntaxSpec{Name: &ast.BasicLit{Kind: token.STRING, Value: strconv.Quote("v1")}},
+	}
+	if d := imp.infoDecl(); d != nil {
+		f.Decls = append(f.Decls, d)
+	}
+
+	// reserve the names of all component schemas before declaring any
+	// type, so that names derived from them cannot take them
+	schemas := sortedKeys(imp.doc.Components.Schemas)
+	for _, name := range schemas {
+		imp.names[name] = imp.newName(types.ExportedName(name))
+	}
+	for _, name := range schemas {
+		imp.schema = name
+		imp.declare(imp.names[name], imp.doc.Components.Schemas[name])
+	}
+	imp.schema = ""
+
+	for _, path := range sortedKeys(imp.doc.Paths) {
+		item := imp.doc.Paths[path]
+		for _, method := range []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"} {
+			if op := *item.Operation(method); op != nil {
+				imp.route(path, method, item, op)
+			}
+		}
+	}
+	if imp.err != nil {
+		return nil, imp.err
+	}
+
+	f.Decls = append(f.Decls, imp.types...)
+	keys := make([]serviceKey, 0, len(imp.services))
+	for key := range imp.services {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].group != keys[j].group {
+			return keys[i].group < keys[j].group
+		}
+		return !keys[i].jwt && keys[j].jwt
+	})
+	for _, key := range keys {
+		f.Decls = append(f.Decls, imp.services[key])
+	}
+	return f, nil
+}
+
+func (imp *importer) infoDecl() *ast.InfoType {
+	info := imp.doc.Info
+	if info == nil {
+		return nil
+	}
+	d := &ast.InfoType{}
+	add := func(key, value string) {
+		if value != "" {
+			d.Kvs = append(d.Kvs, &ast.KeyValueExpr{
+				Key:   ast.NewIdent(key),
+				Value: &ast.BasicLit{Kind: token.STRING, Value: strconv.Quote(value)},
+			})
+		}
+	}
+	add("title", info.Title)
+	add("desc", firstLine(info.Description))
+	if c := info.Contact; c != nil {
+		add("author", c.Name)
+		add("email", c.Email)
+	}
+	add("version", info.Version)
+	if len(d.Kvs) == 0 {
+		return nil
+	}
+	return d
+}
+
+// ----------------------------------------------------------------------------
+// Routes
+
+func (imp *importer) route(path, method string, item *PathItem, op *Operation) {
+	handler := op.OperationID
+	if handler == "" {
+		handler = method + " " + path
+	}
+	handler = imp.newHandler(lowerName(handler))
+	typeName := types.ExportedName(handler)
+
+	rt := &ast.Route{
+		Method: ast.NewIdent(method),
+		Path:   ast.NewIdent(routePath(path)),
+	}
+	if req := imp.request(typeName+"Req", item, op); req != nil {
+		rt.Req = &ast.ParenExpr{X: req}
+	}
+	if resp := imp.response(typeName+"Resp", op); resp != nil {
+		rt.Resp = &ast.ParenExpr{X: resp}
+	}
+
+	summary := op.Summary
+	if summary == "" {
+		summary = firstLine(op.Description)
+	}
+	sr := &ast.ServiceRoute{
+		AtHandler: &ast.KeyValueExpr{Key: ast.NewIdent("@handler"), Value: ast.NewIdent(handler)},
+		Route:     rt,
+	}
+	if summary != "" {
+		sr.AtDoc = &ast.KeyValueExpr{
+			Key:   ast.NewIdent("@doc"),
+			Value: &ast.BasicLit{Kind: token.STRING, Value: strconv.Quote(summary)},
+		}
+	}
+
+	key := serviceKey{jwt: imp.secured(op)}
+	if len(op.Tags) > 0 {
+		key.group = lowerName(op.Tags[0])
+	}
+	svc := imp.service(key)
+	svc.ServiceApi.ServiceRoute = append(svc.ServiceApi.ServiceRoute, sr)
+}
+
+// secured reports whether op requires authentication.
+func (imp *importer) secured(op *Operation) bool {
+	security := op.Security
+	if security == nil {
+		security = imp.doc.Security
+	}
+	for _, req := range security {
+		if len(req) == 0 {
+			return false // authentication is optional
+		}
+	}
+	return len(security) > 0
+}
+
+func (imp *importer) service(key serviceKey) *ast.Service {
+	if svc := imp.services[key]; svc != nil {
+		return svc
+	}
+	name := "api"
+	if info := imp.doc.Info; info != nil && info.Title != "" {
+		name = kebabName(info.Title)
+		if !strings.HasSuffix(name, "-api") && name != "api" {
+			name += "-api"
+		}
+	}
+	svc := &ast.Service{ServiceApi: &ast.ServiceApi{Name: ast.NewIdent(name)}}
+
+	var kvs []*ast.KeyValueExpr
+	add := func(key, value string) {
+		kvs = append(kvs, &ast.KeyValueExpr{Key: ast.NewIdent(key), Value: ast.NewIdent(value)})
+	}
+	if key.jwt {
+		add("jwt", "Auth")
+	}
+	if key.group != "" {
+		add("group", key.group)
+	}
+	if prefix := imp.prefix(); prefix != "" {
+		add("prefix", prefix)
+	}
+	if len(kvs) > 0 {
+		svc.AtServer = &ast.AtServer{Kvs: kvs}
+	}
+	imp.services[key] = svc
+	return svc
+}
+
+// prefix returns the path of the first server URL, or "".
+func (imp *importer) prefix() string {
+	if len(imp.doc.Servers) == 0 {
+		return ""
+	}
+	u, err := url.Parse(imp.doc.Servers[0].URL)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSuffix(u.Path, "/")
+}
+
+// routePath converts the OpenAPI path template, such as "/user/{id}",
+// into an api path, such as "/user/:id".
+func routePath(path string) string {
+	segs := strings.Split(path, "/")
+	for i, seg := range segs {
+		if strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "}") {
+			segs[i] = ":" + seg[1:len(seg)-1]
+		}
+	}
+	return strings.Join(segs, "/")
+}
+
+// request returns the request type of op, or nil if it has neither
+// parameters nor body. If necessary, it declares a struct named name.
+func (imp *importer) request(name string, item *PathItem, op *Operation) ast.Expr {
+	var params []*Parameter
+	index := make(map[string]int) // position of in+name in params
+	for _, list := range [][]*Parameter{item.Parameters, op.Parameters} {
+		for _, p := range list {
+			p, body := imp.param(p)
+			if body != nil && op.RequestBody == nil {
+				op.RequestBody = body
+			}
+			if p == nil || p.In == "cookie" {
+				continue
+			}
+			// operation parameters override path parameters
+			if i, ok := index[p.In+" "+p.Name]; ok {
+				params[i] = p
+				continue
+			}
+			index[p.In+" "+p.Name] = len(params)
+			params = append(params, p)
+		}
+	}
+	body, bodyKey := imp.requestBody(op.RequestBody)
+
+	// a body of a named type without parameters is the request
+	if len(params) == 0 && body != nil && (body.Ref != "" || !isObject(body)) {
+		return imp.typeExpr(body, name)
+	}
+	if len(params) == 0 && body == nil {
+		return nil
+	}
+
+	name = imp.newName(name)
+	imp.declareType(name, "", func() ast.Expr {
+		return imp.requestStruct(name, params, body, bodyKey, op.RequestBody)
+	})
+	return ast.NewIdent(name)
+}
+
+// requestStruct returns the struct type name for the parameters params
+// and the body schema body, whose properties are tagged with bodyKey.
+func (imp *importer) requestStruct(name string, params []*Parameter, body *Schema, bodyKey string, rb *RequestBody) *ast.StructType {
+	s := &structBuilder{imp: imp, name: name}
+	for _, p := range params {
+		key := tag.Form
+		switch p.In {
+		case "path":
+			key = tag.Path
+		case "header":
+			key = tag.Header
+		}
+		s.field(p.Name, key, p.Schema, p.Required || p.In == "path", p.Description)
+	}
+	switch {
+	case body == nil:
+	case body.Ref != "" && isObject(imp.resolve(body)):
+		s.embed(imp.typeExpr(body, ""))
+	case isObject(body):
+		s.properties(body, bodyKey)
+	default:
+		desc := body.Description
+		if desc == "" && rb != nil {
+			desc = rb.Description
+		}
+		s.field("body", bodyKey, body, true, desc)
+	}
+	return s.st()
+}
+
+// param resolves references of p. A reference to a Swagger 2 body
+// parameter yields a request body instead.
+func (imp *importer) param(p *Parameter) (*Parameter, *RequestBody) {
+	if p.Ref == "" {
+		return p, nil
+	}
+	name := refName(p.Ref)
+	if q := imp.doc.Components.Parameters[name]; q != nil {
+		return q, nil
+	}
+	if b := imp.doc.Components.RequestBodies[name]; b != nil {
+		return nil, b
+	}
+	imp.errorf("unresolved reference %s", p.Ref)
+	return nil, nil
+}
+
+// requestBody returns the schema of the body b and the tag key of its
+// properties: json for JSON bodies and form for form bodies.
+func (imp *importer) requestBody(b *RequestBody) (*Schema, string) {
+	if b != nil && b.Ref != "" {
+		ref := b.Ref
+		if b = imp.doc.Components.RequestBodies[refName(ref)]; b == nil {
+			imp.errorf("unresolved reference %s", ref)
+			return nil, ""
+		}
+	}
+	if b == nil {
+		return nil, ""
+	}
+	for _, mediaType := range sortedKeys(b.Content) {
+		if strings.Contains(mediaType, "json") || mediaType == "*/*" {
+			return b.Content[mediaType].Schema, tag.JSON
+		}
+	}
+	for _, mediaType := range []string{"application/x-www-form-urlencoded", "multipart/form-data"} {
+		if mt := b.Content[mediaType]; mt != nil {
+			return mt.Schema, tag.Form
+		}
+	}
+	return nil, ""
+}
+
+// response returns the type of the JSON body of the first successful
+// response of op, or nil.
+func (imp *importer) response(name string, op *Operation) ast.Expr {
+	codes := sortedKeys(op.Responses)
+	sort.SliceStable(codes, func(i, j int) bool {
+		// successful responses, then the default response
+		return codes[i] != "default" && codes[i][0] == '2' && (codes[j] == "default" || codes[j][0] != '2')
+	})
+	for _, code := range codes {
+		if code != "default" && code[0] != '2' {
+			continue
+		}
+		r := op.Responses[code]
+		if r.Ref != "" {
+			if r = imp.doc.Components.Responses[refName(r.Ref)]; r == nil {
+				imp.errorf("unresolved reference %s", op.Responses[code].Ref)
+				return nil
+			}
+		}
+		for _, mediaType := range sortedKeys(r.Content) {
+			if strings.Contains(mediaType, "json") || mediaType == "*/*" {
+				if s := r.Content[mediaType].Schema; s != nil {
+					return imp.typeExpr(s, name)
+				}
+			}
+		}
+		return nil
+	}
+	return nil
+}
+
+// ----------------------------------------------------------------------------
+// Types
+
+// resolve returns the schema that s refers to, or s.
+func (imp *importer) resolve(s *Schema) *Schema {
+	for i := 0; s != nil && s.Ref != "" && i < 10; i++ {
+		s = imp.doc.Components.Schemas[refName(s.Ref)]
+	}
+	return s
+}
+
+// isObject reports whether s describes an object with a fixed set of
+// properties, which becomes a struct.
+func isObject(s *Schema) bool {
+	return s != nil && s.Ref == "" && (len(s.Properties) > 0 || len(s.AllOf) > 1 ||
+		s.Type == "object" && s.AdditionalProperties == nil)
+}
+
+// holds reports whether the struct for s holds a value of the struct for
+// the component schema key, directly or through the required fields and
+// embedded structs of other structs, so that a field of that type in the
+// struct for key would make it a recursive type. Fields of optional
+// objects, slices and maps are not values of the struct.
+func (imp *importer) holds(s *Schema, key string, seen map[string]bool) bool {
+	if s == nil {
+		return false
+	}
+	if len(s.AllOf) == 1 && len(s.Properties) == 0 {
+		return imp.holds(s.AllOf[0], key, seen)
+	}
+	if s.Ref != "" {
+		name := refName(s.Ref)
+		if name == key {
+			return true
+		}
+		if seen[name] {
+			return false
+		}
+		seen[name] = true
+		return imp.holds(imp.doc.Components.Schemas[name], key, seen)
+	}
+	if !isObject(s) {
+		return false
+	}
+	for _, sub := range s.AllOf {
+		if imp.holds(sub, key, seen) {
+			return true
+		}
+	}
+	for prop, ps := range s.Properties {
+		if contains(s.Required, prop) && isObject(imp.resolve(ps)) && imp.holds(ps, key, seen) {
+			return true
+		}
+	}
+	return false
+}
+
+// declare declares the type name for the schema s.
+func (imp *importer) declare(name string, s *Schema) {
+	imp.declareType(name, s.Description, func() ast.Expr {
+		if isObject(s) {
+			return imp.structType(name, s, tag.JSON)
+		}
+		return imp.typeExpr(s, name+"Item")
+	})
+}
+
+// declareType declares the type name. Its type is computed by typ after
+// the declaration has been added, so that the types declared for nested
+// objects follow it.
+func (imp *importer) declareType(name, desc string, typ func() ast.Expr) {
+	imp.declared[name] = true
+	spec := &ast.TypeSpec{Name: ast.NewIdent(name)}
+	imp.types = append(imp.types, &ast.GenDecl{
+		Doc:   comment(desc),
+		Tok:   token.TYPE,
+		Specs: []ast.Spec{spec},
+	})
+	spec.Type = typ()
+}
+
+// newName returns name, or name with a number appended if name is
+// already in use.
+func (imp *importer) newName(name string) string {
+	used := func(name string) bool {
+		if imp.declared[name] {
+			return true
+		}
+		for _, n := range imp.names {
+			if n == name {
+				return true
+			}
+		}
+		return false
+	}
+	unique := name
+	for i := 2; used(unique); i++ {
+		unique = name + strconv.Itoa(i)
+	}
+	imp.declared[unique] = true
+	return unique
+}
+
+func (imp *importer) newHandler(name string) string {
+	unique := name
+	for i := 2; imp.handlers[unique]; i++ {
+		unique = name + strconv.Itoa(i)
+	}
+	imp.handlers[unique] = true
+	return unique
+}
+
+// typeExpr returns the type of values described by s. An object schema
+// that is not a reference is declared as a struct type, preferably with
+// the name hint.
+func (imp *importer) typeExpr(s *Schema, hint string) ast.Expr {
+	if s == nil {
+		return ast.NewIdent("any")
+	}
+	if s.Ref != "" {
+		name, ok := imp.names[refName(s.Ref)]
+		if !ok {
+			imp.errorf("unresolved reference %s", s.Ref)
+			return ast.NewIdent("any")
+		}
+		return ast.NewIdent(name)
+	}
+	if len(s.AllOf) == 1 && len(s.Properties) == 0 {
+		return imp.typeExpr(s.AllOf[0], hint)
+	}
+	if isObject(s) {
+		name := imp.newName(hint)
+		imp.declareType(name, "", func() ast.Expr { return imp.structType(name, s, tag.JSON) })
+		return ast.NewIdent(name)
+	}
+
+	switch s.Type {
+	case "string":
+		return ast.NewIdent("string")
+	case "integer":
+		if s.Format == "int32" {
+			return ast.NewIdent("int32")
+		}
+		return ast.NewIdent("int64")
+	case "number":
+		if s.Format == "float" {
+			return ast.NewIdent("float32")
+		}
+		return ast.NewIdent("float64")
+	case "boolean":
+		return ast.NewIdent("bool")
+	case "array":
+		return &ast.ArrayType{Elt: imp.typeExpr(s.Items, hint+"Item")}
+	case "object":
+		return &ast.MapType{Key: ast.NewIdent("string"), Value: imp.typeExpr(s.AdditionalProperties, hint+"Value")}
+	}
+	return ast.NewIdent("any")
+}
+
+// structType returns the struct for the object schema s; the properties
+// are tagged with key.
+func (imp *importer) structType(name string, s *Schema, key string) *ast.StructType {
+	b := &structBuilder{imp: imp, name: name}
+	b.properties(s, key)
+	return b.st()
+}
+
+// A structBuilder collects the fields of a struct type.
+type structBuilder struct {
+	imp    *importer
+	name   string // name of the struct type, for names of nested types
+	fields []*ast.Field
+	names  map[string]bool // field names in use
+}
+
+func (b *structBuilder) st() *ast.StructType {
+	return &ast.StructType{Fields: &ast.FieldList{List: b.fields}}
+}
+
+func (b *structBuilder) embed(typ ast.Expr) {
+	b.fields = append(b.fields, &ast.Field{Type: typ})
+}
+
+// properties adds the properties of the object schema s, including
+// those of the schemas it is composed of.
+func (b *structBuilder) properties(s *Schema, key string) {
+	for _, sub := range s.AllOf {
+		if sub.Ref != "" {
+			b.embed(b.imp.typeExpr(sub, ""))
+		} else {
+			b.properties(sub, key)
+		}
+	}
+	for _, prop := range sortedKeys(s.Properties) {
+		ps := s.Properties[prop]
+		b.field(prop, key, ps, contains(s.Required, prop), ps.Description)
+	}
+}
+
+// field adds a field for the property or parameter name, tagged with
+// key, of type s.
+func (b *structBuilder) field(name, key string, s *Schema, required bool, desc string) {
+	if b.names == nil {
+		b.names = make(map[string]bool)
+	}
+	fieldName := types.ExportedName(name)
+	for i := 2; b.names[fieldName]; i++ {
+		fieldName = types.ExportedName(name) + strconv.Itoa(i)
+	}
+	b.names[fieldName] = true
+
+	value := name
+	if !required {
+		value += ",optional"
+	}
+	value += tagOptions(s)
+	if desc == "" && s != nil && s.Ref == "" {
+		desc = s.Description
+	}
+	typ := b.imp.typeExpr(s, b.name+fieldName)
+	// An optional object is a pointer, as is a required one that would
+	// make the struct recursive.
+	ref := s
+	for ref != nil && len(ref.AllOf) == 1 && len(ref.Properties) == 0 {
+		ref = ref.AllOf[0]
+	}
+	if ref != nil && ref.Ref != "" && isObject(b.imp.resolve(ref)) &&
+		(!required || b.imp.schema != "" && b.imp.holds(ref, b.imp.schema, make(map[string]bool))) {
+		typ = &ast.StarExpr{X: typ}
+	}
+	b.fields = append(b.fields, &ast.Field{
+		Doc:   comment(desc),
+		Names: []*ast.Ident{ast.NewIdent(fieldName)},
+		Type:  typ,
+		Tag:   &ast.BasicLit{Kind: token.STRING, Value: "`" + key + ":" + strconv.Quote(value) + "`"},
+	})
+}
+
+// tagOptions returns the go-zero tag options for the default value,
+// enumeration and bounds of s, each preceded by a comma.
+func tagOptions(s *Schema) string {
+	if s == nil {
+		return ""
+	}
+	var opts []string
+	if s.Default != nil {
+		if v := optionValue(s.Default); v != "" {
+			opts = append(opts, "default="+v)
+		}
+	}
+	if len(s.Enum) > 0 {
+		var values []string
+		for _, e := range s.Enum {
+			v := optionValue(e)
+			if v == "" || strings.Contains(v, "|") {
+				values = nil
+				break
+			}
+			values = append(values, v)
+		}
+		if values != nil {
+			opts = append(opts, "options="+strings.Join(values, "|"))
+		}
+	}
+	if s.Minimum != nil && s.Maximum != nil {
+		lo, hi := "[", "]"
+		if s.ExclusiveMinimum {
+			lo = "("
+		}
+		if s.ExclusiveMaximum {
+			hi = ")"
+		}
+		opts = append(opts, "range="+lo+formatNumber(*s.Minimum)+":"+formatNumber(*s.Maximum)+hi)
+	}
+	if len(opts) == 0 {
+		return ""
+	}
+	return "," + strings.Join(opts, ",")
+}
+
+// optionValue returns the scalar v as tag option value, or "" if it
+// cannot be written in a tag.
+func optionValue(v interface{}) string {
+	var s string
+	switch v := v.(type) {
+	case string:
+		s = v
+	case float64:
+		s = formatNumber(v)
+	case bool:
+		s = strconv.FormatBool(v)
+	default:
+		return ""
+	}
+	if strings.ContainsAny(s, ",\"`\\") || strings.TrimSpace(s) != s {
+		return ""
+	}
+	return s
+}
+
+func formatNumber(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// ----------------------------------------------------------------------------
+// Names and comments
+
+// refName returns the name of the component a reference such as
+// "#/components/schemas/User" or "#/definitions/User" refers to.
+func refName(ref string) string {
+	name := ref[strings.LastIndex(ref, "/")+1:]
+	name = strings.NewReplacer("~1", "/", "~0", "~").Replace(name)
+	return name
+}
+
+// lowerName returns s as identifier starting with a lower case letter,
+// such as "getUser" for "GetUser" or "get-user".
+func lowerName(s string) string {
+	name := types.ExportedName(s)
+	return strings.ToLower(name[:1]) + name[1:]
+}
+
+// kebabName returns s in lower case with words separated by hyphens,
+// such as "user-api" for "User API".
+func kebabName(s string) string {
+	return strings.ToLower(strings.Join(types.Words(s), "-"))
+}
+
+func firstLine(s string) string {
+	s = strings.TrimSpace(s)
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		s = strings.TrimSpace(s[:i])
+	}
+	return s
+}
+
+// comment returns the text as a group of line comments, or nil if the
+// text is empty.
+func comment(text string) *ast.CommentGroup {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil
+	}
+	cg := &ast.CommentGroup{}
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		if line == "" {
+			cg.List = append(cg.List, &ast.Comment{Text: "//"})
+			continue
+		}
+		cg.List = append(cg.List, &ast.Comment{Text: "// " + line})
+	}
+	return cg
+}
+
+// sortedKeys returns the keys of the map m, which has string keys, in
+// increasing order.
+func sortedKeys(m interface{}) []string {
+	v := reflect.ValueOf(m)
+	keys := make([]string, 0, v.Len())
+	for _, k := range v.MapKeys() {
+		keys = append(keys, k.String())
+	}
+	sort.Strings(keys)
+	return keys
+}
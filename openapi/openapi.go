@@ -0,0 +1,212 @@
+// Package openapi converts api files to OpenAPI 3.0 documents, and
+// OpenAPI 3 and Swagger 2 documents to api files.
+//
+// The document types cover the part of the OpenAPI specification that
+// api files can express; see https://spec.openapis.org/oas/v3.0.3.
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/zeromicro/api-ast/internal/yaml"
+)
+
+// Version is the OpenAPI version of exported documents.
+const Version = "3.0.3"
+
+// A Document is an OpenAPI document.
+type Document struct {
+	OpenAPI    string                `json:"openapi"`
+	Info       *Info                 `json:"info"`
+	Servers    []*Server             `json:"servers,omitempty"`
+	Tags       []*Tag                `json:"tags,omitempty"`
+	Paths      map[string]*PathItem  `json:"paths"`
+	Components *Components           `json:"components,omitempty"`
+	Security   []map[string][]string `json:"security,omitempty"` // default for all operations
+}
+
+// Info is the metadata of an API.
+type Info struct {
+	Title       string   `json:"title"`
+	Description string   `json:"description,omitempty"`
+	Contact     *Contact `json:"contact,omitempty"`
+	Version     string   `json:"version"`
+}
+
+// Contact describes the owner of an API.
+type Contact struct {
+	Name  string `json:"name,omitempty"`
+	Email string `json:"email,omitempty"`
+}
+
+// A Server is a server hosting an API.
+type Server struct {
+	URL         string `json:"url"`
+	Description string `json:"description,omitempty"`
+}
+
+// A Tag groups operations.
+type Tag struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// A PathItem holds the operations of a single path.
+type PathItem struct {
+	Get        *Operation   `json:"get,omitempty"`
+	Put        *Operation   `json:"put,omitempty"`
+	Post       *Operation   `json:"post,omitempty"`
+	Delete     *Operation   `json:"delete,omitempty"`
+	Options    *Operation   `json:"options,omitempty"`
+	Head       *Operation   `json:"head,omitempty"`
+	Patch      *Operation   `json:"patch,omitempty"`
+	Trace      *Operation   `json:"trace,omitempty"`
+	Parameters []*Parameter `json:"parameters,omitempty"`
+}
+
+// Operation returns a pointer to the operation for the HTTP method in
+// lower case, or nil if method is unknown.
+func (p *PathItem) Operation(method string) **Operation {
+	switch method {
+	case "get":
+		return &p.Get
+	case "put":
+		return &p.Put
+	case "post":
+		return &p.Post
+	case "delete":
+		return &p.Delete
+	case "options":
+		return &p.Options
+	case "head":
+		return &p.Head
+	case "patch":
+		return &p.Patch
+	case "trace":
+		return &p.Trace
+	}
+	return nil
+}
+
+// An Operation is a single API operation on a path.
+type Operation struct {
+	Tags        []string              `json:"tags,omitempty"`
+	Summary     string                `json:"summary,omitempty"`
+	Description string                `json:"description,omitempty"`
+	OperationID string                `json:"operationId,omitempty"`
+	Parameters  []*Parameter          `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]*Response  `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+}
+
+// A Parameter is a path, query or header parameter of an operation.
+type Parameter struct {
+	Ref         string  `json:"$ref,omitempty"` // reference to a component parameter
+	Name        string  `json:"name"`
+	In          string  `json:"in"` // "path", "query", "header" or "cookie"
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema,omitempty"`
+}
+
+// A RequestBody is the body of an operation's request.
+type RequestBody struct {
+	Ref         string                `json:"$ref,omitempty"` // reference to a component request body
+	Description string                `json:"description,omitempty"`
+	Required    bool                  `json:"required,omitempty"`
+	Content     map[string]*MediaType `json:"content"`
+}
+
+// A Response is a response of an operation.
+type Response struct {
+	Ref         string                `json:"$ref,omitempty"` // reference to a component response
+	Description string                `json:"description,omitempty"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+// A MediaType describes content of a given media type.
+type MediaType struct {
+	Schema *Schema `json:"schema,omitempty"`
+}
+
+// Components holds reusable objects.
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas,omitempty"`
+	Parameters      map[string]*Parameter      `json:"parameters,omitempty"`
+	RequestBodies   map[string]*RequestBody    `json:"requestBodies,omitempty"`
+	Responses       map[string]*Response       `json:"responses,omitempty"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+// A SecurityScheme describes an authentication method.
+type SecurityScheme struct {
+	Type         string `json:"type"` // "apiKey", "http", "oauth2" or "openIdConnect"
+	Description  string `json:"description,omitempty"`
+	Name         string `json:"name,omitempty"` // apiKey
+	In           string `json:"in,omitempty"`   // apiKey
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+}
+
+// A Schema describes a data type.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Default              interface{}        `json:"default,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	ExclusiveMinimum     bool               `json:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum     bool               `json:"exclusiveMaximum,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	AllOf                []*Schema          `json:"allOf,omitempty"`
+}
+
+// UnmarshalJSON decodes s. Unlike the Schema type, it also accepts a
+// boolean additionalProperties, where true stands for the empty schema.
+func (s *Schema) UnmarshalJSON(data []byte) error {
+	type schema Schema // without methods
+	var x struct {
+		schema
+		AdditionalProperties json.RawMessage `json:"additionalProperties"`
+	}
+	if err := json.Unmarshal(data, &x); err != nil {
+		return err
+	}
+	*s = Schema(x.schema)
+	switch v := bytes.TrimSpace(x.AdditionalProperties); string(v) {
+	case "", "null", "false":
+	case "true":
+		s.AdditionalProperties = &Schema{}
+	default:
+		s.AdditionalProperties = new(Schema)
+		return json.Unmarshal(v, s.AdditionalProperties)
+	}
+	return nil
+}
+
+// JSON returns the indented JSON encoding of d.
+func (d *Document) JSON() ([]byte, error) {
+	data, err := json.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// YAML returns the YAML encoding of d.
+func (d *Document) YAML() ([]byte, error) {
+	data, err := json.Marshal(d)
+	if err != nil {
+		return nil, err
+	}
+	return yaml.FromJSON(data)
+}
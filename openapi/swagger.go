@@ -0,0 +1,248 @@
+package openapi
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+)
+
+// A swagger is a Swagger 2.0 document, as far as it is converted into
+// an OpenAPI 3 document by upgrade; see https://swagger.io/specification/v2/.
+type swagger struct {
+	Info                *Info                      `json:"info"`
+	BasePath            string                     `json:"basePath"`
+	Consumes            []string                   `json:"consumes"`
+	Tags                []*Tag                     `json:"tags"`
+	Paths               map[string]*swaggerPath    `json:"paths"`
+	Definitions         map[string]*Schema         `json:"definitions"`
+	Parameters          map[string]*swaggerParam   `json:"parameters"`
+	Responses           map[string]*swaggerResp    `json:"responses"`
+	SecurityDefinitions map[string]*SecurityScheme `json:"securityDefinitions"`
+	Security            []map[string][]string      `json:"security"`
+}
+
+type swaggerPath struct {
+	Get        *swaggerOperation `json:"get"`
+	Put        *swaggerOperation `json:"put"`
+	Post       *swaggerOperation `json:"post"`
+	Delete     *swaggerOperation `json:"delete"`
+	Options    *swaggerOperation `json:"options"`
+	Head       *swaggerOperation `json:"head"`
+	Patch      *swaggerOperation `json:"patch"`
+	Parameters []*swaggerParam   `json:"parameters"`
+}
+
+type swaggerOperation struct {
+	Tags        []string                `json:"tags"`
+	Summary     string                  `json:"summary"`
+	Description string                  `json:"description"`
+	OperationID string                  `json:"operationId"`
+	Consumes    []string                `json:"consumes"`
+	Parameters  []*swaggerParam         `json:"parameters"`
+	Responses   map[string]*swaggerResp `json:"responses"`
+	Security    []map[string][]string   `json:"security"`
+}
+
+// A swaggerParam is a parameter. The type of a body parameter is given
+// by Schema; other parameters describe their type inline, which
+// UnmarshalJSON moves to Schema as well.
+type swaggerParam struct {
+	Ref         string  `json:"$ref"`
+	Name        string  `json:"name"`
+	In          string  `json:"in"` // "path", "query", "header", "body" or "formData"
+	Description string  `json:"description"`
+	Required    bool    `json:"required"`
+	Schema      *Schema `json:"schema"`
+}
+
+func (p *swaggerParam) UnmarshalJSON(data []byte) error {
+	type param swaggerParam // without methods
+	if err := json.Unmarshal(data, (*param)(p)); err != nil {
+		return err
+	}
+	if p.Ref != "" || p.In == "body" {
+		return nil
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	for _, key := range []string{"name", "in", "description", "required"} {
+		delete(fields, key) // not part of the schema
+	}
+	data, _ = json.Marshal(fields)
+	p.Schema = new(Schema)
+	if err := json.Unmarshal(data, p.Schema); err != nil {
+		return err
+	}
+	if p.Schema.Type == "file" {
+		p.Schema.Type, p.Schema.Format = "string", "binary"
+	}
+	return nil
+}
+
+type swaggerResp struct {
+	Ref         string  `json:"$ref"`
+	Description string  `json:"description"`
+	Schema      *Schema `json:"schema"`
+}
+
+// upgrade converts the Swagger 2 document s into an OpenAPI 3 document.
+// References keep their Swagger form, such as "#/definitions/User";
+// only their last element is significant to the importer.
+func (s *swagger) upgrade() *Document {
+	d := &Document{
+		OpenAPI:  Version,
+		Info:     s.Info,
+		Tags:     s.Tags,
+		Paths:    make(map[string]*PathItem),
+		Security: s.Security,
+		Components: &Components{
+			Schemas:         s.Definitions,
+			Parameters:      make(map[string]*Parameter),
+			RequestBodies:   make(map[string]*RequestBody),
+			Responses:       make(map[string]*Response),
+			SecuritySchemes: s.SecurityDefinitions,
+		},
+	}
+	if s.BasePath != "" && s.BasePath != "/" {
+		d.Servers = []*Server{{URL: s.BasePath}}
+	}
+	for name, p := range s.Parameters {
+		if p.In == "body" {
+			d.Components.RequestBodies[name] = s.body(p, s.Consumes)
+		} else {
+			d.Components.Parameters[name] = s.param(p)
+		}
+	}
+	for name, r := range s.Responses {
+		d.Components.Responses[name] = s.response(r)
+	}
+
+	for path, sp := range s.Paths {
+		item := &PathItem{}
+		for _, p := range sp.Parameters {
+			item.Parameters = append(item.Parameters, s.param(p))
+		}
+		for _, m := range []struct {
+			method string
+			op     *swaggerOperation
+		}{
+			{"get", sp.Get},
+			{"put", sp.Put},
+			{"post", sp.Post},
+			{"delete", sp.Delete},
+			{"options", sp.Options},
+			{"head", sp.Head},
+			{"patch", sp.Patch},
+		} {
+			if m.op != nil {
+				*item.Operation(m.method) = s.operation(m.op)
+			}
+		}
+		d.Paths[path] = item
+	}
+	return d
+}
+
+func (s *swagger) operation(so *swaggerOperation) *Operation {
+	op := &Operation{
+		Tags:        so.Tags,
+		Summary:     so.Summary,
+		Description: so.Description,
+		OperationID: so.OperationID,
+		Responses:   make(map[string]*Response),
+		Security:    so.Security,
+	}
+	consumes := so.Consumes
+	if consumes == nil {
+		consumes = s.Consumes
+	}
+
+	var form *Schema // formData parameters
+	for _, p := range so.Parameters {
+		switch {
+		case p.In == "body":
+			op.RequestBody = s.body(p, consumes)
+		case p.In == "formData":
+			if form == nil {
+				form = &Schema{Type: "object", Properties: make(map[string]*Schema)}
+			}
+			schema := p.Schema
+			schema.Description = p.Description
+			form.Properties[p.Name] = schema
+			if p.Required {
+				form.Required = append(form.Required, p.Name)
+			}
+		default:
+			op.Parameters = append(op.Parameters, s.param(p))
+		}
+	}
+	if form != nil && op.RequestBody == nil {
+		sort.Strings(form.Required)
+		mediaType := "application/x-www-form-urlencoded"
+		if contains(consumes, "multipart/form-data") {
+			mediaType = "multipart/form-data"
+		}
+		op.RequestBody = &RequestBody{
+			Required: len(form.Required) > 0,
+			Content:  map[string]*MediaType{mediaType: {Schema: form}},
+		}
+	}
+
+	for code, r := range so.Responses {
+		op.Responses[code] = s.response(r)
+	}
+	return op
+}
+
+func (s *swagger) param(p *swaggerParam) *Parameter {
+	if p.Ref != "" {
+		return &Parameter{Ref: p.Ref}
+	}
+	return &Parameter{
+		Name:        p.Name,
+		In:          p.In,
+		Description: p.Description,
+		Required:    p.Required,
+		Schema:      p.Schema,
+	}
+}
+
+func (s *swagger) body(p *swaggerParam, consumes []string) *RequestBody {
+	if p.Ref != "" {
+		return &RequestBody{Ref: p.Ref}
+	}
+	mediaType := "application/json"
+	for _, c := range consumes {
+		if strings.Contains(c, "json") {
+			mediaType = c
+			break
+		}
+	}
+	return &RequestBody{
+		Description: p.Description,
+		Required:    p.Required,
+		Content:     map[string]*MediaType{mediaType: {Schema: p.Schema}},
+	}
+}
+
+func (s *swagger) response(r *swaggerResp) *Response {
+	if r.Ref != "" {
+		return &Response{Ref: r.Ref}
+	}
+	resp := &Response{Description: r.Description}
+	if r.Schema != nil {
+		resp.Content = jsonContent(r.Schema)
+	}
+	return resp
+}
+
+func contains(list []string, s string) bool {
+	for _, x := range list {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
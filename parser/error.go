@@ -1,13 +1,24 @@
 package parser
 
-import "github.com/zeromicro/api-ast/token"
+import (
+	"strings"
+
+	"github.com/zeromicro/api-ast/diag"
+	"github.com/zeromicro/api-ast/token"
+)
 
 type bailout struct {
 }
 
 func (p *parser) error(pos token.Pos, msg string) {
+	p.errorCode(diag.SyntaxError, pos, token.NoPos, msg, nil)
+}
+
+// errorCode reports the error msg of the code at the source from pos to
+// end, which may be NoPos, with the fixes, if any.
+func (p *parser) errorCode(code diag.Code, pos, end token.Pos, msg string, fixes []diag.Fix) {
 	if p.trace {
-		defer un(trace(p, "error: "+msg))
+		p.traceError(pos, msg)
 	}
 
 	epos := p.file.Position(pos)
@@ -24,10 +35,40 @@ func (p *parser) error(pos token.Pos, msg string) {
 		}
 	}
 	p.errors.Add(epos, msg)
+
+	var eend token.Position
+	if end.IsValid() {
+		eend = p.file.Position(end)
+	}
+	d := diag.New(code, diag.NewSpan(epos, eend), msg)
+	d.Fixes = fixes
+	p.diags.Add(d)
+}
+
+// scanError reports the error msg of the code of the scanner at pos.
+func (p *parser) scanError(pos token.Position, code diag.Code, msg string) {
+	p.errors.Add(pos, msg)
+	p.diags.Add(diag.New(code, diag.NewSpan(pos, token.Position{}), msg))
 }
 
 func (p *parser) errorExpected(pos token.Pos, msg string) {
+	code := diag.ExpectedToken
+	switch msg {
+	case "';'":
+		code = diag.ExpectedSemicolon
+	case "type":
+		code = diag.ExpectedType
+	case "declaration":
+		code = diag.ExpectedDecl
+	case "method":
+		code = diag.ExpectedMethod
+	case "annotation":
+		code = diag.ExpectedAnnotation
+	}
+	want := msg
 	msg = "expected " + msg
+	end := token.NoPos
+	var fixes []diag.Fix
 	if pos == p.pos {
 		// the error happened at the current position;
 		// make the error message more specific
@@ -40,6 +81,88 @@ func (p *parser) errorExpected(pos token.Pos, msg string) {
 		default:
 			msg += ", found '" + p.tok.String() + "'"
 		}
+		end = p.tokenEnd()
+		if p.tok == token.IDENT {
+			span := diag.NewSpan(p.file.Position(pos), p.file.Position(end))
+			hint, suggested := diag.Suggest(span, diag.Closest(p.lit, suggestions(want)))
+			if hint != "" {
+				msg += "; " + hint
+			}
+			fixes = suggested
+		}
+		if fix := p.fixExpected(code, want, end); fix != nil {
+			fixes = append(fixes, *fix)
+		}
+	}
+	p.errorCode(code, pos, end, msg, fixes)
+}
+
+// httpMethods are the methods of routes.
+var httpMethods = []string{"get", "head", "post", "put", "patch", "delete", "connect", "options", "trace"}
+
+// isMethod reports whether name is the method of a route, in any case.
+func isMethod(name string) bool {
+	for _, m := range httpMethods {
+		if strings.EqualFold(name, m) {
+			return true
+		}
+	}
+	return false
+}
+
+// suggestions returns the names that an identifier found in place of the
+// token want may be a misspelling of.
+func suggestions(want string) []string {
+	switch want {
+	case "declaration":
+		return []string{
+			token.TYPE.String(), token.SERVICE.String(), token.ATSERVER.String(),
+			token.IMPORT.String(), token.INFO.String(), token.SYNTAX.String(),
+		}
+	case "method":
+		return httpMethods
+	case "annotation":
+		return []string{token.HANDLER.String(), token.DOC.String()}
+	}
+	if len(want) > 2 && want[0] == '\'' && token.Lookup(want[1:len(want)-1]).IsKeyword() {
+		return []string{want[1 : len(want)-1]} // a keyword token
+	}
+	return nil
+}
+
+// tokenEnd returns the end of the current token.
+func (p *parser) tokenEnd() token.Pos {
+	switch {
+	case p.tok == token.EOF, p.tok == token.SEMICOLON && p.lit == "\n":
+		return p.pos
+	case p.lit != "":
+		return p.pos + token.Pos(len(p.lit))
+	}
+	return p.pos + token.Pos(len(p.tok.String()))
+}
+
+// fixExpected returns the fix of the error that the token want, of the
+// code, was expected at the current token, which ends at end, or nil.
+// A missing semicolon or closing delimiter is inserted, and a comma in
+// place of a semicolon is replaced.
+func (p *parser) fixExpected(code diag.Code, want string, end token.Pos) *diag.Fix {
+	at := diag.NewSpan(p.file.Position(p.pos), token.Position{})
+	switch {
+	case code == diag.ExpectedSemicolon && p.tok == token.COMMA:
+		return &diag.Fix{
+			Message: "replace ',' by ';'",
+			Edits:   []diag.Edit{{Span: diag.NewSpan(p.file.Position(p.pos), p.file.Position(end)), NewText: ";"}},
+		}
+	case code == diag.ExpectedSemicolon && p.tok != token.SEMICOLON:
+		return &diag.Fix{
+			Message: "insert ';'",
+			Edits:   []diag.Edit{{Span: at, NewText: "; "}},
+		}
+	case want == "')'", want == "']'", want == "'}'":
+		return &diag.Fix{
+			Message: "insert " + want,
+			Edits:   []diag.Edit{{Span: at, NewText: want[1:2]}},
+		}
 	}
-	p.error(pos, msg)
+	return nil
 }
@@ -1,7 +1,12 @@
 package parser
 
 import (
+	"bytes"
 	"fmt"
+	"strings"
+
+	"github.com/zeromicro/api-ast/ast"
+	"github.com/zeromicro/api-ast/diag"
 	"github.com/zeromicro/api-ast/token"
 )
 
@@ -66,3 +71,189 @@ service foo-api {
 	// "c.api"
 	// "d/d.api"
 }
+
+func ExampleReparse() {
+	fset := token.NewFileSet()
+
+	src := []byte(`syntax = "v1"
+
+type User {
+	Name string
+}
+
+type Page {
+	Users []User
+}
+`)
+	f, err := ParseFile(fset, "user.api", src, ParseComments)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	user, page := f.Decls[0], f.Decls[1]
+
+	// Add a field to User: only its declaration is parsed again.
+	offset := bytes.Index(src, []byte("\tName"))
+	at := diag.Position{Offset: offset}
+	edit := diag.Edit{Span: diag.Span{Start: at, End: at}, NewText: "\tId int64\n"}
+	f, src, err = Reparse(fset, "user.api", f, src, edit, ParseComments)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(f.Decls[0] == user, f.Decls[1] == page)
+	fmt.Println(fset.Position(f.Decls[1].Pos()))
+
+	// output:
+	//
+	// false true
+	// user.api:8:1
+}
+
+func ExampleParseFile_partial() {
+	src := `syntax = "v1"
+
+info (
+	title: "users"
+)
+
+import "types.api"
+
+type Page {
+	Users []User
+}
+
+service user-api {
+	@handler listUsers
+	get /users returns (Page)
+}
+`
+	for _, mode := range []Mode{ImportsOnly, HeaderOnly, TypesOnly} {
+		fset := token.NewFileSet()
+		f, err := ParseFile(fset, "user.api", src, mode)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		var decls []string
+		for _, d := range f.Decls {
+			switch d := d.(type) {
+			case *ast.GenDecl:
+				decls = append(decls, d.Tok.String())
+			case *ast.InfoType:
+				decls = append(decls, "info")
+			case *ast.Service:
+				decls = append(decls, fmt.Sprintf("service (%d routes)", len(d.ServiceApi.ServiceRoute)))
+			}
+		}
+		fmt.Println(strings.Join(decls, ", "))
+	}
+
+	// output:
+	//
+	// import
+	// info, import
+	// info, import, type, service (0 routes)
+}
+
+func ExampleConfig_ParseFile() {
+	src := `type User {
+	Name string
+}
+
+service user-api {
+	@handler getUser
+	get /users/:id returns (User
+}
+`
+	cfg := &Config{
+		Mode: Trace,
+		TraceFunc: func(e TraceEvent) {
+			// only the top-level productions and the errors
+			if e.Kind == TraceError || e.Kind == TraceEnter && e.Depth <= 1 {
+				fmt.Printf("%d:%d: %*s%s %s\n", e.Pos.Line, e.Pos.Column, 2*e.Depth, "", e.Kind, e.Name)
+			}
+		},
+	}
+	fset := token.NewFileSet()
+	_, err := cfg.ParseFile(fset, "user.api", src)
+	fmt.Println(err)
+
+	// output:
+	// 1:1: enter File
+	// 1:1:   enter Declaration
+	// 5:1:   enter Declaration
+	// 7:30:               error expected ')', found newline
+	// user.api:7:30: expected ')', found newline
+}
+
+func ExampleParseFile_returns() {
+	// The response may follow returns on the same or on the next line,
+	// and a path may end with a keyword.
+	src := `service user-api {
+	@handler getUser
+	get /users/:id returns (User)
+
+	@handler getUserInfo
+	get /users/info returns
+	(UserInfo)
+
+	@handler listUsers
+	get /users/type
+}
+`
+	fset := token.NewFileSet()
+	f, err := ParseFile(fset, "user.api", src, 0)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	for _, d := range f.Decls {
+		s, ok := d.(*ast.Service)
+		if !ok {
+			continue
+		}
+		for _, r := range s.ServiceApi.ServiceRoute {
+			resp := "-"
+			if r.Route.Resp != nil {
+				resp = r.Route.Resp.X.(*ast.Ident).Name
+			}
+			fmt.Println(r.Route.Method.Name, r.Route.Path.Name, resp)
+		}
+	}
+
+	// output:
+	// get /users/:id User
+	// get /users/info UserInfo
+	// get /users/type -
+}
+
+func ExampleParseFile_server() {
+	// Names in @server may contain keywords, dashes and slashes, and
+	// middleware may be a list of names.
+	src := `@server(
+	group: user-service
+	prefix: /api/v1/info
+	middleware: Log, Trace
+)
+service user-api {
+	@handler getUser
+	get /user returns (User)
+}
+`
+	fset := token.NewFileSet()
+	f, err := ParseFile(fset, "user.api", src, 0)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	for _, kv := range f.Decls[0].(*ast.Service).AtServer.Kvs {
+		fmt.Printf("%s %T %q %s-%s\n", kv.Key.Name, kv.Value, kv.Text(),
+			fset.Position(kv.Value.Pos()), fset.Position(kv.Value.End()))
+	}
+
+	// output:
+	// group *ast.Ident "user-service" user.api:2:9-user.api:2:21
+	// prefix *ast.Ident "/api/v1/info" user.api:3:10-user.api:3:22
+	// middleware *ast.IdentList "Log,Trace" user.api:4:14-user.api:4:24
+}
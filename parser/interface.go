@@ -4,9 +4,12 @@ import (
 	"bytes"
 	"errors"
 	"io"
+	"io/fs"
 	"os"
 
 	"github.com/zeromicro/api-ast/ast"
+	"github.com/zeromicro/api-ast/cst"
+	"github.com/zeromicro/api-ast/diag"
 	"github.com/zeromicro/api-ast/token"
 )
 
@@ -19,15 +22,65 @@ type Mode uint
 const (
 	iotaMode Mode = 1 << iota
 	// PackageClauseOnly    Mode             = 1 << iota // stop parsing after package clause
-	//ImportsOnly                           // stop parsing after import declarations
 	ParseComments                         // parse comments and add them to AST
 	Trace                                 // print a trace of parsed productions
 	DeclarationErrors                     // report declaration errors
 	SpuriousErrors                        // same as AllErrors, for backward-compatibility
 	SkipObjectResolution                  // don't resolve identifiers to objects - see ParseFile
+	Lossless                              // keep every token and all trivia - see ParseConcrete
+	ImportsOnly                           // stop parsing after the syntax and import declarations - see ParseFile
+	HeaderOnly                            // stop parsing after the syntax, info and import declarations
+	TypesOnly                             // skip the routes of services
 	AllErrors            = SpuriousErrors // report all errors (not just the first 10 on different lines)
 )
 
+// A Config controls parsing beyond the mode.
+type Config struct {
+	Mode Mode // parser mode
+
+	// TraceOutput receives the trace as text if Mode has the Trace bit
+	// set; default: standard output.
+	TraceOutput io.Writer
+
+	// TraceFunc, if set, receives the trace as events instead of text if
+	// Mode has the Trace bit set.
+	TraceFunc func(e TraceEvent)
+
+	// Diagnostics, if set, receives the syntax errors as diagnostics with
+	// codes, spans and fixes, in source order, when parsing is done.
+	Diagnostics func(d *diag.Diagnostic)
+}
+
+// ParseFile parses a file like the package function ParseFile.
+func (cfg *Config) ParseFile(fset *token.FileSet, filename string, src interface{}) (*ast.File, error) {
+	if fset == nil {
+		panic("parser.Config.ParseFile: not token.FileSet provided (fset == nil)")
+	}
+	text, err := readSource(filename, src)
+	if err != nil {
+		return nil, err
+	}
+	p := cfg.newParser()
+	return p.parse(fset, filename, text, cfg.Mode)
+}
+
+// ParseFS parses a file like the package function ParseFS.
+func (cfg *Config) ParseFS(fset *token.FileSet, fsys fs.FS, path string) (*ast.File, error) {
+	if fset == nil {
+		panic("parser.Config.ParseFS: not token.FileSet provided (fset == nil)")
+	}
+	text, err := fs.ReadFile(fsys, path)
+	if err != nil {
+		return nil, err
+	}
+	p := cfg.newParser()
+	return p.parse(fset, path, text, cfg.Mode)
+}
+
+func (cfg *Config) newParser() *parser {
+	return &parser{traceOut: cfg.TraceOutput, tracer: cfg.TraceFunc, diagFn: cfg.Diagnostics}
+}
+
 // If src != nil, readSource converts src to a []byte if possible;
 // otherwise it returns an error. If src == nil, readSource returns
 // the result of reading the file specified by filename.
@@ -66,6 +119,13 @@ func readSource(filename string, src interface{}) ([]byte, error) {
 // the object resolution phase of parsing will be skipped, causing File.Scope,
 // File.Unresolved, and all Ident.Obj fields to be nil.
 //
+// The header of a file is made of its syntax, info and import declarations,
+// which usually precede all others. If the HeaderOnly mode bit is set,
+// parsing stops at the first other declaration; if the ImportsOnly mode bit
+// is set, it does too, and the info declarations are left out. If the
+// TypesOnly mode bit is set, the services are parsed without their routes,
+// whose source is skipped up to the closing brace of the service.
+//
 // Position information is recorded in the file set fset, which must not be
 // nil.
 //
@@ -79,6 +139,35 @@ func ParseFile(fset *token.FileSet, filename string, src interface{}, mode Mode)
 	if fset == nil {
 		panic("parser.ParseFile: not token.FileSet provided (fset == nil)")
 	}
+	return (&Config{Mode: mode}).ParseFile(fset, filename, src)
+}
+
+// ParseFS parses the source code of the api file with the slash-separated
+// path in the file system fsys, such as an embed.FS or an fstest.MapFS,
+// like ParseFile. The path is used as the file name of the positions.
+//
+func ParseFS(fset *token.FileSet, fsys fs.FS, path string, mode Mode) (f *ast.File, err error) {
+	if fset == nil {
+		panic("parser.ParseFS: not token.FileSet provided (fset == nil)")
+	}
+	return (&Config{Mode: mode}).ParseFS(fset, fsys, path)
+}
+
+// ParseConcrete parses the source code of a single api source file like
+// ParseFile, with the Lossless mode bit set, and returns its concrete
+// syntax tree. The tree keeps every token, including the semicolons
+// inserted by the scanner, and all white space, newlines and comments,
+// such that the source is reproduced byte for byte by cst.File.WriteTo.
+// The abstract syntax tree is available as the AST field of the result.
+//
+// As with ParseFile, a syntactically incorrect source results in a
+// partial AST and a scanner.ErrorList; the concrete syntax tree always
+// covers the complete source.
+//
+func ParseConcrete(fset *token.FileSet, filename string, src interface{}, mode Mode) (f *cst.File, err error) {
+	if fset == nil {
+		panic("parser.ParseConcrete: not token.FileSet provided (fset == nil)")
+	}
 
 	text, err := readSource(filename, src)
 	if err != nil {
@@ -86,6 +175,16 @@ func ParseFile(fset *token.FileSet, filename string, src interface{}, mode Mode)
 	}
 
 	var p parser
+	file, err := p.parse(fset, filename, text, mode|Lossless)
+
+	// the parser may have stopped early; collect the remaining tokens
+	for p.tok != token.EOF {
+		p.next0()
+	}
+	return cst.New(p.file, text, file, p.tokens), err
+}
+
+func (p *parser) parse(fset *token.FileSet, filename string, text []byte, mode Mode) (f *ast.File, err error) {
 	defer func() {
 		if e := recover(); e != nil {
 			if _, ok := e.(bailout); !ok {
@@ -103,6 +202,12 @@ func ParseFile(fset *token.FileSet, filename string, src interface{}, mode Mode)
 
 		p.errors.Sort()
 		err = p.errors.Err()
+		if p.diagFn != nil {
+			p.diags.Sort()
+			for _, d := range p.diags {
+				p.diagFn(d)
+			}
+		}
 	}()
 
 	p.init(fset, filename, text, mode)
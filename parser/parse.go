@@ -6,6 +6,7 @@ import (
 	"unicode"
 
 	"github.com/zeromicro/api-ast/ast"
+	"github.com/zeromicro/api-ast/diag"
 	"github.com/zeromicro/api-ast/token"
 )
 
@@ -51,46 +52,38 @@ func (p *parser) parseIdent() *ast.Ident {
 	return &ast.Ident{NamePos: pos, Name: name}
 }
 
-// parseApiIdent api ident support more like "user-api" "/path/:name"
+// parseApiIdent api ident support more like "user-api" "/path/:name".
+// The name consists of adjacent tokens without white space in between;
+// keywords are permitted, e.g. "@handler", "jwt" or "/user/info".
 func (p *parser) parseApiIdent() *ast.Ident {
 	if p.trace {
 		defer un(trace(p, "ApiIdent"))
 	}
 	pos := p.pos
-	var name string
-	if p.tok == token.QUO { // colon for "/path/:name
-		for p.tok != token.EOF {
-			// current is Ident, need to check next is Ident. it should break
-			var check bool
-			switch p.tok {
-			case token.QUO, token.COLON:
-				name += p.tok.String()
-			default:
-				check = true
-				name += p.lit
-			}
-			p.next()
-			if check && (p.tok != token.QUO && p.tok != token.COLON) { //
-				break
-			}
+	if p.tok != token.QUO && p.tok != token.IDENT && !p.tok.IsKeyword() {
+		p.expect(token.IDENT) // use expect() error handling
+		return &ast.Ident{NamePos: pos, Name: "_"}
+	}
+
+	isPath := p.tok == token.QUO // colon for "/path/:name
+	var name strings.Builder
+	for end := pos; p.pos == end; {
+		switch {
+		case p.tok == token.IDENT, p.tok == token.INT, p.tok.IsKeyword():
+		case p.tok == token.QUO, p.tok == token.SUB, p.tok == token.PERIOD:
+		case p.tok == token.COLON && isPath:
+		default:
+			return &ast.Ident{NamePos: pos, Name: name.String()}
 		}
-	} else if p.tok == token.ATSERVER || p.tok == token.DOC || p.tok == token.HANDLER { // support @
-		name = p.lit
-		p.next()
-	} else if p.tok == token.IDENT {
-		name = p.lit
-		p.next()
-		for p.tok == token.SUB { // support user-api
-			name += p.lit
-			p.next()
-			name += p.lit
-			p.next()
+		text := p.lit
+		if text == "" {
+			text = p.tok.String()
 		}
-	} else {
-		name = "_"
-		p.expect(token.IDENT) // use expect() error handling
+		name.WriteString(text)
+		end = p.pos + token.Pos(len(text))
+		p.next()
 	}
-	return &ast.Ident{NamePos: pos, Name: name}
+	return &ast.Ident{NamePos: pos, Name: name.String()}
 }
 
 type parseSpecFunction func(doc *ast.CommentGroup, pos token.Pos, keyword token.Token, iota int) ast.Spec
@@ -153,7 +146,7 @@ func (p *parser) parseImportSpec(doc *ast.CommentGroup, _ token.Pos, _ token.Tok
 	if p.tok == token.STRING {
 		path = p.lit
 		if !isValidImport(path) {
-			p.error(pos, "invalid import path: "+path)
+			p.errorCode(diag.InvalidImportPath, pos, pos+token.Pos(len(path)), "invalid import path: "+path, nil)
 		}
 		p.next()
 	} else {
@@ -379,7 +372,7 @@ func (p *parser) parseArrayType(lbrack token.Pos, len ast.Expr) *ast.ArrayType {
 		//}
 		//p.exprLev--
 	}
-	p.expect(token.LBRACK)
+	p.expect(token.RBRACK)
 	elt := p.parseType()
 	return &ast.ArrayType{
 		Lbrack: lbrack,
@@ -440,8 +433,6 @@ func (p *parser) parseDecl(sync map[token.Token]bool) ast.Decl {
 			To:   p.pos,
 		}
 	}
-
-	panic("not support")
 }
 
 // ----------------------------------------------------------------------------
@@ -495,7 +486,15 @@ func (p *parser) parseElement(expectColon bool) *ast.KeyValueExpr {
 		}
 		p.next()
 	} else {
-		value = p.parseIdent()
+		value = p.parseApiIdent()
+		if p.tok == token.COMMA { // middleware: Auth, Log
+			list := []*ast.Ident{value.(*ast.Ident)}
+			for p.tok == token.COMMA {
+				p.next()
+				list = append(list, p.parseApiIdent())
+			}
+			value = &ast.IdentList{List: list}
+		}
 	}
 	return &ast.KeyValueExpr{
 		Key:   key,
@@ -526,7 +525,7 @@ func (p *parser) parseAtServer() *ast.AtServer {
 		defer un(trace(p, "AtServer"))
 	}
 
-	pos := p.pos
+	pos := p.expect(token.ATSERVER)
 	p.expect(token.LPAREN)
 	kvs := p.parseElementList()
 	rParen := p.expect(token.RPAREN)
@@ -546,7 +545,12 @@ func (p *parser) parseServiceApi() *ast.ServiceApi {
 	pos := p.expect(token.SERVICE)
 	name := p.parseApiIdent()
 	lBrace := p.expect(token.LBRACE)
-	serviceRoutes := p.parseServiceRouteList()
+	var serviceRoutes []*ast.ServiceRoute
+	if p.mode&TypesOnly != 0 {
+		p.skipBody()
+	} else {
+		serviceRoutes = p.parseServiceRouteList()
+	}
 	rBrace := p.expect(token.RBRACE)
 	p.expectSemi()
 
@@ -559,6 +563,21 @@ func (p *parser) parseServiceApi() *ast.ServiceApi {
 	}
 }
 
+// skipBody skips the tokens up to the closing brace of the body that the
+// previous token opened.
+func (p *parser) skipBody() {
+	depth := 0
+	for p.tok != token.EOF && (p.tok != token.RBRACE || depth > 0) {
+		switch p.tok {
+		case token.LBRACE:
+			depth++
+		case token.RBRACE:
+			depth--
+		}
+		p.next()
+	}
+}
+
 func (p *parser) parseServiceRouteList() []*ast.ServiceRoute {
 	if p.trace {
 		defer un(trace(p, "ServiceRouteList"))
@@ -601,6 +620,13 @@ func (p *parser) parseRoute() *ast.Route {
 		defer un(trace(p, "Route"))
 	}
 
+	if p.tok == token.IDENT && !isMethod(p.lit) {
+		if strings.HasPrefix(p.lit, "@") {
+			p.errorExpected(p.pos, "annotation")
+		} else {
+			p.errorExpected(p.pos, "method")
+		}
+	}
 	method := p.parseIdent()
 	path := p.parseApiIdent()
 	rPos := path.End()
@@ -612,14 +638,19 @@ func (p *parser) parseRoute() *ast.Route {
 		typ := p.parseType()
 		rparen := p.expect(token.RPAREN)
 		req = &ast.ParenExpr{Lparen: lparen, X: typ, Rparen: rparen}
-		rPos = rparen
+		rPos = req.End()
 	}
 
 	var returnPos token.Pos
+	if p.tok == token.IDENT && diag.Closest(p.lit, []string{token.RETURNS.String()}) != nil {
+		// a misspelled returns; go on as if it were spelled right
+		p.errorExpected(p.pos, "'"+token.RETURNS.String()+"'")
+		p.tok = token.RETURNS
+	}
 	if p.tok == token.RETURNS {
 		returnPos = p.pos
 		p.next()
-		rPos = returnPos
+		rPos = returnPos + token.Pos(len(token.RETURNS.String()))
 	}
 
 	var resp *ast.ParenExpr
@@ -629,7 +660,7 @@ func (p *parser) parseRoute() *ast.Route {
 		typ := p.parseType()
 		rparen := p.expect(token.RPAREN)
 		resp = &ast.ParenExpr{Lparen: lparen, X: typ, Rparen: rparen}
-		rPos = rparen
+		rPos = resp.End()
 	}
 	p.expectSemi()
 
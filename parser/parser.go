@@ -1,7 +1,11 @@
 package parser
 
 import (
+	"io"
+
 	"github.com/zeromicro/api-ast/ast"
+	"github.com/zeromicro/api-ast/cst"
+	"github.com/zeromicro/api-ast/diag"
 	"github.com/zeromicro/api-ast/scanner"
 	"github.com/zeromicro/api-ast/token"
 )
@@ -9,12 +13,17 @@ import (
 type parser struct {
 	file    *token.File
 	errors  scanner.ErrorList
+	diags   diag.List                // the errors as diagnostics
+	diagFn  func(d *diag.Diagnostic) // receives the diagnostics; or nil
 	scanner scanner.Scanner
 
 	// Tracing/debugging
-	mode   Mode
-	trace  bool
-	indent int
+	mode       Mode
+	trace      bool
+	indent     int
+	traceOut   io.Writer        // text trace output; or nil for standard output
+	tracer     func(TraceEvent) // receives trace events instead of text; or nil
+	traceNames []string         // names of the entered productions, for tracer
 
 	// Comments
 	comments    []*ast.CommentGroup
@@ -36,18 +45,19 @@ type parser struct {
 	exprLev int // < 0: in control clause, >= 0: in expression
 
 	imports []*ast.ImportSpec // list of imports
+
+	// Lossless mode
+	tokens []cst.RawToken // all scanned tokens, including comments
 }
 
 func (p *parser) init(fileSet *token.FileSet, filename string, src []byte, mode Mode) {
 	p.file = fileSet.AddFile(filename, -1, len(src))
 	var m scanner.Mode
-	if mode&ParseComments != 0 {
+	if mode&(ParseComments|Lossless) != 0 {
 		m = scanner.ScanComments
 	}
 
-	p.scanner.Init(p.file, src, func(pos token.Position, msg string) {
-		p.errors.Add(pos, msg)
-	}, m)
+	p.scanner.Init(p.file, src, p.scanError, m)
 
 	p.mode = mode
 	p.trace = mode&Trace != 0
@@ -147,18 +157,13 @@ func (p *parser) consumeCommentGroup(n int) (comments *ast.CommentGroup, endline
 
 func (p *parser) next0() {
 	if p.trace && p.pos.IsValid() {
-		s := p.tok.String()
-		switch {
-		case p.tok.IsLiteral():
-			p.printTrace(s, p.lit)
-		case p.tok.IsOperator(), p.tok.IsKeyword():
-			p.printTrace("\"" + s + "\"")
-		default:
-			p.printTrace(s)
-		}
+		p.traceToken()
 	}
 
 	p.pos, p.tok, p.lit = p.scanner.Scan()
+	if p.mode&Lossless != 0 {
+		p.tokens = append(p.tokens, cst.RawToken{Pos: p.pos, Tok: p.tok, Lit: p.lit})
+	}
 }
 
 func (p *parser) parseFile() *ast.File {
@@ -175,19 +180,23 @@ func (p *parser) parseFile() *ast.File {
 	if p.tok == token.SYNTAX { // syntax = "v1"
 		syntax = p.parseSyntax()
 	} else {
-		// TODO: 未指定 语法版本的使用默认版本
+		// 未指定语法版本的使用默认版本 (no syntax declaration: use the default version)
 		syntax = &ast.SyntaxSpec{
-			TokPos: 0,
-			Assign: 0,
-			Name:   nil,
+			Name:     &ast.BasicLit{Kind: token.STRING, Value: `"v1"`},
+			Implicit: true,
 		}
-		p.expectSemi()
-		panic("not finished")
 	}
 
 	var decls []ast.Decl
 	for p.tok != token.EOF {
-		decls = append(decls, p.parseDecl(declStart))
+		if p.mode&(ImportsOnly|HeaderOnly) != 0 && p.tok != token.IMPORT && p.tok != token.INFO {
+			break
+		}
+		d := p.parseDecl(declStart)
+		if _, ok := d.(*ast.InfoType); ok && p.mode&(ImportsOnly|HeaderOnly) == ImportsOnly {
+			continue
+		}
+		decls = append(decls, d)
 	}
 
 	f := &ast.File{
@@ -0,0 +1,289 @@
+package parser
+
+import (
+	"errors"
+	"reflect"
+
+	"github.com/zeromicro/api-ast/ast"
+	"github.com/zeromicro/api-ast/diag"
+	"github.com/zeromicro/api-ast/scanner"
+	"github.com/zeromicro/api-ast/token"
+)
+
+// Reparse applies the edit to the source src of the file old, which was
+// parsed by ParseFile from src with the file name filename and the mode,
+// and parses the result. Only the offsets of the span of the edit are
+// used. It returns the new syntax tree and source.
+//
+// Only the top-level declarations the edit touches are parsed again: the
+// declarations that end on a line before the edit and those that start,
+// with their doc comments, at least two lines after it are reused, with
+// their positions shifted to a new token.File added to fset. The nodes of
+// old are thus modified and old must not be used anymore.
+//
+// The result is the same as that of ParseFile for the new source. If the
+// reparsed declarations have syntax errors, or if the edit changes the
+// syntax declaration or leaks into the reused declarations, as by opening
+// a comment or raw string, the new source is parsed in full. So it is if
+// the mode lacks ParseComments or has Lossless, ImportsOnly or HeaderOnly
+// set. Old must have no syntax errors.
+//
+func Reparse(fset *token.FileSet, filename string, old *ast.File, src []byte, edit diag.Edit, mode Mode) (f *ast.File, newSrc []byte, err error) {
+	off, end := edit.Span.Start.Offset, edit.Span.End.Offset
+	if off < 0 || off > end || end > len(src) {
+		return nil, nil, errors.New("invalid edit")
+	}
+	newSrc = make([]byte, 0, len(src)-(end-off)+len(edit.NewText))
+	newSrc = append(newSrc, src[:off]...)
+	newSrc = append(newSrc, edit.NewText...)
+	newSrc = append(newSrc, src[end:]...)
+
+	if mode&(ParseComments|Lossless|ImportsOnly|HeaderOnly) == ParseComments {
+		if f := reparse(fset, filename, old, src, newSrc, off, end, mode); f != nil {
+			return f, newSrc, nil
+		}
+	}
+	f, err = ParseFile(fset, filename, newSrc, mode)
+	return f, newSrc, err
+}
+
+// reparse parses the declarations of old touched by the edit of src at
+// the offsets [off, end) again and returns the new syntax tree, or nil
+// if the file must be parsed in full.
+func reparse(fset *token.FileSet, filename string, old *ast.File, src, newSrc []byte, off, end int, mode Mode) *ast.File {
+	tf := oldFile(fset, old)
+	if tf == nil || tf.Size() != len(src) {
+		return nil
+	}
+	line := func(offset int) int { return tf.Line(tf.Pos(offset)) }
+	offset := func(p token.Pos) int { return tf.Offset(p) }
+	start := func(d ast.Decl) token.Pos {
+		if g, ok := d.(*ast.GenDecl); ok && g.Doc != nil {
+			return g.Doc.Pos()
+		}
+		return d.Pos()
+	}
+
+	// the edit must follow the syntax declaration and the file doc
+	first := 0
+	if !old.Syntax.Implicit {
+		first = offset(old.Syntax.End())
+	} else if old.Doc != nil {
+		first = offset(old.Doc.End())
+	}
+	if off <= first || line(off) == line(first) {
+		return nil
+	}
+
+	// reuse the declarations [0, a) and [b, n)
+	editLine, editEndLine := line(off), line(end)
+	a := 0
+	for a < len(old.Decls) && line(offset(old.Decls[a].End())) < editLine {
+		a++
+	}
+	b := a
+	for b < len(old.Decls) && line(offset(start(old.Decls[b]))) <= editEndLine+1 {
+		b++
+	}
+
+	// the reparsed region [lo, hi) of src: from the line after the last
+	// reused declaration before the edit, or after the syntax declaration,
+	// to the line of the first reused declaration after it
+	lo := first
+	if a > 0 {
+		lo = offset(old.Decls[a-1].End())
+	}
+	for lo < len(src) && src[lo] != '\n' {
+		lo++
+	}
+	if lo < len(src) {
+		lo++
+	}
+	hi := len(src)
+	if b < len(old.Decls) {
+		hi = offset(start(old.Decls[b]))
+		for hi > 0 && src[hi-1] != '\n' {
+			hi--
+		}
+	}
+	if lo > off || end > hi {
+		return nil
+	}
+	for _, c := range old.Comments {
+		if o, e := offset(c.Pos()), offset(c.End()); o < lo && e > lo || o < hi && e > hi {
+			return nil // a general comment spans the boundary
+		}
+	}
+	delta := len(newSrc) - len(src)
+	newHi := hi + delta
+
+	// the lines of the new file: those before the region are kept, those
+	// in it are added by the scanner, and those after it are shifted
+	nf := fset.AddFile(filename, -1, len(newSrc))
+	for l := 2; l <= tf.LineCount(); l++ {
+		if o := offset(tf.LineStart(l)); o <= lo {
+			nf.AddLine(o)
+		}
+	}
+	var p parser
+	p.file = nf
+	var m scanner.Mode
+	if mode&ParseComments != 0 {
+		m = scanner.ScanComments
+	}
+	p.scanner.Init(nf, newSrc, p.scanError, m)
+	p.scanner.Seek(lo)
+	p.mode = mode
+	p.trace = mode&Trace != 0
+	decls, ok := p.parseRegion(newHi)
+	if !ok {
+		return nil
+	}
+	for l := 2; l <= tf.LineCount(); l++ {
+		if o := offset(tf.LineStart(l)); o > hi {
+			nf.AddLine(o + delta)
+		}
+	}
+
+	// the region must end where the reused declarations begin; comments
+	// scanned beyond it belong to them
+	var comments []*ast.CommentGroup
+	stop := nf.Offset(p.pos)
+	for _, c := range p.comments {
+		if o := nf.Offset(c.Pos()); o >= newHi {
+			if o < stop {
+				stop = o
+			}
+			break
+		}
+		comments = append(comments, c)
+	}
+	if b < len(old.Decls) {
+		if p.tok == token.EOF || stop != offset(start(old.Decls[b]))+delta {
+			return nil
+		}
+	} else if p.tok != token.EOF {
+		return nil
+	}
+
+	// shift the reused nodes to the new file; the comments are split
+	// first, as shifting the declarations shifts their comments too
+	var before, after []*ast.CommentGroup
+	for _, c := range old.Comments {
+		switch o := offset(c.Pos()); {
+		case o < lo:
+			before = append(before, c)
+		case o >= hi:
+			after = append(after, c)
+		}
+	}
+	d0 := token.Pos(nf.Base() - tf.Base())
+	d1 := d0 + token.Pos(delta)
+	seen := make(map[uintptr]bool)
+	shift(reflect.ValueOf(old.Doc), d0, seen)
+	shift(reflect.ValueOf(old.Syntax), d0, seen)
+	f := &ast.File{Doc: old.Doc, Syntax: old.Syntax, Scope: old.Scope}
+	for _, d := range old.Decls[:a] {
+		shift(reflect.ValueOf(d), d0, seen)
+		f.Decls = append(f.Decls, d)
+	}
+	f.Decls = append(f.Decls, decls...)
+	for _, d := range old.Decls[b:] {
+		shift(reflect.ValueOf(d), d1, seen)
+		f.Decls = append(f.Decls, d)
+	}
+	for _, c := range before {
+		shift(reflect.ValueOf(c), d0, seen)
+	}
+	for _, c := range after {
+		shift(reflect.ValueOf(c), d1, seen)
+	}
+	f.Comments = append(append(before, comments...), after...)
+	for _, d := range f.Decls {
+		if g, ok := d.(*ast.GenDecl); ok && g.Tok == token.IMPORT {
+			for _, s := range g.Specs {
+				if s, ok := s.(*ast.ImportSpec); ok {
+					f.Imports = append(f.Imports, s)
+				}
+			}
+		}
+	}
+	if mode&SkipObjectResolution == 0 {
+		resolveFile(f, nf, nil)
+	}
+	return f
+}
+
+// parseRegion parses the declarations that start before the offset end
+// and reports whether they are free of errors.
+func (p *parser) parseRegion(end int) (decls []ast.Decl, ok bool) {
+	defer func() {
+		if e := recover(); e != nil {
+			if _, isBailout := e.(bailout); !isBailout {
+				panic(e)
+			}
+			ok = false
+		}
+	}()
+	p.next()
+	for p.tok != token.EOF && p.file.Offset(p.pos) < end {
+		if p.tok == token.SYNTAX {
+			return nil, false
+		}
+		decls = append(decls, p.parseDecl(declStart))
+	}
+	return decls, p.errors.Len() == 0
+}
+
+// oldFile returns the token.File of the file f, or nil.
+func oldFile(fset *token.FileSet, f *ast.File) *token.File {
+	if f.Syntax.Pos().IsValid() {
+		return fset.File(f.Syntax.Pos())
+	}
+	if f.Doc != nil {
+		return fset.File(f.Doc.Pos())
+	}
+	if len(f.Decls) > 0 {
+		return fset.File(f.Decls[0].Pos())
+	}
+	if len(f.Comments) > 0 {
+		return fset.File(f.Comments[0].Pos())
+	}
+	return nil
+}
+
+var posType = reflect.TypeOf(token.NoPos)
+
+// shift adds delta to all valid positions in the syntax tree v. Nodes
+// in seen are skipped; shifted nodes are added to seen.
+func shift(v reflect.Value, delta token.Pos, seen map[uintptr]bool) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() || seen[v.Pointer()] {
+			return
+		}
+		seen[v.Pointer()] = true
+		shift(v.Elem(), delta, seen)
+	case reflect.Interface:
+		if !v.IsNil() {
+			shift(v.Elem(), delta, seen)
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			shift(v.Index(i), delta, seen)
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			fv := v.Field(i)
+			switch {
+			case fv.Type() == posType:
+				if p := token.Pos(fv.Int()); p.IsValid() && fv.CanSet() {
+					fv.SetInt(int64(p + delta))
+				}
+			case fv.Type() == reflect.TypeOf((*ast.Scope)(nil)):
+			default:
+				shift(fv, delta, seen)
+			}
+		}
+	}
+}
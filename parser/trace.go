@@ -1,24 +1,115 @@
 package parser
 
-import "fmt"
+import (
+	"fmt"
+	"os"
+
+	"github.com/zeromicro/api-ast/token"
+)
+
+// A TraceKind is the kind of a trace event.
+type TraceKind int
+
+const (
+	TraceEnter TraceKind = iota // a production is entered
+	TraceExit                   // a production is left
+	TraceToken                  // a token is consumed
+	TraceError                  // an error is reported
+)
+
+var traceKinds = [...]string{
+	TraceEnter: "enter",
+	TraceExit:  "exit",
+	TraceToken: "token",
+	TraceError: "error",
+}
+
+func (k TraceKind) String() string {
+	if 0 <= k && int(k) < len(traceKinds) {
+		return traceKinds[k]
+	}
+	return fmt.Sprintf("TraceKind(%d)", int(k))
+}
+
+// MarshalText encodes k as its name, such as "enter".
+func (k TraceKind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+// A TraceEvent is an event of the trace of a parse.
+//
+type TraceEvent struct {
+	Kind  TraceKind      `json:"kind"`
+	Name  string         `json:"name"`          // production for enter and exit, token for token, message for error
+	Lit   string         `json:"lit,omitempty"` // literal of a token, if any
+	Pos   token.Position `json:"pos"`           // current position; for an error, position of the error
+	Depth int            `json:"depth"`         // number of enclosing productions
+}
+
+// String returns e in the form of a line of the text trace.
+func (e TraceEvent) String() string {
+	return fmt.Sprintf("%d:%d: %s %s %s", e.Pos.Line, e.Pos.Column, e.Kind, e.Name, e.Lit)
+}
 
 func (p *parser) printTrace(a ...interface{}) {
 	const dots = ". . . . . . . . . . . . . . . . . . . . . . . . . . . . . . . . "
 	const n = len(dots)
+	w := p.traceOut
+	if w == nil {
+		w = os.Stdout
+	}
 	pos := p.file.Position(p.pos)
-	fmt.Printf("%5d:%3d: ", pos.Line, pos.Column)
+	fmt.Fprintf(w, "%5d:%3d: ", pos.Line, pos.Column)
 	i := 2 * p.indent
 	for i > n {
-		fmt.Print(dots)
+		fmt.Fprint(w, dots)
 		i -= n
 	}
 	// i <= n
-	fmt.Print(dots[0:i])
-	fmt.Println(a...)
+	fmt.Fprint(w, dots[0:i])
+	fmt.Fprintln(w, a...)
+}
+
+// traceEvent delivers an event of the kind at the current position.
+func (p *parser) traceEvent(kind TraceKind, name, lit string) {
+	p.tracer(TraceEvent{Kind: kind, Name: name, Lit: lit, Pos: p.file.Position(p.pos), Depth: p.indent})
+}
+
+// traceToken traces the current token.
+func (p *parser) traceToken() {
+	s := p.tok.String()
+	switch {
+	case p.tracer != nil:
+		lit := ""
+		if p.tok.IsLiteral() {
+			lit = p.lit
+		}
+		p.traceEvent(TraceToken, s, lit)
+	case p.tok.IsLiteral():
+		p.printTrace(s, p.lit)
+	case p.tok.IsOperator(), p.tok.IsKeyword():
+		p.printTrace("\"" + s + "\"")
+	default:
+		p.printTrace(s)
+	}
+}
+
+// traceError traces the error msg at pos.
+func (p *parser) traceError(pos token.Pos, msg string) {
+	if p.tracer != nil {
+		p.tracer(TraceEvent{Kind: TraceError, Name: msg, Pos: p.file.Position(pos), Depth: p.indent})
+		return
+	}
+	un(trace(p, "error: "+msg))
 }
 
 func trace(p *parser, msg string) *parser {
-	p.printTrace(msg, "(")
+	if p.tracer != nil {
+		p.traceEvent(TraceEnter, msg, "")
+		p.traceNames = append(p.traceNames, msg)
+	} else {
+		p.printTrace(msg, "(")
+	}
 	p.indent++
 	return p
 }
@@ -26,5 +117,11 @@ func trace(p *parser, msg string) *parser {
 // Usage pattern: defer un(trace(p, "..."))
 func un(p *parser) {
 	p.indent--
-	p.printTrace(")")
+	if p.tracer != nil {
+		n := len(p.traceNames) - 1
+		p.traceEvent(TraceExit, p.traceNames[n], "")
+		p.traceNames = p.traceNames[:n]
+	} else {
+		p.printTrace(")")
+	}
 }
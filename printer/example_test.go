@@ -0,0 +1,94 @@
+package printer_test
+
+import (
+	"os"
+
+	"github.com/zeromicro/api-ast/parser"
+	"github.com/zeromicro/api-ast/printer"
+	"github.com/zeromicro/api-ast/token"
+)
+
+func ExampleConfig_Fprint() {
+	fset := token.NewFileSet() // positions are relative to fset
+
+	src := `syntax = "v1"
+info(
+	title: "demo"
+	version: "1.0"
+)
+type User {
+	Id int64 ` + "`json:\"id\"`" + `
+	Name string ` + "`json:\"name\"`" + ` // the name
+}
+service demo-api {
+  @handler getUser
+  get /user/:id returns (User)
+}
+`
+
+	f, err := parser.ParseFile(fset, "demo.api", src, parser.ParseComments)
+	if err != nil {
+		panic(err)
+	}
+
+	cfg := printer.Config{Mode: printer.UseSpaces | printer.TabIndent, TabWidth: 8}
+	if err := cfg.Fprint(os.Stdout, fset, f); err != nil {
+		panic(err)
+	}
+
+	// output:
+	// syntax = "v1"
+	//
+	// info(
+	// 	title:   "demo"
+	// 	version: "1.0"
+	// )
+	//
+	// type User {
+	// 	Id   int64  `json:"id"`
+	// 	Name string `json:"name"` // the name
+	// }
+	//
+	// service demo-api {
+	// 	@handler getUser
+	// 	get /user/:id returns (User)
+	// }
+}
+
+func ExampleConfig_Fprint_server() {
+	fset := token.NewFileSet() // positions are relative to fset
+
+	src := `@server(
+	jwt: Auth
+	group: user-service
+	prefix: /api/v1
+	middleware: Log,Trace
+)
+service user-api {
+	@handler getUser
+	get /user/info returns (User)
+}
+`
+
+	f, err := parser.ParseFile(fset, "user.api", src, parser.ParseComments)
+	if err != nil {
+		panic(err)
+	}
+
+	cfg := printer.Config{Mode: printer.UseSpaces | printer.TabIndent, TabWidth: 8}
+	if err := cfg.Fprint(os.Stdout, fset, f); err != nil {
+		panic(err)
+	}
+
+	// output:
+	// @server(
+	// 	jwt:        Auth
+	// 	group:      user-service
+	// 	prefix:     /api/v1
+	// 	middleware: Log, Trace
+	// )
+	// service user-api {
+	// 	@handler getUser
+	// 	get /user/info returns (User)
+	// }
+}
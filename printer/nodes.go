@@ -1,99 +1,252 @@
 package printer
 
 import (
+	"strings"
+
 	"github.com/zeromicro/api-ast/ast"
 	"github.com/zeromicro/api-ast/token"
 )
 
 // ----------------------------------------------------------------------------
-//
+// Common AST nodes.
 
-func (p *printer) file(src *ast.File) {
-	p.setComment(src.Doc)
-	// for go it print package name; go-zero should just print decl
-	//p.print(src.Pos(), token.PACKAGE, blank)
-	//p.expr(src.Name)
-	p.declList(src.Decls)
-	p.print(newline)
+// Print as many newlines as necessary (but at least min newlines) to get to
+// the current line. ws is printed before the first line break. If newSection
+// is set, the first line break is printed as formfeed. Returns 0 if no line
+// breaks were printed, returns 1 if there was exactly one newline printed,
+// and returns a value > 1 if there was a formfeed or more than one newline
+// printed.
+func (p *printer) linebreak(line, min int, ws whiteSpace, newSection bool) (nbreaks int) {
+	n := nlimit(line - p.pos.Line)
+	if n < min {
+		n = min
+	}
+
+	if n > 0 {
+		p.print(ws)
+		if newSection {
+			p.print(formfeed)
+			n--
+			nbreaks = 2
+		}
+		nbreaks += n
+		for ; n > 0; n-- {
+			p.print(newline)
+		}
+	}
+	return
 }
 
+// setComment sets g as the next comment if g != nil and if node comments
+// are enabled - this mode is used when printing source code fragments such
+// as exports only. It assumes that there is no pending comment in p.comments
+// and at most one pending comment in the p.comment cache.
 func (p *printer) setComment(g *ast.CommentGroup) {
-	if g == nil { // p.useNodeComments
+	if g == nil || !p.useNodeComments {
 		return
 	}
 	if p.comments == nil {
+		// initialize p.comments lazily
 		p.comments = make([]*ast.CommentGroup, 1)
 	} else if p.cindex < len(p.comments) {
+		// for some reason there are pending comments; this
+		// should never happen - handle gracefully and flush
+		// all comments up to g, ignore anything after that
 		p.flush(p.posFor(g.List[0].Pos()), token.ILLEGAL)
 		p.comments = p.comments[0:1]
+		// in debug mode, report error
 		p.internalError("setComment found pending comments")
 	}
-
 	p.comments[0] = g
 	p.cindex = 0
-
+	// don't overwrite any pending comment in the p.comment cache
+	// (there may be a pending comment when a line comment is
+	// immediately followed by a lead comment with no other
+	// tokens between)
 	if p.commentOffset == infinity {
-		p.nextComment()
+		p.nextComment() // get comment ready for use
 	}
 }
 
-func (p *printer) declList(list []ast.Decl) {
-	tok := token.ILLEGAL
-	for _, d := range list {
-		prev := tok
-		tok = declToken(d)
-		if len(p.output) > 0 {
-			min := 1
-			if prev != tok || getDoc(d) != nil {
-				min = 2
-			}
+// setLineComment is like setComment for the line comment g of a node.
+// Without position, g is printed on the line of the preceding token.
+func (p *printer) setLineComment(g *ast.CommentGroup) {
+	p.setComment(g)
+	if g != nil && p.useNodeComments {
+		p.lineComment = g
+	}
+}
 
-			p.linebreak(p.lineFor(d.Pos()), min, ignore, p.numLines(d) > 1)
+func (p *printer) identList(list []*ast.Ident) {
+	for i, x := range list {
+		if i > 0 {
+			p.print(token.COMMA, blank)
 		}
-		p.decl(d)
+		p.expr(x)
 	}
 }
 
-// numLines returns the number of lines spanned by node n in the original source.
-func (p *printer) numLines(n ast.Node) int {
-	if from := n.Pos(); from.IsValid() {
-		if to := n.End(); to.IsValid() {
-			return p.lineFor(to) - p.lineFor(from) + 1
+// fieldList prints the fields of a struct type. The opening brace is
+// preceded by a blank unless the struct type is printed without the
+// struct keyword right after a type name.
+func (p *printer) fieldList(fields *ast.FieldList, leadingBlank bool) {
+	lbrace := fields.Opening
+	list := fields.List
+	rbrace := fields.Closing
+	// unlike in Go, the brace may follow an identifier, which would make
+	// commentBefore report no comments
+	hasComments := p.commentOffset < p.posFor(rbrace).Offset
+
+	if leadingBlank {
+		p.print(blank)
+	}
+	if len(list) == 0 && !hasComments {
+		p.print(lbrace, token.LBRACE, rbrace, token.RBRACE)
+		return
+	}
+	p.print(lbrace, token.LBRACE, indent)
+	if hasComments || len(list) > 0 {
+		p.print(formfeed)
+	}
+
+	sep := vtab
+	if len(list) == 1 {
+		sep = blank
+	}
+	var line int
+	for i, f := range list {
+		if i > 0 {
+			p.linebreak(p.lineFor(f.Pos()), 1, ignore, p.linesFrom(line) > 0)
+		}
+		extraTabs := 0
+		p.setComment(f.Doc)
+		p.recordLine(&line)
+		if len(f.Names) > 0 {
+			// named fields
+			p.identList(f.Names)
+			p.print(sep)
+			p.expr(f.Type)
+			extraTabs = 1
+		} else {
+			// anonymous field
+			p.expr(f.Type)
+			extraTabs = 2
+		}
+		if f.Tag != nil {
+			p.print(sep)
+			p.expr(f.Tag)
+			extraTabs = 0
+		}
+		if f.Comment != nil {
+			for ; extraTabs > 0; extraTabs-- {
+				p.print(sep)
+			}
+			p.setLineComment(f.Comment)
 		}
 	}
-	return infinity
+
+	p.print(unindent, formfeed, rbrace, token.RBRACE)
 }
 
-func (p *printer) linebreak(line, min int, ws whiteSpace, newSection bool) (nbreaks int) {
-	n := nlimit(line - p.pos.Line)
-	if n < min {
-		n = min
-	}
+// ----------------------------------------------------------------------------
+// Expressions
 
-	if n > 0 {
-		p.print(ws)
-		if newSection {
-			p.print(formfeed)
-			n--
-			nbreaks = 2
+func (p *printer) expr(x ast.Expr) {
+	switch x := x.(type) {
+	case *ast.BadExpr:
+		p.print(x.Pos(), "BadExpr")
+
+	case *ast.Ident:
+		p.print(x.Pos(), x)
+
+	case *ast.BasicLit:
+		p.print(x.Pos(), x)
+
+	case *ast.SelectorExpr:
+		p.expr(x.X)
+		p.print(token.PERIOD)
+		p.expr(x.Sel)
+
+	case *ast.StarExpr:
+		p.print(x.Star, token.MUL)
+		p.expr(x.X)
+
+	case *ast.ParenExpr:
+		p.print(x.Lparen, token.LPAREN)
+		p.expr(x.X)
+		p.print(x.Rparen, token.RPAREN)
+
+	case *ast.ArrayType:
+		p.print(x.Lbrack, token.LBRACK)
+		if x.Len != nil {
+			p.expr(x.Len)
 		}
-		nbreaks += n
-		for ; n > 0; n-- {
-			p.print(newline)
+		p.print(token.RBRACK)
+		p.expr(x.Elt)
+
+	case *ast.MapType:
+		p.print(x.Map, token.MAP, token.LBRACK)
+		p.expr(x.Key)
+		p.print(token.RBRACK)
+		p.expr(x.Value)
+
+	case *ast.StructType:
+		// the struct keyword is optional; print it only if the
+		// source has it
+		keyword := x.Struct.IsValid() && x.Struct != x.Fields.Opening
+		if keyword {
+			p.print(x.Struct, token.STRUCT)
+		}
+		p.fieldList(x.Fields, keyword)
+
+	case *ast.IdentList:
+		for i, id := range x.List {
+			if i > 0 {
+				p.print(token.COMMA, blank)
+			}
+			p.expr(id)
 		}
+
+	case *ast.KeyValueExpr:
+		p.expr(x.Key)
+		if strings.HasPrefix(x.Key.Name, "@") {
+			// annotations such as @doc have no colon
+			p.print(blank)
+		} else {
+			p.print(x.Colon, token.COLON, vtab)
+		}
+		p.expr(x.Value)
+
+	default:
+		panic("unreachable")
 	}
-	return
 }
 
-func (p *printer) decl(decl ast.Decl) {
-	switch d := decl.(type) {
-	case *ast.BadDecl:
-		p.print(d.Pos(), "BadDecl")
-	case *ast.GenDecl:
-		p.genDecl(d)
-	//case *ast.FuncDecl:
-	//	p.funcDecl(d)
-	// TODO: finish me
+// ----------------------------------------------------------------------------
+// Declarations
+
+func (p *printer) spec(spec ast.Spec, n int) {
+	switch s := spec.(type) {
+	case *ast.ImportSpec:
+		p.setComment(s.Doc)
+		p.expr(s.Path)
+		p.setLineComment(s.Comment)
+		p.print(s.EndPos)
+
+	case *ast.TypeSpec:
+		p.setComment(s.Doc)
+		p.expr(s.Name)
+		if st, ok := s.Type.(*ast.StructType); ok && !(st.Struct.IsValid() && st.Struct != st.Fields.Opening) {
+			// the brace of a struct without keyword follows the name directly
+			p.print(blank)
+		} else if n == 1 {
+			p.print(blank)
+		} else {
+			p.print(vtab)
+		}
+		p.expr(s.Type)
+		p.setLineComment(s.Comment)
+
 	default:
 		panic("unreachable")
 	}
@@ -103,19 +256,167 @@ func (p *printer) genDecl(d *ast.GenDecl) {
 	p.setComment(d.Doc)
 	p.print(d.Pos(), d.Tok, blank)
 
-	if d.Lparen.IsValid() || len(d.Specs) > 1 {
-		p.print(indent, formfeed)
+	if d.Lparen.IsValid() || len(d.Specs) != 1 {
+		// group of parenthesized declarations
+		p.print(d.Lparen, token.LPAREN)
 		if n := len(d.Specs); n > 0 {
-
-		} else {
+			p.print(indent, formfeed)
 			var line int
 			for i, s := range d.Specs {
 				if i > 0 {
-					p.linebreak(p.lineFor(s.Pos()), 1, ignore, p.lines)
+					p.linebreak(p.lineFor(s.Pos()), 1, ignore, p.linesFrom(line) > 0)
 				}
 				p.recordLine(&line)
+				p.spec(s, n)
 			}
+			p.print(unindent, formfeed)
 		}
 		p.print(d.Rparen, token.RPAREN)
+	} else {
+		// single declaration
+		p.spec(d.Specs[0], 1)
+	}
+}
+
+// keyValueList prints the key-value pairs of an info or @server
+// declaration, one per line, with the values aligned.
+func (p *printer) keyValueList(kvs []*ast.KeyValueExpr, rparen token.Pos) {
+	p.print(token.LPAREN)
+	if len(kvs) > 0 {
+		p.print(indent, formfeed)
+		var line int
+		for i, kv := range kvs {
+			if i > 0 {
+				p.linebreak(p.lineFor(kv.Pos()), 1, ignore, p.linesFrom(line) > 0)
+			}
+			p.recordLine(&line)
+			p.expr(kv)
+		}
+		p.print(unindent, formfeed)
+	}
+	p.print(rparen, token.RPAREN)
+}
+
+func (p *printer) infoType(d *ast.InfoType) {
+	p.print(d.Pos(), token.INFO)
+	p.keyValueList(d.Kvs, d.RParen)
+}
+
+func (p *printer) service(d *ast.Service) {
+	if s := d.AtServer; s != nil {
+		p.print(s.Pos(), token.ATSERVER)
+		p.keyValueList(s.Kvs, s.RParen)
+		p.linebreak(p.lineFor(d.ServiceApi.Pos()), 1, ignore, false)
+	}
+
+	api := d.ServiceApi
+	p.print(api.Pos(), token.SERVICE, blank)
+	p.expr(api.Name)
+	p.print(blank, api.LBrace, token.LBRACE, indent)
+	if len(api.ServiceRoute) > 0 || p.commentBefore(p.posFor(api.RBrace)) {
+		p.print(formfeed)
+	}
+	for i, r := range api.ServiceRoute {
+		if i > 0 {
+			// separate routes with annotations by a blank line
+			min := 1
+			if r.AtDoc != nil || r.AtHandler != nil {
+				min = 2
+			}
+			p.linebreak(p.lineFor(r.Pos()), min, ignore, true)
+		}
+		p.serviceRoute(r)
+	}
+	p.print(unindent, formfeed, api.RBrace, token.RBRACE)
+}
+
+func (p *printer) serviceRoute(r *ast.ServiceRoute) {
+	// print the annotations in source order
+	annotations := []*ast.KeyValueExpr{r.AtDoc, r.AtHandler}
+	if r.AtDoc != nil && r.AtHandler != nil && r.AtHandler.Pos() < r.AtDoc.Pos() {
+		annotations[0], annotations[1] = annotations[1], annotations[0]
+	}
+	for _, kv := range annotations {
+		if kv != nil {
+			p.expr(kv)
+			p.print(newline)
+		}
+	}
+	p.route(r.Route)
+}
+
+func (p *printer) route(r *ast.Route) {
+	p.expr(r.Method)
+	p.print(blank)
+	p.expr(r.Path)
+	if r.Req != nil {
+		p.print(blank)
+		p.expr(r.Req)
+	}
+	if r.ReturnPos.IsValid() || r.Resp != nil {
+		p.print(blank, r.ReturnPos, token.RETURNS)
+	}
+	if r.Resp != nil {
+		p.print(blank)
+		p.expr(r.Resp)
+	}
+}
+
+func (p *printer) decl(decl ast.Decl) {
+	switch d := decl.(type) {
+	case *ast.BadDecl:
+		p.print(d.Pos(), "BadDecl")
+	case *ast.GenDecl:
+		p.genDecl(d)
+	case *ast.InfoType:
+		p.infoType(d)
+	case *ast.Service:
+		p.service(d)
+	default:
+		panic("unreachable")
 	}
-}
\ No newline at end of file
+}
+
+// ----------------------------------------------------------------------------
+// Files
+
+func (p *printer) declList(list []ast.Decl) {
+	tok := token.ILLEGAL
+	for _, d := range list {
+		prev := tok
+		tok = declToken(d)
+		// If the declaration token changed (e.g., from import to type)
+		// or the next declaration has documentation associated with it,
+		// print an empty line between top-level declarations. Declarations
+		// spanning several lines, such as services, are separated the same
+		// way.
+		if len(p.output) > 0 {
+			min := 1
+			if prev != tok || getDoc(d) != nil || p.numLines(d) > 1 {
+				min = 2
+			}
+			p.linebreak(p.lineFor(d.Pos()), min, ignore, p.numLines(d) > 1)
+		}
+		p.decl(d)
+	}
+}
+
+// numLines returns the number of lines spanned by node n in the original source.
+func (p *printer) numLines(n ast.Node) int {
+	if from := n.Pos(); from.IsValid() {
+		if to := n.End(); to.IsValid() {
+			return p.lineFor(to) - p.lineFor(from) + 1
+		}
+	}
+	return infinity
+}
+
+func (p *printer) file(src *ast.File) {
+	p.setComment(src.Doc)
+	if s := src.Syntax; s != nil && !s.Implicit {
+		p.print(s.Pos(), token.SYNTAX, blank, s.Assign, token.ASSIGN, blank)
+		p.expr(s.Name)
+	}
+	p.declList(src.Decls)
+	p.print(newline)
+}
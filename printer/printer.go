@@ -5,6 +5,7 @@ import (
 	"github.com/zeromicro/api-ast/ast"
 	"github.com/zeromicro/api-ast/token"
 	"os"
+	"text/tabwriter"
 )
 
 type whiteSpace byte
@@ -41,6 +42,11 @@ type printer struct {
 	prevOpen    token.Token  // previous non-brace "open" token (, [, or token.ILLEGAL
 	wsbuf       []whiteSpace // delayed white space
 
+	// The endAlignment flag is set when a literal or comment spanning
+	// lines breaks the column formatting of the current section; any
+	// further alignment up to the end of the line is ignored.
+	endAlignment bool
+
 	// Positions
 	// The out position differs from the pos position when the result
 	// formatting differs from the source formatting (in the amount of
@@ -54,7 +60,9 @@ type printer struct {
 
 	// comment
 	commentInfo
-	comments []*ast.CommentGroup // may be nil
+	comments        []*ast.CommentGroup // may be nil
+	useNodeComments bool                // if not set, ignore lead and line comments of nodes
+	lineComment     *ast.CommentGroup   // last line comment of a node, see setLineComment
 
 	cachedPos  token.Pos
 	cachedLine int // line corresponding to cachedPos
@@ -63,31 +71,39 @@ type printer struct {
 func (p *printer) init(cfg *Config, fSet *token.FileSet, nodeSize map[ast.Node]int) {
 	p.config = *cfg
 	p.fSet = fSet
+	p.pos = token.Position{Line: 1, Column: 1}
+	p.out = token.Position{Line: 1, Column: 1}
 	p.wsbuf = make([]whiteSpace, 0, 16)
 	p.cachedPos = -1
 }
 
 func (p *printer) printNode(node ast.Node) error {
+	// get comments ready for use
 	if n, ok := node.(*ast.File); ok {
 		p.comments = n.Comments
 	}
+	// if there are no comments, use node comments
+	p.useNodeComments = p.comments == nil
 
 	p.nextComment()
 
 	p.print(pmode(0)) // pmode(0) just rest p.mode
 
+	// format node
 	switch n := node.(type) {
-	// TODO: finish me
 	case ast.Expr:
-
+		p.expr(n)
 	case ast.Decl:
+		p.decl(n)
 	case ast.Spec:
-	//case []ast.Decl:
+		p.spec(n, 1)
 	case *ast.File:
 		p.file(n)
+	default:
+		return fmt.Errorf("go/printer: unsupported node type %T", node)
 	}
 
-	return fmt.Errorf("go/printer: unsupported node type %T", node)
+	return nil
 }
 
 // nlimit limits n to maxNewlines.
@@ -313,44 +329,58 @@ func (p *printer) writeWhitespace(n int) {
 	p.wsbuf = p.wsbuf[:l]
 }
 
+// writeIndent writes indentation.
+func (p *printer) writeIndent() {
+	// use "hard" htabs - indentation columns
+	// must not be discarded by the tabwriter
+	n := p.config.Indent + p.indent // include base indentation
+	for i := 0; i < n; i++ {
+		p.output = append(p.output, '\t')
+	}
+
+	// update positions
+	p.pos.Offset += n
+	p.pos.Column += n
+	p.out.Column += n
+}
+
 // writeByte writes ch n times to p.output and updates p.pos.
 // Only used to write formatting (white space) characters.
 func (p *printer) writeByte(ch byte, n int) {
-	// TODO: finish me
-	//if p.endAlignment {
-	//	// Ignore any alignment control character;
-	//	// and at the end of the line, break with
-	//	// a formfeed to indicate termination of
-	//	// existing columns.
-	//	switch ch {
-	//	case '\t', '\v':
-	//		ch = ' '
-	//	case '\n', '\f':
-	//		ch = '\f'
-	//		p.endAlignment = false
-	//	}
-	//}
-	//
-	//if p.out.Column == 1 {
-	//	// no need to write line directives before white space
-	//	p.writeIndent()
-	//}
-	//
-	//for i := 0; i < n; i++ {
-	//	p.output = append(p.output, ch)
-	//}
-	//
-	//// update positions
-	//p.pos.Offset += n
-	//if ch == '\n' || ch == '\f' {
-	//	p.pos.Line += n
-	//	p.out.Line += n
-	//	p.pos.Column = 1
-	//	p.out.Column = 1
-	//	return
-	//}
-	//p.pos.Column += n
-	//p.out.Column += n
+	if p.endAlignment {
+		// Ignore any alignment control character;
+		// and at the end of the line, break with
+		// a formfeed to indicate termination of
+		// existing columns.
+		switch ch {
+		case '\t', '\v':
+			ch = ' '
+		case '\n', '\f':
+			ch = '\f'
+			p.endAlignment = false
+		}
+	}
+
+	if p.out.Column == 1 {
+		// no need to write line directives before white space
+		p.writeIndent()
+	}
+
+	for i := 0; i < n; i++ {
+		p.output = append(p.output, ch)
+	}
+
+	// update positions
+	p.pos.Offset += n
+	if ch == '\n' || ch == '\f' {
+		p.pos.Line += n
+		p.out.Line += n
+		p.pos.Column = 1
+		p.out.Column = 1
+		return
+	}
+	p.pos.Column += n
+	p.out.Column += n
 }
 
 // writeString writes the string s to p.output and updates p.pos, p.out,
@@ -365,5 +395,58 @@ func (p *printer) writeByte(ch byte, n int) {
 // printer benchmark by up to 10%.
 //
 func (p *printer) writeString(pos token.Position, s string, isLit bool) {
-	// TODO: finish me
+	if p.out.Column == 1 {
+		p.writeIndent()
+	}
+
+	if pos.IsValid() {
+		// update p.pos (if pos is invalid, continue with existing p.pos)
+		// Note: Must do this after handling line beginnings because
+		// writeIndent updates p.pos if there's indentation, but p.pos
+		// is the position of s.
+		p.pos = pos
+	}
+
+	if isLit {
+		// Protect s such that is passes through the tabwriter
+		// unchanged. Note that valid api files cannot contain
+		// tabwriter.Escape bytes since they do not appear in legal
+		// UTF-8 sequences.
+		p.output = append(p.output, tabwriter.Escape)
+	}
+
+	p.output = append(p.output, s...)
+
+	// update positions
+	nlines := 0
+	var li int // index of last newline; valid if nlines > 0
+	for i := 0; i < len(s); i++ {
+		// Raw string literals may contain any character except back quote (`).
+		if ch := s[i]; ch == '\n' || ch == '\f' {
+			// account for line break
+			nlines++
+			li = i
+			// A line break inside a literal will break whatever column
+			// formatting is in place; ignore any further alignment through
+			// the end of the line.
+			p.endAlignment = true
+		}
+	}
+	p.pos.Offset += len(s)
+	if nlines > 0 {
+		p.pos.Line += nlines
+		p.out.Line += nlines
+		c := len(s) - li
+		p.pos.Column = c
+		p.out.Column = c
+	} else {
+		p.pos.Column += len(s)
+		p.out.Column += len(s)
+	}
+
+	if isLit {
+		p.output = append(p.output, tabwriter.Escape)
+	}
+
+	p.last = p.pos
 }
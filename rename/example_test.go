@@ -0,0 +1,186 @@
+package rename_test
+
+import (
+	"fmt"
+
+	"github.com/zeromicro/api-ast/ast"
+	"github.com/zeromicro/api-ast/diag"
+	"github.com/zeromicro/api-ast/loader"
+	"github.com/zeromicro/api-ast/parser"
+	"github.com/zeromicro/api-ast/rename"
+	"github.com/zeromicro/api-ast/token"
+)
+
+func ExampleType() {
+	const src = `type (
+	// User is a registered user.
+	User {
+		Name string ` + "`json:\"name\"`" + `
+	}
+	ListReply {
+		Users []User ` + "`json:\"users\"`" + ` // first page
+	}
+)
+
+service user-api {
+	@handler getUser
+	get /users/:id returns (User)
+	@handler listUsers
+	get /users returns (ListReply)
+}
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "user.api", src, parser.ParseComments)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	api := &loader.API{Fset: fset, Files: []*ast.File{f}, Filenames: []string{"user.api"}}
+
+	if _, err := rename.Type(api, "User", "ListReply"); err != nil {
+		fmt.Println(err)
+	}
+	edits, err := rename.Type(api, "User", "Account")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	for _, e := range edits {
+		fmt.Println(e)
+	}
+	out, err := diag.Apply([]byte(src), edits)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Print(string(out))
+	// Output:
+	// rename: type ListReply is already declared at user.api:6:2
+	// user.api:3:2: User -> Account
+	// user.api:7:11: User -> Account
+	// user.api:13:26: User -> Account
+	// type (
+	// 	// User is a registered user.
+	// 	Account {
+	// 		Name string `json:"name"`
+	// 	}
+	// 	ListReply {
+	// 		Users []Account `json:"users"` // first page
+	// 	}
+	// )
+	//
+	// service user-api {
+	// 	@handler getUser
+	// 	get /users/:id returns (Account)
+	// 	@handler listUsers
+	// 	get /users returns (ListReply)
+	// }
+}
+
+func ExampleHandler() {
+	const src = `service user-api {
+	@handler getUser
+	get /users/:id
+	@handler listUsers
+	get /users
+}
+
+service admin-api {
+	@handler getUser
+	get /admin/users/:id
+}
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "user.api", src, parser.ParseComments)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	api := &loader.API{Fset: fset, Files: []*ast.File{f}, Filenames: []string{"user.api"}}
+
+	if _, err := rename.Handler(api, "user-api", "getUser", "listUsers"); err != nil {
+		fmt.Println(err)
+	}
+	if edits, err := rename.Handler(api, "", "getUser", "getUser"); err == nil {
+		fmt.Println(len(edits), "edits")
+	}
+	edits, err := rename.Handler(api, "user-api", "getUser", "findUser")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	for _, e := range edits {
+		fmt.Println(e)
+	}
+	// output:
+	// rename: handler listUsers is already declared at user.api:4:11
+	// 0 edits
+	// user.api:2:11: getUser -> findUser
+}
+
+func ExampleField() {
+	const src = `type (
+	Base {
+		Id int64 ` + "`json:\"id\"`" + `
+	}
+	User {
+		Base
+		Name  string ` + "`json:\"name\"`" + `
+		Email string ` + "`json:\"email\"`" + `
+		Age   int
+	}
+)
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "user.api", src, parser.ParseComments)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	api := &loader.API{Fset: fset, Files: []*ast.File{f}, Filenames: []string{"user.api"}}
+
+	// refused: collisions and a change of the encoding
+	if _, err := rename.Field(api, "User", "Name", "Email", ""); err != nil {
+		fmt.Println(err)
+	}
+	if _, err := rename.Field(api, "User", "Name", "FullName", "id"); err != nil {
+		fmt.Println(err)
+	}
+	if _, err := rename.Field(api, "User", "Age", "Years", ""); err != nil {
+		fmt.Println(err)
+	}
+
+	var edits []diag.Edit
+	for _, args := range [][3]string{
+		{"Name", "FullName", "fullName"}, // renames the field and its json name
+		{"Age", "Years", "age"},          // adds a json tag keeping the encoding
+	} {
+		list, err := rename.Field(api, "User", args[0], args[1], args[2])
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		edits = append(edits, list...)
+	}
+	out, err := diag.Apply([]byte(src), edits)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Print(string(out))
+	// output:
+	// rename: field Name would collide with field Email of User at user.api:8:3
+	// rename: json name "id" is already used by field Id of User at user.api:3:3
+	// rename: field Age is encoded by its Go name; give a json name to keep its encoding
+	// type (
+	// 	Base {
+	// 		Id int64 `json:"id"`
+	// 	}
+	// 	User {
+	// 		Base
+	// 		FullName  string `json:"fullName"`
+	// 		Email string `json:"email"`
+	// 		Years   int `json:"age"`
+	// 	}
+	// )
+}
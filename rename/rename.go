@@ -0,0 +1,382 @@
+// Package rename computes the edits that rename a type, a handler or a
+// struct field throughout an API and all files it imports.
+//
+// A renamed type is renamed in its declaration and in every reference:
+// the types of fields, embedded fields, the definitions of other types
+// and the requests and responses of routes. A renamed handler is renamed
+// in every @handler of the service that declares it. A renamed field is
+// renamed in its struct and, optionally, in its json tag.
+//
+// Renames that would make the API mean something else are refused: a
+// new type name that is already declared or predeclared, a new handler
+// name already used in the service, a new field or json name already
+// used in the struct or in a struct that embeds it, and a new field name
+// that would change the encoded name of a field not named by its tags.
+//
+// The edits replace text at byte offsets, so that the rest of the files,
+// comments included, is preserved; diag.Apply applies them to a source.
+package rename
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/zeromicro/api-ast/ast"
+	"github.com/zeromicro/api-ast/diag"
+	"github.com/zeromicro/api-ast/loader"
+	"github.com/zeromicro/api-ast/tag"
+	"github.com/zeromicro/api-ast/token"
+	"github.com/zeromicro/api-ast/types"
+)
+
+// Type returns the edits renaming the type old to new.
+func Type(api *loader.API, old, new string) ([]diag.Edit, error) {
+	r, err := newRenamer(api)
+	if err != nil {
+		return nil, err
+	}
+	if r.info.Lookup(old) == nil {
+		return nil, fmt.Errorf("rename: type %s is not declared", old)
+	}
+	if !token.IsIdentifier(new) || types.Predeclared(new) {
+		return nil, fmt.Errorf("rename: invalid type name %q", new)
+	}
+	if s := r.info.Lookup(new); s != nil {
+		return nil, fmt.Errorf("rename: type %s is already declared at %s", new, api.Fset.Position(s.Name.Pos()))
+	}
+
+	for _, s := range r.info.Specs {
+		if s.Name.Name == old {
+			r.edit(s.Name.Pos(), old, new)
+		}
+		if st, ok := s.Type.(*ast.StructType); ok {
+			// an embedded field is named after its type
+			if f := fieldNamed(st, new); f != nil && embeds(st, old) {
+				return nil, fmt.Errorf("rename: embedded field %s would collide with field %s of %s at %s",
+					old, new, s.Name.Name, api.Fset.Position(f.Pos()))
+			}
+		}
+		ast.TypeRefs(s.Type, func(id *ast.Ident) {
+			if id.Name == old {
+				r.edit(id.Pos(), old, new)
+			}
+		})
+	}
+	for _, sr := range r.routes() {
+		for _, x := range []*ast.ParenExpr{sr.Route.Req, sr.Route.Resp} {
+			if x == nil {
+				continue
+			}
+			ast.TypeRefs(x, func(id *ast.Ident) {
+				if id.Name == old {
+					r.edit(id.Pos(), old, new)
+				}
+			})
+		}
+	}
+	return r.result(), nil
+}
+
+// Handler returns the edits renaming the handler old to new in every
+// service named service, or in all services if service is empty.
+func Handler(api *loader.API, service, old, new string) ([]diag.Edit, error) {
+	r, err := newRenamer(api)
+	if err != nil {
+		return nil, err
+	}
+	if !token.IsIdentifier(new) {
+		return nil, fmt.Errorf("rename: invalid handler name %q", new)
+	}
+	found := make(map[string]bool) // services with the handler old
+	for _, svc := range r.services() {
+		name := svc.ServiceApi.Name.Name
+		if service != "" && name != service {
+			continue
+		}
+		for _, sr := range svc.ServiceApi.ServiceRoute {
+			if sr.AtHandler != nil && sr.AtHandler.Text() == old {
+				found[name] = true
+			}
+		}
+	}
+	if len(found) == 0 {
+		return nil, fmt.Errorf("rename: handler %s is not declared", old)
+	}
+	if old == new {
+		return nil, nil
+	}
+	for _, svc := range r.services() {
+		if !found[svc.ServiceApi.Name.Name] {
+			continue
+		}
+		for _, sr := range svc.ServiceApi.ServiceRoute {
+			h := sr.AtHandler
+			if h == nil {
+				continue
+			}
+			switch h.Text() {
+			case new:
+				return nil, fmt.Errorf("rename: handler %s is already declared at %s", new, api.Fset.Position(h.Value.Pos()))
+			case old:
+				r.edit(h.Value.Pos(), old, new)
+			}
+		}
+	}
+	return r.result(), nil
+}
+
+// Field returns the edits renaming the field old of the struct type typ
+// to new. If json is not empty, the name in the json tag of the field is
+// renamed to json too, or a json tag is added if the field has none. A
+// field whose tags do not name it is encoded by its Go name, so renaming
+// it is refused unless json is given.
+func Field(api *loader.API, typ, old, new, json string) ([]diag.Edit, error) {
+	r, err := newRenamer(api)
+	if err != nil {
+		return nil, err
+	}
+	s := r.info.Lookup(typ)
+	if s == nil {
+		return nil, fmt.Errorf("rename: type %s is not declared", typ)
+	}
+	st, ok := s.Type.(*ast.StructType)
+	if !ok {
+		return nil, fmt.Errorf("rename: type %s is not a struct type", typ)
+	}
+	var field *ast.Field
+	var ident *ast.Ident
+	if st.Fields != nil {
+		for _, f := range st.Fields.List {
+			for _, n := range f.Names {
+				if n.Name == old {
+					field, ident = f, n
+				}
+			}
+		}
+	}
+	if ident == nil {
+		return nil, fmt.Errorf("rename: type %s has no field %s", typ, old)
+	}
+	if !token.IsIdentifier(new) {
+		return nil, fmt.Errorf("rename: invalid field name %q", new)
+	}
+	if old != new && json == "" {
+		fields, err := r.info.Fields(s.Name)
+		if err != nil {
+			return nil, fmt.Errorf("rename: %v", err)
+		}
+		for _, f := range fields {
+			if f.Field == field && f.Name == old && !namedByTag(f) {
+				return nil, fmt.Errorf("rename: field %s is encoded by its Go name; give a json name to keep its encoding", old)
+			}
+		}
+	}
+
+	// the new names must not collide in the struct or in any struct
+	// that embeds it, where the field is promoted
+	structs := []*ast.TypeSpec{s}
+	for _, e := range r.info.Specs {
+		if es, ok := e.Type.(*ast.StructType); ok && e != s && r.embedsDeep(es, typ, make(map[string]bool)) {
+			structs = append(structs, e)
+		}
+	}
+	for _, e := range structs {
+		fields, err := r.info.Fields(e.Name)
+		if err != nil {
+			return nil, fmt.Errorf("rename: %v", err)
+		}
+		for _, f := range fields {
+			if f.Field == field {
+				continue
+			}
+			if f.Name == new && old != new {
+				return nil, fmt.Errorf("rename: field %s would collide with field %s of %s at %s",
+					old, new, e.Name.Name, api.Fset.Position(f.Field.Pos()))
+			}
+			if t := f.Tags.Get(tag.JSON); json != "" && t != nil && t.Name == json {
+				return nil, fmt.Errorf("rename: json name %q is already used by field %s of %s at %s",
+					json, f.Name, e.Name.Name, api.Fset.Position(f.Field.Pos()))
+			}
+		}
+	}
+
+	if old != new {
+		r.edit(ident.Pos(), old, new)
+	}
+	if json != "" {
+		if err := r.jsonTag(field, json); err != nil {
+			return nil, err
+		}
+	}
+	return r.result(), nil
+}
+
+// A renamer collects the edits of a rename.
+type renamer struct {
+	api   *loader.API
+	info  *types.Info
+	edits []diag.Edit
+	seen  map[token.Pos]bool
+}
+
+func newRenamer(api *loader.API) (*renamer, error) {
+	info, err := types.NewInfo(api.Files...)
+	if err != nil {
+		return nil, fmt.Errorf("rename: %v", err)
+	}
+	return &renamer{api: api, info: info, seen: make(map[token.Pos]bool)}, nil
+}
+
+// edit records the replacement of the text old at pos by new.
+func (r *renamer) edit(pos token.Pos, old, new string) {
+	if r.seen[pos] || !pos.IsValid() {
+		return
+	}
+	r.seen[pos] = true
+	r.edits = append(r.edits, diag.Edit{
+		Span:    diag.SpanOf(r.api.Fset, pos, pos+token.Pos(len(old))),
+		OldText: old,
+		NewText: new,
+	})
+}
+
+// result returns the edits sorted by file and offset.
+func (r *renamer) result() []diag.Edit {
+	sort.Slice(r.edits, func(i, j int) bool {
+		a, b := r.edits[i].Span, r.edits[j].Span
+		if a.Filename != b.Filename {
+			return a.Filename < b.Filename
+		}
+		return a.Start.Offset < b.Start.Offset
+	})
+	return r.edits
+}
+
+// jsonTag records the edit renaming the json tag of field f to name, or
+// adding one if f has none.
+func (r *renamer) jsonTag(f *ast.Field, name string) error {
+	if f.Tag == nil {
+		r.edit(f.Type.End(), "", " `json:\""+name+"\"`")
+		return nil
+	}
+	lit := f.Tag.Value
+	i := strings.Index(lit, `json:"`)
+	if i < 0 || i > 0 && lit[i-1] != ' ' && lit[i-1] != '`' && lit[i-1] != '"' {
+		if !strings.HasPrefix(lit, "`") {
+			return fmt.Errorf("rename: field has no json tag")
+		}
+		r.edit(f.Tag.Pos()+1, "", `json:"`+name+`" `)
+		return nil
+	}
+	start := i + len(`json:"`)
+	end := start
+	for end < len(lit) && lit[end] != ',' && lit[end] != '"' {
+		end++
+	}
+	if old := lit[start:end]; old != name {
+		r.edit(f.Tag.Pos()+token.Pos(start), old, name)
+	}
+	return nil
+}
+
+// namedByTag reports whether the encoded name of f is given by its
+// tags, as opposed to derived from its Go name.
+func namedByTag(f *types.Field) bool {
+	for _, key := range []string{tag.JSON, tag.Form, tag.Path, tag.Header} {
+		if t := f.Tags.Get(key); t != nil {
+			return t.Name != ""
+		}
+	}
+	return false
+}
+
+// services returns the service declarations of all files.
+func (r *renamer) services() []*ast.Service {
+	var list []*ast.Service
+	for _, f := range r.api.Files {
+		list = append(list, f.Services()...)
+	}
+	return list
+}
+
+// routes returns the routes of all files.
+func (r *renamer) routes() []*ast.ServiceRoute {
+	var list []*ast.ServiceRoute
+	for _, svc := range r.services() {
+		for _, sr := range svc.ServiceApi.ServiceRoute {
+			if sr.Route != nil {
+				list = append(list, sr)
+			}
+		}
+	}
+	return list
+}
+
+// embedsDeep reports whether the struct st embeds the type name,
+// directly or through other embedded structs.
+func (r *renamer) embedsDeep(st *ast.StructType, name string, seen map[string]bool) bool {
+	if st.Fields == nil {
+		return false
+	}
+	for _, f := range st.Fields.List {
+		if len(f.Names) != 0 {
+			continue
+		}
+		e := embeddedName(f.Type)
+		if e == name {
+			return true
+		}
+		if s := r.info.Lookup(e); s != nil && !seen[e] {
+			seen[e] = true
+			if es, ok := s.Type.(*ast.StructType); ok && r.embedsDeep(es, name, seen) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// embeds reports whether the struct st embeds the type name directly.
+func embeds(st *ast.StructType, name string) bool {
+	if st.Fields == nil {
+		return false
+	}
+	for _, f := range st.Fields.List {
+		if len(f.Names) == 0 && embeddedName(f.Type) == name {
+			return true
+		}
+	}
+	return false
+}
+
+// fieldNamed returns the field of st named name, or nil.
+func fieldNamed(st *ast.StructType, name string) *ast.Field {
+	if st.Fields == nil {
+		return nil
+	}
+	for _, f := range st.Fields.List {
+		for _, n := range f.Names {
+			if n.Name == name {
+				return f
+			}
+		}
+		if len(f.Names) == 0 && embeddedName(f.Type) == name {
+			return f
+		}
+	}
+	return nil
+}
+
+// embeddedName returns the field name of an embedded field of type x.
+func embeddedName(x ast.Expr) string {
+	switch t := x.(type) {
+	case *ast.Ident:
+		return t.Name
+	case *ast.StarExpr:
+		return embeddedName(t.X)
+	case *ast.SelectorExpr:
+		return t.Sel.Name
+	}
+	return ""
+}
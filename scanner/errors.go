@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"go/scanner"
 	"io"
+
+	"github.com/zeromicro/api-ast/diag"
 )
 
 type (
@@ -16,13 +18,13 @@ func PrintError(w io.Writer, err error) {
 	scanner.PrintError(w, err)
 }
 
-func (s *Scanner) error(offs int, msg string) {
+func (s *Scanner) error(offs int, code diag.Code, msg string) {
 	if s.err != nil {
-		s.err(s.file.Position(s.file.Pos(offs)), msg)
+		s.err(s.file.Position(s.file.Pos(offs)), code, msg)
 	}
 	s.ErrorCount++
 }
 
-func (s *Scanner) errorf(offs int, format string, args ...interface{}) {
-	s.error(offs, fmt.Sprintf(format, args...))
+func (s *Scanner) errorf(offs int, code diag.Code, format string, args ...interface{}) {
+	s.error(offs, code, fmt.Sprintf(format, args...))
 }
@@ -62,17 +62,10 @@ func ExampleScanner_Scan_quo() {
 	}
 
 	// output:
-	// 1:1	IDENT	"cos"
-	// 1:4	(	""
-	// 1:5	IDENT	"x"
-	// 1:6	)	""
-	// 1:8	+	""
-	// 1:10	IMAG	"1i"
-	// 1:12	*	""
-	// 1:13	IDENT	"sin"
-	// 1:16	(	""
-	// 1:17	IDENT	"x"
-	// 1:18	)	""
-	// 1:20	;	"\n"
-	// 1:20	COMMENT	"// Euler"
+	// 1:1	/	""
+	// 1:2	IDENT	"path"
+	// 1:6	/	""
+	// 1:7	:	""
+	// 1:8	IDENT	"name"
+	// 1:12	;	"\n"
 }
@@ -18,6 +18,12 @@ func isDigit(ch rune) bool {
 	return isDecimal(ch) || ch >= utf8.RuneSelf && unicode.IsDigit(ch)
 }
 
+// isJoiner reports whether ch joins the tokens of a path or name, such
+// as "/users/:id" or "user-api", when there is no white space around it.
+func isJoiner(ch byte) bool {
+	return ch == '/' || ch == ':' || ch == '-' || ch == '.'
+}
+
 func litname(prefix rune) string {
 	switch prefix {
 	case 'x':
@@ -3,6 +3,7 @@ package scanner
 import (
 	"bytes"
 	"fmt"
+	"github.com/zeromicro/api-ast/diag"
 	"github.com/zeromicro/api-ast/token"
 	"path/filepath"
 	"strconv"
@@ -22,7 +23,7 @@ func (s *Scanner) scanIdentifier() string {
 	offs := s.offset
 
 	for rdOffset, b := range s.src[s.rdOffset:] {
-		if 'a' <= b && b <= 'z' || 'A' <= b && 'Z' <= b || b == '_' || '0' <= b && b <= '9' {
+		if 'a' <= b && b <= 'z' || 'A' <= b && b <= 'Z' || b == '_' || '0' <= b && b <= '9' {
 			continue
 		}
 
@@ -86,23 +87,23 @@ func (s *Scanner) scanNumber() (token.Token, string) {
 	if s.ch == '.' {
 		tok = token.FLOAT
 		if prefix == '0' || prefix == 'b' {
-			s.error(s.offset, "invalid radix point in "+litname(prefix))
+			s.error(s.offset, diag.InvalidNumber, "invalid radix point in "+litname(prefix))
 		}
 		s.next()
 		digsep |= s.digits(base, &invalid)
 	}
 
 	if digsep&1 == 0 {
-		s.error(s.offset, litname(prefix)+"has no digits")
+		s.error(s.offset, diag.InvalidNumber, litname(prefix)+"has no digits")
 	}
 
 	// exponent
 	if e := lower(s.ch); e == 'e' || e == 'p' {
 		switch {
 		case e == 'e' && prefix != 0 && prefix != '0':
-			s.errorf(s.offset, "%q exponent requires decimal mantissa", s.ch)
+			s.errorf(s.offset, diag.InvalidNumber, "%q exponent requires decimal mantissa", s.ch)
 		case e == 'p' && prefix != 'x':
-			s.errorf(s.offset, "%q exponent requires hexadecimal mantissa", s.ch)
+			s.errorf(s.offset, diag.InvalidNumber, "%q exponent requires hexadecimal mantissa", s.ch)
 		}
 		s.next()
 		tok = token.FLOAT
@@ -112,10 +113,10 @@ func (s *Scanner) scanNumber() (token.Token, string) {
 		ds := s.digits(10, nil)
 		digsep |= ds
 		if ds&1 == 0 {
-			s.error(s.offset, "exponent has no digits")
+			s.error(s.offset, diag.InvalidNumber, "exponent has no digits")
 		}
 	} else if prefix == 'x' && tok == token.FLOAT {
-		s.error(s.offset, "hexadecimal mantissa requires a 'p' exponent")
+		s.error(s.offset, diag.InvalidNumber, "hexadecimal mantissa requires a 'p' exponent")
 	}
 
 	if s.ch == 'i' {
@@ -125,11 +126,11 @@ func (s *Scanner) scanNumber() (token.Token, string) {
 
 	lit := string(s.src[offs:s.offset])
 	if tok == token.INT && invalid >= 0 {
-		s.errorf(invalid, "invalid digit %q in %s", lit[invalid-offs], litname(prefix))
+		s.errorf(invalid, diag.InvalidNumber, "invalid digit %q in %s", lit[invalid-offs], litname(prefix))
 	}
 	if digsep&2 != 0 {
 		if i := invalidSep(lit); i >= 0 {
-			s.error(offs+i, "'_' mus separate successive digits")
+			s.error(offs+i, diag.InvalidNumber, "'_' mus separate successive digits")
 		}
 	}
 
@@ -172,7 +173,7 @@ func (s *Scanner) scanString() string {
 	for {
 		ch := s.ch
 		if ch == '\n' || ch < 0 {
-			s.error(offs, "string literal not terminated")
+			s.error(offs, diag.NotTerminated, "string literal not terminated")
 			break
 		}
 		s.next()
@@ -215,7 +216,7 @@ func (s *Scanner) scanEscape(quote rune) bool {
 		if s.ch < 0 {
 			msg = "escape sequence not terminated"
 		}
-		s.error(offs, msg)
+		s.error(offs, diag.InvalidEscape, msg)
 		return false
 	}
 
@@ -227,7 +228,7 @@ func (s *Scanner) scanEscape(quote rune) bool {
 			if s.ch < 0 {
 				msg = "escape sequence not terminated"
 			}
-			s.error(s.offset, msg)
+			s.error(s.offset, diag.InvalidEscape, msg)
 			return false
 		}
 		x = x*base + d
@@ -236,7 +237,7 @@ func (s *Scanner) scanEscape(quote rune) bool {
 	}
 
 	if x > max || 0xD800 <= x && x < 0xE000 {
-		s.error(offs, "escape sequence is invalid Unicode code point")
+		s.error(offs, diag.InvalidEscape, "escape sequence is invalid Unicode code point")
 		return false
 	}
 
@@ -256,7 +257,7 @@ func (s *Scanner) scanRune() string {
 		if ch == '\n' || ch < 0 {
 			// only report error if we don't have one already
 			if valid {
-				s.error(offs, "rune literal not terminated")
+				s.error(offs, diag.NotTerminated, "rune literal not terminated")
 				valid = false
 			}
 			break
@@ -275,7 +276,7 @@ func (s *Scanner) scanRune() string {
 	}
 
 	if valid && n != 1 {
-		s.error(offs, "illegal rune literal")
+		s.error(offs, diag.InvalidEscape, "illegal rune literal")
 	}
 
 	return string(s.src[offs:s.offset])
@@ -289,7 +290,7 @@ func (s *Scanner) scanRawString() string {
 	for {
 		ch := s.ch
 		if ch < 0 {
-			s.error(offs, "raw string literal not terminated")
+			s.error(offs, diag.NotTerminated, "raw string literal not terminated")
 			break
 		}
 		s.next()
@@ -454,7 +455,7 @@ func (s *Scanner) scanComment() string {
 		}
 	}
 
-	s.error(offs, "comment not terminated")
+	s.error(offs, diag.NotTerminated, "comment not terminated")
 
 exit:
 	lit := s.src[offs:s.offset]
@@ -501,7 +502,7 @@ func (s *Scanner) updateLineInfo(next, offs int, text []byte) {
 
 	if !ok {
 		// text has a suffix :xxx but xxx is not a number
-		s.error(offs+i, "invalid line number: "+string(text[i:]))
+		s.error(offs+i, diag.InvalidLineDirective, "invalid line number: "+string(text[i:]))
 		return
 	}
 
@@ -512,7 +513,7 @@ func (s *Scanner) updateLineInfo(next, offs int, text []byte) {
 		i, i2 = i2, i
 		line, col = n2, n
 		if col == 0 {
-			s.error(offs+i2, "invalid column number: "+string(text[i2:]))
+			s.error(offs+i2, diag.InvalidLineDirective, "invalid column number: "+string(text[i2:]))
 			return
 		}
 		text = text[:i2-1] // lop off ":col"
@@ -522,7 +523,7 @@ func (s *Scanner) updateLineInfo(next, offs int, text []byte) {
 	}
 
 	if line == 0 {
-		s.error(offs+i, "invalid line number: "+string(text[i:]))
+		s.error(offs+i, diag.InvalidLineDirective, "invalid line number: "+string(text[i:]))
 		return
 	}
 
@@ -2,6 +2,7 @@ package scanner
 
 import (
 	"fmt"
+	"github.com/zeromicro/api-ast/diag"
 	"github.com/zeromicro/api-ast/token"
 	"path/filepath"
 	"unicode/utf8"
@@ -24,10 +25,11 @@ const (
 
 // An ErrorHandler may be provided to Scanner.Init. If a syntax error is
 // encountered and a handler was installed, the handler is called with a
-// position and an error message. The position points to the beginning of
-// the offending token.
+// position, the diagnostic code of the error, such as diag.NotTerminated,
+// and an error message. The position points to the beginning of the
+// offending token.
 //
-type ErrorHandler func(pos token.Position, msg string)
+type ErrorHandler func(pos token.Position, code diag.Code, msg string)
 
 type Scanner struct {
 	// immutable state
@@ -71,6 +73,22 @@ func (s *Scanner) Init(file *token.File, src []byte, err ErrorHandler, mode Mode
 	}
 }
 
+// Seek positions s at offset, which must be the start of a line, as if
+// it had just scanned the preceding newline. The next token is the first
+// one at or after offset. Seek is used to rescan a part of a file.
+//
+func (s *Scanner) Seek(offset int) {
+	if offset < 0 || offset > len(s.src) {
+		panic(fmt.Sprintf("offset (%d) out of range [0, %d]", offset, len(s.src)))
+	}
+	s.ch = ' '
+	s.offset = offset
+	s.rdOffset = offset
+	s.lineOffset = offset
+	s.insertSemi = false
+	s.next()
+}
+
 const (
 	bom = 0xFEFF // byte order mark, only permitted as very first character
 	eof = -1     // end of file
@@ -86,14 +104,14 @@ func (s *Scanner) next() {
 		r, w := rune(s.src[s.rdOffset]), 1
 		switch {
 		case r == 0:
-			s.error(s.offset, "illegal character NULL")
+			s.error(s.offset, diag.IllegalCharacter, "illegal character NULL")
 		case r >= utf8.RuneSelf:
 			// not ASCII
 			r, w = utf8.DecodeRune(s.src[s.rdOffset:])
 			if r == utf8.RuneError && w == 1 {
-				s.error(s.offset, "illegal UTF-8 encoding")
+				s.error(s.offset, diag.IllegalCharacter, "illegal UTF-8 encoding")
 			} else if r == bom && s.offset > 0 {
-				s.error(s.offset, "illegal byte order mark")
+				s.error(s.offset, diag.IllegalCharacter, "illegal byte order mark")
 			}
 		}
 		s.rdOffset += w
@@ -126,17 +144,16 @@ scanAgain:
 	insertSemi := false
 	switch ch := s.ch; {
 	case isLetter(ch):
+		joined := s.offset > 0 && isJoiner(s.src[s.offset-1])
 		lit = s.scanIdentifier()
 		if len(lit) > 1 {
 			tok = token.Lookup(lit)
-			switch tok {
-			case token.IDENT:
-				insertSemi = true
-			}
 		} else {
-			insertSemi = true
 			tok = token.IDENT
 		}
+		// a keyword that is part of a path or name, such as info in
+		// "get /user/info" or service in "user-service", may end a line
+		insertSemi = tok == token.IDENT || joined
 	case isDecimal(ch) || ch == '.' && isDecimal(rune(s.peek())):
 		insertSemi = true
 		tok, lit = s.scanNumber()
@@ -145,14 +162,10 @@ scanAgain:
 		lit = "@" + s.scanIdentifier()
 		if len(lit) > 1 {
 			tok = token.Lookup(lit)
-			switch tok {
-			case token.IDENT:
-				insertSemi = true
-			}
 		} else {
-			insertSemi = true
 			tok = token.IDENT
 		}
+		insertSemi = tok == token.IDENT
 	default:
 		s.next()
 		switch ch {
@@ -272,7 +285,7 @@ scanAgain:
 		default:
 			// next reports unexpected BOMs - don't repeat
 			if ch != bom {
-				s.errorf(s.file.Offset(pos), "illegal character %#U", ch)
+				s.errorf(s.file.Offset(pos), diag.IllegalCharacter, "illegal character %#U", ch)
 			}
 			insertSemi = s.insertSemi // preserve insertSemi info
 			tok = token.ILLEGAL
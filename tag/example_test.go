@@ -0,0 +1,56 @@
+package tag_test
+
+import (
+	"fmt"
+
+	"github.com/zeromicro/api-ast/tag"
+)
+
+func ExampleParse() {
+	tags, err := tag.Parse(`json:"page,optional, default=1" form:"page"`)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	for _, t := range tags {
+		fmt.Printf("%s %q %q\n", t.Key, t.Name, t.Options)
+	}
+	t := tags.Get(tag.JSON)
+	fmt.Println(t.Option("default"))
+	fmt.Println(t.Option("range"))
+	fmt.Println(tags.Optional())
+
+	_, err = tag.Parse(`json:name`)
+	fmt.Println(err)
+
+	// output:
+	// json "page" ["optional" "default=1"]
+	// form "page" []
+	// 1 true
+	//  false
+	// true
+	// bad syntax for struct tag pair "json:name"
+}
+
+func ExampleTags_Range() {
+	tags, err := tag.Parse(`json:"age,range=(0:130],default=18" form:"age,options=18|21"`)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(tags.Default())
+	fmt.Println(tags.Enum())
+	r, ok := tags.Range()
+	fmt.Printf("%+v %v\n", r, ok)
+	fmt.Println(r.Contains(0), r.Contains(130))
+
+	_, err = tag.ParseRange("[1:ten]")
+	fmt.Println(err)
+
+	// output:
+	// 18 true
+	// [18 21] true
+	// {Min:0 Max:130 HasMin:true HasMax:true MinExcl:true MaxExcl:false} true
+	// false true
+	// tag: bad range "[1:ten]"
+}
@@ -0,0 +1,238 @@
+// Package tag parses the struct tags of api type fields.
+//
+// A tag is a list of space-separated key:"value" pairs as in Go. The
+// value is a comma-separated list whose first element is a name and
+// whose remaining elements are options, such as in
+//
+//	`json:"name,optional" form:"page,default=1"`
+//
+// The go-zero options default, options and range are parsed by Default,
+// Enum and Range.
+package tag
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/zeromicro/api-ast/ast"
+)
+
+// Well-known tag keys. They determine where a field of a request type
+// is taken from.
+const (
+	JSON   = "json"   // request or response body
+	Form   = "form"   // query string or form body
+	Path   = "path"   // path parameter
+	Header = "header" // request header
+)
+
+// A Tag is a single key:"value" pair of a struct tag.
+type Tag struct {
+	Key     string   // such as "json"
+	Name    string   // first element of the value; may be empty
+	Options []string // remaining elements, such as "optional" or "default=1"
+}
+
+// Option returns the value of the option name, which is the text after
+// "name=", or "" for a bare option. The result ok reports whether the
+// option is present.
+func (t *Tag) Option(name string) (value string, ok bool) {
+	for _, opt := range t.Options {
+		if opt == name {
+			return "", true
+		}
+		if strings.HasPrefix(opt, name) && len(opt) > len(name) && opt[len(name)] == '=' {
+			return opt[len(name)+1:], true
+		}
+	}
+	return "", false
+}
+
+// HasOption reports whether the option name is present.
+func (t *Tag) HasOption(name string) bool {
+	_, ok := t.Option(name)
+	return ok
+}
+
+// Enum returns the values of the option "options", such as a, b and c
+// for "options=a|b|c". The result ok reports whether the option is
+// present.
+func (t *Tag) Enum() (values []string, ok bool) {
+	v, ok := t.Option("options")
+	if !ok {
+		return nil, false
+	}
+	return strings.Split(v, "|"), true
+}
+
+// Range returns the bounds of the option "range", such as "[1:10)". The
+// result ok reports whether the option is present and well-formed.
+func (t *Tag) Range() (r Range, ok bool) {
+	v, ok := t.Option("range")
+	if !ok {
+		return Range{}, false
+	}
+	r, err := ParseRange(v)
+	return r, err == nil
+}
+
+// A Range is the interval of numbers allowed by a go-zero range option.
+// Either bound may be absent, as in "[1:]".
+type Range struct {
+	Min, Max         float64
+	HasMin, HasMax   bool
+	MinExcl, MaxExcl bool // the bound is exclusive: "(" or ")"
+}
+
+// ParseRange parses a go-zero range, such as "[1:10)" or "(0:]".
+func ParseRange(s string) (Range, error) {
+	var r Range
+	if len(s) < 3 || (s[0] != '[' && s[0] != '(') || (s[len(s)-1] != ']' && s[len(s)-1] != ')') {
+		return r, fmt.Errorf("tag: bad range %q", s)
+	}
+	bounds := strings.SplitN(s[1:len(s)-1], ":", 2)
+	if len(bounds) != 2 {
+		return r, fmt.Errorf("tag: bad range %q", s)
+	}
+	r.MinExcl, r.MaxExcl = s[0] == '(', s[len(s)-1] == ')'
+	var err error
+	if lo := strings.TrimSpace(bounds[0]); lo != "" {
+		if r.Min, err = strconv.ParseFloat(lo, 64); err != nil {
+			return Range{}, fmt.Errorf("tag: bad range %q", s)
+		}
+		r.HasMin = true
+	}
+	if hi := strings.TrimSpace(bounds[1]); hi != "" {
+		if r.Max, err = strconv.ParseFloat(hi, 64); err != nil {
+			return Range{}, fmt.Errorf("tag: bad range %q", s)
+		}
+		r.HasMax = true
+	}
+	return r, nil
+}
+
+// Contains reports whether n lies within r.
+func (r Range) Contains(n float64) bool {
+	if r.HasMin && (n < r.Min || r.MinExcl && n == r.Min) {
+		return false
+	}
+	if r.HasMax && (n > r.Max || r.MaxExcl && n == r.Max) {
+		return false
+	}
+	return true
+}
+
+// Tags is a parsed struct tag, in source order.
+type Tags []*Tag
+
+// Get returns the tag with the given key, or nil.
+func (tags Tags) Get(key string) *Tag {
+	for _, t := range tags {
+		if t.Key == key {
+			return t
+		}
+	}
+	return nil
+}
+
+// Optional reports whether a field with these tags may be omitted, that
+// is, whether one of its tags has the option "optional", "omitempty" or
+// "default".
+func (tags Tags) Optional() bool {
+	for _, t := range tags {
+		if t.HasOption("optional") || t.HasOption("omitempty") || t.HasOption("default") {
+			return true
+		}
+	}
+	return false
+}
+
+// Default returns the value of the first option "default" of the tags.
+// The result ok reports whether there is one.
+func (tags Tags) Default() (value string, ok bool) {
+	for _, t := range tags {
+		if v, ok := t.Option("default"); ok {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// Enum returns the values of the first option "options" of the tags;
+// see Tag.Enum.
+func (tags Tags) Enum() (values []string, ok bool) {
+	for _, t := range tags {
+		if v, ok := t.Enum(); ok {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+// Range returns the first well-formed option "range" of the tags; see
+// Tag.Range.
+func (tags Tags) Range() (r Range, ok bool) {
+	for _, t := range tags {
+		if r, ok := t.Range(); ok {
+			return r, true
+		}
+	}
+	return Range{}, false
+}
+
+// Parse parses the unquoted struct tag s.
+func Parse(s string) (Tags, error) {
+	var tags Tags
+	for {
+		s = strings.TrimLeft(s, " \t")
+		if s == "" {
+			return tags, nil
+		}
+
+		i := 0
+		for i < len(s) && s[i] > ' ' && s[i] != ':' && s[i] != '"' && s[i] != 0x7f {
+			i++
+		}
+		if i == 0 || i+1 >= len(s) || s[i] != ':' || s[i+1] != '"' {
+			return tags, fmt.Errorf("bad syntax for struct tag pair %q", s)
+		}
+		key := s[:i]
+		s = s[i+1:]
+
+		// scan quoted string to find value
+		i = 1
+		for i < len(s) && s[i] != '"' {
+			if s[i] == '\\' {
+				i++
+			}
+			i++
+		}
+		if i >= len(s) {
+			return tags, fmt.Errorf("bad syntax for struct tag value %q", s)
+		}
+		value, err := strconv.Unquote(s[:i+1])
+		if err != nil {
+			return tags, fmt.Errorf("bad syntax for struct tag value %q", s[:i+1])
+		}
+		s = s[i+1:]
+
+		elems := strings.Split(value, ",")
+		for j := range elems {
+			elems[j] = strings.TrimSpace(elems[j])
+		}
+		tags = append(tags, &Tag{Key: key, Name: elems[0], Options: elems[1:]})
+	}
+}
+
+// Field returns the parsed tags of field f, which may be nil.
+func Field(f *ast.Field) (Tags, error) {
+	if f == nil || f.Tag == nil {
+		return nil, nil
+	}
+	s, err := strconv.Unquote(f.Tag.Value)
+	if err != nil {
+		return nil, fmt.Errorf("bad struct tag %s", f.Tag.Value)
+	}
+	return Parse(s)
+}
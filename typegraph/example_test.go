@@ -0,0 +1,65 @@
+package typegraph_test
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/zeromicro/api-ast/parser"
+	"github.com/zeromicro/api-ast/token"
+	"github.com/zeromicro/api-ast/typegraph"
+)
+
+func ExampleBuild() {
+	const src = `
+type Node {
+	Name     string
+	Children []*Node
+}
+
+type TreeReply {
+	Root Node
+}
+
+type LegacyReply {
+	Items []Node
+}
+
+service tree-api {
+	@handler getTree
+	get /tree returns (TreeReply)
+}
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "tree.api", src, 0)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	g, err := typegraph.Build(fset, f)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	for _, t := range g.Unused() {
+		fmt.Printf("%s: %s is unused\n", t.Position, t.Name)
+	}
+	fmt.Println("cycles:", g.Cycles)
+	os.Stdout.Write(g.DOT())
+
+	// Output:
+	// tree.api:11:6: LegacyReply is unused
+	// cycles: [[Node]]
+	// digraph api {
+	// 	rankdir=LR;
+	// 	node [shape=box];
+	// 	r0 [label="GET /tree", shape=ellipse];
+	// 	"Node";
+	// 	"TreeReply";
+	// 	"LegacyReply" [style=dashed, color=gray, fontcolor=gray];
+	// 	r0 -> "TreeReply" [label=response];
+	// 	"Node" -> "Node" [label="Children"];
+	// 	"TreeReply" -> "Node" [label="Root"];
+	// 	"LegacyReply" -> "Node" [label="Items"];
+	// }
+}
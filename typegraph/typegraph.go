@@ -0,0 +1,309 @@
+// Package typegraph builds the graph of the dependencies between the
+// types and routes of an API.
+//
+// A declared type depends on every declared type its definition refers
+// to: the types of its fields, including those of inline structs, its
+// embedded types, and the element, key and value types of arrays, maps
+// and pointers. A route depends on its request and response types. A
+// type is used if a route depends on it, directly or through other
+// types; the remaining types are unused and are candidates for removal.
+//
+// The graph can be exported as JSON or in the DOT language of Graphviz,
+// where unused types are drawn dashed.
+package typegraph
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/zeromicro/api-ast/ast"
+	"github.com/zeromicro/api-ast/token"
+	"github.com/zeromicro/api-ast/types"
+)
+
+// A Graph is the dependency graph of the types and routes of an API.
+type Graph struct {
+	Types  []*Type    `json:"types"`  // declared types, in source order
+	Routes []*Route   `json:"routes"` // routes, in source order
+	Cycles [][]string `json:"cycles"` // recursive types, each cycle sorted by name
+
+	byName map[string]*Type
+}
+
+// A Type is a declared type.
+type Type struct {
+	Name     string   `json:"name"`
+	Position string   `json:"position"`
+	Refs     []*Ref   `json:"refs"`   // the types it depends on
+	Routes   []string `json:"routes"` // the routes that depend on it directly
+	Used     bool     `json:"used"`   // set if a route depends on it, directly or indirectly
+}
+
+// A Ref is a dependency of a type on another.
+type Ref struct {
+	Type  string `json:"type"`            // name of the referenced type
+	Kind  string `json:"kind"`            // "field", "embedded" or "type" for the definition itself
+	Field string `json:"field,omitempty"` // name of the field, such as "Address.City" for an inline struct
+}
+
+// A Route is a route and the types it depends on.
+type Route struct {
+	Method   string   `json:"method"`
+	Path     string   `json:"path"` // full path, including the prefix
+	Handler  string   `json:"handler"`
+	Position string   `json:"position"`
+	Request  []string `json:"request"`  // declared types of the request
+	Response []string `json:"response"` // declared types of the response
+}
+
+// String returns the method and path of r.
+func (r *Route) String() string { return r.Method + " " + r.Path }
+
+// Build returns the dependency graph of the declarations of files. The
+// positions are taken from fset.
+func Build(fset *token.FileSet, files ...*ast.File) (*Graph, error) {
+	info, err := types.NewInfo(files...)
+	if err != nil {
+		return nil, fmt.Errorf("typegraph: %v", err)
+	}
+	g := &Graph{
+		Types:  []*Type{},
+		Routes: []*Route{},
+		Cycles: [][]string{},
+		byName: make(map[string]*Type),
+	}
+	for _, s := range info.Specs {
+		t := &Type{Name: s.Name.Name, Position: fset.Position(s.Name.Pos()).String(), Refs: []*Ref{}, Routes: []string{}}
+		g.Types = append(g.Types, t)
+		g.byName[t.Name] = t
+	}
+	for _, s := range info.Specs {
+		t := g.byName[s.Name.Name]
+		seen := make(map[Ref]bool)
+		add := func(name, kind, field string) {
+			r := Ref{Type: name, Kind: kind, Field: field}
+			if g.byName[name] != nil && !seen[r] {
+				seen[r] = true
+				t.Refs = append(t.Refs, &r)
+			}
+		}
+		typeNames(s.Type, func(name string) { add(name, "type", "") })
+		if st := ast.InlineStruct(s.Type); st != nil {
+			fieldRefs(st, "", add)
+		}
+	}
+
+	for _, rt := range types.Routes(files...) {
+		r := &Route{
+			Method:   rt.Method,
+			Path:     rt.Path,
+			Handler:  rt.Handler,
+			Position: fset.Position(rt.Decl.Route.Method.Pos()).String(),
+			Request:  []string{},
+			Response: []string{},
+		}
+		if rt.Req != nil {
+			r.Request = g.declared(rt.Req)
+		}
+		if rt.Resp != nil {
+			r.Response = g.declared(rt.Resp)
+		}
+		for _, name := range append(append([]string{}, r.Request...), r.Response...) {
+			t := g.byName[name]
+			if n := len(t.Routes); n == 0 || t.Routes[n-1] != r.String() {
+				t.Routes = append(t.Routes, r.String())
+			}
+			g.mark(t)
+		}
+		g.Routes = append(g.Routes, r)
+	}
+	g.cycles()
+	return g, nil
+}
+
+// fieldRefs calls add for every declared type the fields of the struct
+// st refer to; prefix is the field path of st.
+func fieldRefs(st *ast.StructType, prefix string, add func(name, kind, field string)) {
+	if st.Fields == nil {
+		return
+	}
+	for _, f := range st.Fields.List {
+		if len(f.Names) == 0 {
+			typeNames(f.Type, func(name string) { add(name, "embedded", prefix+name) })
+			continue
+		}
+		for _, n := range f.Names {
+			field := prefix + n.Name
+			typeNames(f.Type, func(name string) { add(name, "field", field) })
+			if inner := ast.InlineStruct(f.Type); inner != nil {
+				fieldRefs(inner, field+".", add)
+			}
+		}
+	}
+}
+
+// typeNames calls fn for every type name in the type expression x,
+// except those inside inline struct types.
+func typeNames(x ast.Expr, fn func(name string)) {
+	switch t := x.(type) {
+	case *ast.Ident:
+		fn(t.Name)
+	case *ast.StarExpr:
+		typeNames(t.X, fn)
+	case *ast.ParenExpr:
+		typeNames(t.X, fn)
+	case *ast.ArrayType:
+		typeNames(t.Elt, fn)
+	case *ast.MapType:
+		typeNames(t.Key, fn)
+		typeNames(t.Value, fn)
+	}
+}
+
+// declared returns the names of the declared types that the type x of a
+// request or response refers to directly, including through the fields
+// of inline structs.
+func (g *Graph) declared(x ast.Expr) []string {
+	list := []string{}
+	seen := make(map[string]bool)
+	add := func(name, _, _ string) {
+		if g.byName[name] != nil && !seen[name] {
+			seen[name] = true
+			list = append(list, name)
+		}
+	}
+	typeNames(x, func(name string) { add(name, "", "") })
+	if st := ast.InlineStruct(x); st != nil {
+		fieldRefs(st, "", add)
+	}
+	return list
+}
+
+// mark marks t and the types it depends on as used.
+func (g *Graph) mark(t *Type) {
+	if t.Used {
+		return
+	}
+	t.Used = true
+	for _, r := range t.Refs {
+		g.mark(g.byName[r.Type])
+	}
+}
+
+// Unused returns the types that no route depends on, in source order.
+func (g *Graph) Unused() []*Type {
+	var list []*Type
+	for _, t := range g.Types {
+		if !t.Used {
+			list = append(list, t)
+		}
+	}
+	return list
+}
+
+// cycles computes the recursive types: the strongly connected components
+// of the type graph with more than one type, or with a type that refers
+// to itself.
+func (g *Graph) cycles() {
+	// Tarjan's algorithm
+	index := make(map[*Type]int)
+	low := make(map[*Type]int)
+	onStack := make(map[*Type]bool)
+	var stack []*Type
+	var visit func(t *Type)
+	visit = func(t *Type) {
+		index[t] = len(index)
+		low[t] = index[t]
+		stack = append(stack, t)
+		onStack[t] = true
+		self := false
+		for _, r := range t.Refs {
+			u := g.byName[r.Type]
+			self = self || u == t
+			if _, ok := index[u]; !ok {
+				visit(u)
+				if low[u] < low[t] {
+					low[t] = low[u]
+				}
+			} else if onStack[u] && index[u] < low[t] {
+				low[t] = index[u]
+			}
+		}
+		if low[t] != index[t] {
+			return
+		}
+		var scc []string
+		for {
+			u := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[u] = false
+			scc = append(scc, u.Name)
+			if u == t {
+				break
+			}
+		}
+		if len(scc) > 1 || self {
+			sort.Strings(scc)
+			g.Cycles = append(g.Cycles, scc)
+		}
+	}
+	for _, t := range g.Types {
+		if _, ok := index[t]; !ok {
+			visit(t)
+		}
+	}
+	sort.Slice(g.Cycles, func(i, j int) bool { return g.Cycles[i][0] < g.Cycles[j][0] })
+}
+
+// JSON returns the indented JSON encoding of g.
+func (g *Graph) JSON() ([]byte, error) {
+	data, err := json.MarshalIndent(g, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// DOT returns g in the DOT language. Routes are ellipses, types are
+// boxes, and unused types are dashed; edges are labeled with the field
+// or the part of the route that causes the dependency.
+func (g *Graph) DOT() []byte {
+	var buf bytes.Buffer
+	buf.WriteString("digraph api {\n\trankdir=LR;\n\tnode [shape=box];\n")
+	for i, r := range g.Routes {
+		fmt.Fprintf(&buf, "\tr%d [label=%s, shape=ellipse];\n", i, strconv.Quote(r.String()))
+	}
+	for _, t := range g.Types {
+		if t.Used {
+			fmt.Fprintf(&buf, "\t%s;\n", strconv.Quote(t.Name))
+		} else {
+			fmt.Fprintf(&buf, "\t%s [style=dashed, color=gray, fontcolor=gray];\n", strconv.Quote(t.Name))
+		}
+	}
+	for i, r := range g.Routes {
+		for _, name := range r.Request {
+			fmt.Fprintf(&buf, "\tr%d -> %s [label=request];\n", i, strconv.Quote(name))
+		}
+		for _, name := range r.Response {
+			fmt.Fprintf(&buf, "\tr%d -> %s [label=response];\n", i, strconv.Quote(name))
+		}
+	}
+	for _, t := range g.Types {
+		for _, r := range t.Refs {
+			label := r.Field
+			if r.Kind == "embedded" {
+				label = "embedded"
+			}
+			if label != "" {
+				fmt.Fprintf(&buf, "\t%s -> %s [label=%s];\n", strconv.Quote(t.Name), strconv.Quote(r.Type), strconv.Quote(label))
+			} else {
+				fmt.Fprintf(&buf, "\t%s -> %s;\n", strconv.Quote(t.Name), strconv.Quote(r.Type))
+			}
+		}
+	}
+	buf.WriteString("}\n")
+	return buf.Bytes()
+}
@@ -0,0 +1,170 @@
+package types_test
+
+import (
+	"fmt"
+
+	"github.com/zeromicro/api-ast/parser"
+	"github.com/zeromicro/api-ast/token"
+	"github.com/zeromicro/api-ast/types"
+)
+
+func ExampleInfo_Fields() {
+	fset := token.NewFileSet() // positions are relative to fset
+
+	src := `syntax = "v1"
+
+type Base {
+	Id   int64
+	Name string
+}
+
+type Audit {
+	Id      int64
+	Created string
+}
+
+type User {
+	Base
+	Audit
+	Name string
+}
+`
+
+	f, err := parser.ParseFile(fset, "user.api", src, 0)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	info, err := types.NewInfo(f)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	// User.Name shadows Base.Name, and Base.Id and Audit.Id are
+	// ambiguous, so neither is a field of User.
+	fields, err := info.Fields(info.Lookup("User").Name)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	for _, fld := range fields {
+		fmt.Println(fld.Name, fset.Position(fld.Field.Pos()).Line)
+	}
+
+	// output:
+	// Created 10
+	// Name 16
+}
+
+func ExampleInfo_Indirect() {
+	fset := token.NewFileSet() // positions are relative to fset
+
+	src := `syntax = "v1"
+
+type ID int64
+
+type Ref *ID
+
+type User {
+	Id ID
+}
+`
+
+	f, err := parser.ParseFile(fset, "user.api", src, 0)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	info, err := types.NewInfo(f)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	// The encoding of Ref is that of its element, an int64; User is
+	// not a type name once its definition is substituted.
+	for _, name := range []string{"ID", "Ref", "User"} {
+		x := info.Indirect(info.Lookup(name).Name)
+		fmt.Printf("%s %q\n", name, info.UnderlyingName(x))
+	}
+
+	// output:
+	// ID "int64"
+	// Ref "int64"
+	// User ""
+}
+
+func ExampleBasicKind_Parse() {
+	for _, typ := range []string{"int32", "uint", "float64", "bool", "string", "User"} {
+		kind := types.Basic(typ)
+		v, err := kind.Parse("1")
+		fmt.Printf("%s: %s %T %v\n", typ, kind, v, err)
+	}
+
+	// output:
+	// int32: integer int64 <nil>
+	// uint: unsigned integer uint64 <nil>
+	// float64: number float64 <nil>
+	// bool: boolean bool <nil>
+	// string: string string <nil>
+	// User: invalid string <nil>
+}
+
+func ExamplePredeclared() {
+	for _, name := range []string{"int64", "interface{}", "User"} {
+		fmt.Println(name, types.Predeclared(name))
+	}
+	// output:
+	// int64 true
+	// interface{} true
+	// User false
+}
+
+func ExampleRoutes() {
+	src := `syntax = "v1"
+
+type User {
+	Name string
+}
+
+@server (
+	prefix: /v1
+)
+service user-api {
+	@handler getUser
+	get /users/:id returns (User)
+	@handler ping
+	post /ping
+}
+`
+	f, err := parser.ParseFile(token.NewFileSet(), "user.api", src, 0)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	for _, r := range types.Routes(f) {
+		fmt.Println(r, r.Handler)
+		for _, path := range []string{"/v1/users/42", "/v1/ping"} {
+			if params, ok := r.Match(path); ok {
+				fmt.Println("\tmatches", path, params)
+			}
+		}
+	}
+	// output:
+	// GET /v1/users/:id getUser
+	// 	matches /v1/users/42 map[id:42]
+	// POST /v1/ping ping
+	// 	matches /v1/ping map[]
+}
+
+func ExampleExportedName() {
+	for _, s := range []string{"user-api", "user_id", "getHTTPStatus", "2fa"} {
+		fmt.Println(types.Words(s), types.ExportedName(s), types.IsIdentifier(s))
+	}
+	// output:
+	// [user api] UserApi false
+	// [user id] UserId true
+	// [get HTTPStatus] GetHTTPStatus true
+	// [2fa] X2fa false
+}
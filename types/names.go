@@ -0,0 +1,64 @@
+package types
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Words splits s into words at non-alphanumeric characters and at
+// lower-to-upper case transitions, such as "user", "Id" for "user_Id".
+func Words(s string) []string {
+	var list []string
+	start := -1
+	var prev rune
+	for i, r := range s {
+		alnum := unicode.IsLetter(r) || unicode.IsDigit(r)
+		switch {
+		case !alnum:
+			if start >= 0 {
+				list = append(list, s[start:i])
+				start = -1
+			}
+		case start < 0:
+			start = i
+		case unicode.IsUpper(r) && (unicode.IsLower(prev) || unicode.IsDigit(prev)):
+			list = append(list, s[start:i])
+			start = i
+		}
+		prev = r
+	}
+	if start >= 0 {
+		list = append(list, s[start:])
+	}
+	return list
+}
+
+// ExportedName returns s as exported identifier in upper camel case,
+// such as "UserApi" for "user-api". A name that would not start with a
+// letter is prefixed with X.
+func ExportedName(s string) string {
+	var b strings.Builder
+	for _, w := range Words(s) {
+		r, n := utf8.DecodeRuneInString(w)
+		b.WriteRune(unicode.ToUpper(r))
+		b.WriteString(w[n:])
+	}
+	name := b.String()
+	if r, _ := utf8.DecodeRuneInString(name); !unicode.IsLetter(r) {
+		name = "X" + name
+	}
+	return name
+}
+
+// IsIdentifier reports whether name is a Go identifier: a letter or
+// underscore followed by letters, digits and underscores. Unlike
+// token.IsIdentifier, it accepts the keywords of api files.
+func IsIdentifier(name string) bool {
+	for i, c := range name {
+		if !unicode.IsLetter(c) && c != '_' && (i == 0 || !unicode.IsDigit(c)) {
+			return false
+		}
+	}
+	return name != ""
+}
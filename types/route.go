@@ -0,0 +1,89 @@
+package types
+
+import (
+	"strings"
+
+	"github.com/zeromicro/api-ast/ast"
+)
+
+// A Route is a route of a service, with the prefix of the service
+// applied to its path.
+type Route struct {
+	Service *ast.Service      // the service declaring the route
+	Decl    *ast.ServiceRoute // the declaration; Decl.Route is not nil
+	Method  string            // upper case, such as GET
+	Path    string            // full path, including the prefix
+	Handler string            // handler name; or ""
+	Req     ast.Expr          // request type, without parentheses; or nil
+	Resp    ast.Expr          // response type, without parentheses; or nil
+
+	segments []string // segments of Path
+}
+
+// String returns the method and path of r, such as "GET /users/:id".
+func (r *Route) String() string { return r.Method + " " + r.Path }
+
+// Routes returns the routes of the services of files, in source order.
+// Routes lacking a method or path, as left by syntax errors, are
+// skipped.
+func Routes(files ...*ast.File) []*Route {
+	var list []*Route
+	for _, f := range files {
+		for _, svc := range f.Services() {
+			prefix := svc.AtServer.Value("prefix")
+			for _, sr := range svc.ServiceApi.ServiceRoute {
+				rt := sr.Route
+				if rt == nil || rt.Method == nil || rt.Path == nil {
+					continue
+				}
+				r := &Route{
+					Service: svc,
+					Decl:    sr,
+					Method:  strings.ToUpper(rt.Method.Name),
+					Path:    prefix + rt.Path.Name,
+				}
+				r.segments = splitPath(r.Path)
+				if sr.AtHandler != nil {
+					r.Handler = sr.AtHandler.Text()
+				}
+				if rt.Req != nil {
+					r.Req = rt.Req.X
+				}
+				if rt.Resp != nil {
+					r.Resp = rt.Resp.X
+				}
+				list = append(list, r)
+			}
+		}
+	}
+	return list
+}
+
+// Match reports whether the request path matches the path of r, whose
+// segments starting with ':' match any segment, and returns the values
+// of these path parameters by name.
+func (r *Route) Match(path string) (params map[string]string, ok bool) {
+	pattern := r.segments
+	if pattern == nil {
+		pattern = splitPath(r.Path) // r was not made by Routes
+	}
+	segments := splitPath(path)
+	if len(segments) != len(pattern) {
+		return nil, false
+	}
+	params = make(map[string]string)
+	for i, s := range pattern {
+		switch {
+		case strings.HasPrefix(s, ":"):
+			params[s[1:]] = segments[i]
+		case s != segments[i]:
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+// splitPath returns the segments of the slash-separated path p.
+func splitPath(p string) []string {
+	return strings.Split(strings.Trim(p, "/"), "/")
+}
@@ -0,0 +1,312 @@
+// Package types resolves the named types declared in api files.
+//
+// Unlike go/types, the package does not type-check: it indexes type
+// declarations by name and provides the operations code generators
+// need, such as following type names and flattening embedded fields.
+package types
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/zeromicro/api-ast/ast"
+	"github.com/zeromicro/api-ast/tag"
+	"github.com/zeromicro/api-ast/token"
+)
+
+// Info holds the type declarations of a set of files.
+type Info struct {
+	Specs []*ast.TypeSpec          // type declarations, in source order
+	Defs  map[string]*ast.TypeSpec // type declarations by name
+}
+
+// NewInfo collects the type declarations of files. It reports an error
+// if a type is declared more than once; the first declaration wins.
+func NewInfo(files ...*ast.File) (*Info, error) {
+	info := &Info{Defs: make(map[string]*ast.TypeSpec)}
+	var err error
+	for _, f := range files {
+		for _, d := range f.Decls {
+			d, ok := d.(*ast.GenDecl)
+			if !ok || d.Tok != token.TYPE {
+				continue
+			}
+			for _, s := range d.Specs {
+				s, ok := s.(*ast.TypeSpec)
+				if !ok {
+					continue
+				}
+				if info.Defs[s.Name.Name] != nil {
+					if err == nil {
+						err = fmt.Errorf("type %s redeclared", s.Name.Name)
+					}
+					continue
+				}
+				info.Defs[s.Name.Name] = s
+				info.Specs = append(info.Specs, s)
+			}
+		}
+	}
+	return info, err
+}
+
+// Lookup returns the declaration of the type name, or nil.
+func (info *Info) Lookup(name string) *ast.TypeSpec {
+	return info.Defs[name]
+}
+
+// Underlying returns x with parentheses removed and declared type names
+// replaced by their definitions, recursively. Predeclared, qualified and
+// undeclared type names are returned as is.
+func (info *Info) Underlying(x ast.Expr) ast.Expr {
+	seen := make(map[string]bool)
+	for {
+		switch t := x.(type) {
+		case *ast.ParenExpr:
+			x = t.X
+			continue
+		case *ast.Ident:
+			if s := info.Defs[t.Name]; s != nil && !seen[t.Name] {
+				seen[t.Name] = true
+				x = s.Type
+				continue
+			}
+		}
+		return x
+	}
+}
+
+// Indirect returns the type underlying x with pointers removed, as the
+// encoding of a pointer is that of its element.
+func (info *Info) Indirect(x ast.Expr) ast.Expr {
+	for {
+		x = info.Underlying(x)
+		star, ok := x.(*ast.StarExpr)
+		if !ok {
+			return x
+		}
+		x = star.X
+	}
+}
+
+// UnderlyingName returns the name of the type underlying x if that is a
+// type name, such as int64 for a type declared as int64, or "".
+func (info *Info) UnderlyingName(x ast.Expr) string {
+	if id, ok := info.Underlying(x).(*ast.Ident); ok {
+		return id.Name
+	}
+	return ""
+}
+
+// Struct returns the struct type underlying x, or nil.
+func (info *Info) Struct(x ast.Expr) *ast.StructType {
+	st, _ := info.Underlying(x).(*ast.StructType)
+	return st
+}
+
+// A BasicKind is the kind of values of a predeclared scalar type.
+type BasicKind int
+
+const (
+	Invalid BasicKind = iota // not a predeclared scalar type
+	Bool
+	Int
+	Uint
+	Float
+	String
+)
+
+var basicKinds = [...]string{
+	Invalid: "invalid",
+	Bool:    "boolean",
+	Int:     "integer",
+	Uint:    "unsigned integer",
+	Float:   "number",
+	String:  "string",
+}
+
+func (k BasicKind) String() string {
+	if 0 <= k && int(k) < len(basicKinds) {
+		return basicKinds[k]
+	}
+	return fmt.Sprintf("BasicKind(%d)", int(k))
+}
+
+// Basic returns the kind of the predeclared type name, such as Int for
+// int32, or Invalid.
+func Basic(name string) BasicKind {
+	switch name {
+	case "bool":
+		return Bool
+	case "int", "int8", "int16", "int32", "int64", "rune":
+		return Int
+	case "uint", "uint8", "uint16", "uint32", "uint64", "uintptr", "byte":
+		return Uint
+	case "float32", "float64":
+		return Float
+	case "string":
+		return String
+	}
+	return Invalid
+}
+
+// predeclared are the predeclared type names that are identifiers, in
+// the order of suggestions.
+var predeclared = []string{
+	"string", "bool", "int", "int8", "int16", "int32", "int64",
+	"uint", "uint8", "uint16", "uint32", "uint64", "uintptr",
+	"float32", "float64", "complex64", "complex128", "byte", "rune", "any",
+}
+
+// Predeclared reports whether name is a predeclared type name. The
+// empty interface, spelled interface{}, counts as one.
+func Predeclared(name string) bool {
+	if name == "interface{}" {
+		return true
+	}
+	for _, p := range predeclared {
+		if p == name {
+			return true
+		}
+	}
+	return false
+}
+
+// PredeclaredNames returns the predeclared type names that are
+// identifiers, most common first, as suggested for a misspelled name.
+func PredeclaredNames() []string {
+	return append([]string(nil), predeclared...)
+}
+
+// Parse converts the text s, such as the value of a tag option, to a
+// value of kind k: a bool, int64, uint64 or float64, or s itself for
+// strings and invalid kinds.
+func (k BasicKind) Parse(s string) (interface{}, error) {
+	switch k {
+	case Bool:
+		return strconv.ParseBool(s)
+	case Int:
+		return strconv.ParseInt(s, 10, 64)
+	case Uint:
+		return strconv.ParseUint(s, 10, 64)
+	case Float:
+		return strconv.ParseFloat(s, 64)
+	}
+	return s, nil
+}
+
+// A Field is a (possibly promoted) field of a struct type.
+type Field struct {
+	Name  string     // Go field name
+	Type  ast.Expr   // field type
+	Tags  tag.Tags   // parsed field tags
+	Field *ast.Field // declaring field
+}
+
+// PropertyName returns the name of f in the encoding of its struct: the
+// name of its first json, form, path or header tag, or its Go name if
+// that tag has no name or there is none. It returns "" if the tag names
+// it "-", so that f is not encoded.
+func (f *Field) PropertyName() string {
+	for _, key := range []string{tag.JSON, tag.Form, tag.Path, tag.Header} {
+		if t := f.Tags.Get(key); t != nil {
+			switch t.Name {
+			case "-":
+				return ""
+			case "":
+				return f.Name
+			}
+			return t.Name
+		}
+	}
+	return f.Name
+}
+
+// Fields returns the fields of the struct type underlying x in source
+// order. The fields of embedded structs are promoted, except for those
+// shadowed by fields of the embedding struct, as in Go. As in Go, too,
+// fields of the same name at the same, shallowest depth are ambiguous,
+// and none of them is returned. The result is empty if x is not a
+// struct type.
+func (info *Info) Fields(x ast.Expr) ([]*Field, error) {
+	var list []*Field
+	depth := make(map[*Field]int)
+	seen := make(map[string]bool) // structs on the embedding path
+	var collect func(x ast.Expr, d int) error
+	collect = func(x ast.Expr, d int) error {
+		st := info.Struct(x)
+		if st == nil || st.Fields == nil {
+			return nil
+		}
+		for _, f := range st.Fields.List {
+			tags, err := tag.Field(f)
+			if err != nil {
+				return err
+			}
+			if len(f.Names) == 0 {
+				name := embeddedName(f.Type)
+				if info.Struct(f.Type) != nil && f.Tag == nil {
+					if seen[name] {
+						return fmt.Errorf("invalid recursive embedding of %s", name)
+					}
+					seen[name] = true
+					err := collect(f.Type, d+1)
+					delete(seen, name)
+					if err != nil {
+						return err
+					}
+					continue
+				}
+				fld := &Field{Name: name, Type: f.Type, Tags: tags, Field: f}
+				depth[fld] = d
+				list = append(list, fld)
+				continue
+			}
+			for _, n := range f.Names {
+				fld := &Field{Name: n.Name, Type: f.Type, Tags: tags, Field: f}
+				depth[fld] = d
+				list = append(list, fld)
+			}
+		}
+		return nil
+	}
+	if err := collect(x, 0); err != nil {
+		return nil, err
+	}
+
+	// remove promoted fields shadowed by a shallower field of the
+	// same name, and ambiguous fields
+	best := make(map[string]*Field)
+	ambiguous := make(map[string]bool)
+	for _, f := range list {
+		switch m := best[f.Name]; {
+		case m == nil || depth[f] < depth[m]:
+			best[f.Name] = f
+			ambiguous[f.Name] = false
+		case depth[f] == depth[m]:
+			ambiguous[f.Name] = true
+		}
+	}
+	var res []*Field
+	for _, f := range list {
+		if best[f.Name] == f && !ambiguous[f.Name] {
+			res = append(res, f)
+		}
+	}
+	return res, nil
+}
+
+// embeddedName returns the field name of an embedded field of type x.
+func embeddedName(x ast.Expr) string {
+	switch t := x.(type) {
+	case *ast.Ident:
+		return t.Name
+	case *ast.StarExpr:
+		return embeddedName(t.X)
+	case *ast.SelectorExpr:
+		return t.Sel.Name
+	case *ast.ParenExpr:
+		return embeddedName(t.X)
+	}
+	return ""
+}
@@ -0,0 +1,293 @@
+package validate
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/zeromicro/api-ast/ast"
+	"github.com/zeromicro/api-ast/tag"
+	"github.com/zeromicro/api-ast/types"
+)
+
+// A decoder decodes and checks the values of a request.
+type decoder struct {
+	info   *types.Info
+	errors []*FieldError
+}
+
+func (d *decoder) errorf(in, field, rule, format string, args ...interface{}) {
+	d.errors = append(d.errors, &FieldError{In: in, Field: field, Rule: rule, Message: fmt.Sprintf(format, args...)})
+}
+
+// request decodes the request req with the path parameters params as a
+// value of the struct type x.
+func (d *decoder) request(req *http.Request, x ast.Expr, params map[string]string) (map[string]interface{}, error) {
+	fields, err := d.info.Fields(x)
+	if err != nil {
+		return nil, err
+	}
+	body, ok := d.body(req)
+	if !ok {
+		return nil, nil
+	}
+	if err := req.ParseForm(); err != nil {
+		d.errorf("form", "", "syntax", "invalid form: %v", err)
+		return nil, nil
+	}
+
+	var object map[string]interface{}
+	if body != nil {
+		if object, ok = body.(map[string]interface{}); !ok {
+			d.errorf("body", "", "type", "body is not a JSON object")
+			return nil, nil
+		}
+	}
+	values := make(map[string]interface{})
+	for _, f := range fields {
+		in, name := binding(f)
+		var v interface{}
+		var ok bool
+		switch in {
+		case "":
+			continue
+		case tag.Path:
+			var list []string
+			if p, found := params[name]; found {
+				list = []string{p}
+			}
+			v, ok = d.text(f, in, name, list)
+		case tag.Form:
+			v, ok = d.text(f, in, name, req.Form[name])
+		case tag.Header:
+			v, ok = d.text(f, in, name, req.Header.Values(name))
+		case tag.JSON:
+			var jv interface{}
+			if jv, ok = object[name]; ok {
+				v = d.json(jv, f.Type, f.Tags, name)
+			} else {
+				v, ok = d.missing(f, in, name)
+			}
+		}
+		if ok {
+			values[f.Name] = v
+		}
+	}
+	return values, nil
+}
+
+// body returns the decoded JSON body of req, or nil if it has none, and
+// reports whether the body is valid. The body of req is left ready to
+// be read again.
+func (d *decoder) body(req *http.Request) (interface{}, bool) {
+	if req.Body == nil {
+		return nil, true
+	}
+	data, err := io.ReadAll(req.Body)
+	if err != nil {
+		d.errorf("body", "", "syntax", "cannot read body: %v", err)
+		return nil, false
+	}
+	req.Body = io.NopCloser(bytes.NewReader(data))
+	if len(bytes.TrimSpace(data)) == 0 || !strings.Contains(req.Header.Get("Content-Type"), "json") {
+		return nil, true
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		d.errorf("body", "", "syntax", "invalid JSON body: %v", err)
+		return nil, false
+	}
+	return v, true
+}
+
+// binding returns the part of a request that field f is bound to and
+// the name under which it appears there; the part is "" if f is not
+// bound.
+func binding(f *types.Field) (in, name string) {
+	for _, key := range []string{tag.JSON, tag.Form, tag.Path, tag.Header} {
+		if t := f.Tags.Get(key); t != nil {
+			switch t.Name {
+			case "-":
+				return "", ""
+			case "":
+				return key, f.Name
+			}
+			return key, t.Name
+		}
+	}
+	return tag.JSON, f.Name
+}
+
+// missing returns the default value of the field f, which is missing
+// from the request part in under the name name, and reports whether it
+// has one. A required field is reported.
+func (d *decoder) missing(f *types.Field, in, name string) (interface{}, bool) {
+	for _, t := range f.Tags {
+		if def, ok := t.Option("default"); ok {
+			x := d.info.Indirect(f.Type)
+			if v, err := types.Basic(d.info.UnderlyingName(x)).Parse(def); err == nil {
+				return v, true
+			}
+			return def, true
+		}
+	}
+	if !f.Tags.Optional() {
+		d.errorf(in, name, "required", "%s %q is required", in, name)
+	}
+	return nil, false
+}
+
+// text decodes the textual values list of the field f, which is bound
+// to the request part in under the name name, and reports whether the
+// field has a value. A slice field takes all values, any other field
+// the first.
+func (d *decoder) text(f *types.Field, in, name string, list []string) (interface{}, bool) {
+	if len(list) == 0 {
+		return d.missing(f, in, name)
+	}
+	x := d.info.Indirect(f.Type)
+	at, isSlice := x.(*ast.ArrayType)
+	if isSlice {
+		x = d.info.Indirect(at.Elt)
+	} else {
+		list = list[:1]
+	}
+	kind := types.Basic(d.info.UnderlyingName(x))
+	var values []interface{}
+	for _, s := range list {
+		if kind == types.Invalid {
+			values = append(values, s)
+			continue
+		}
+		v, err := kind.Parse(s)
+		if err != nil {
+			d.errorf(in, name, "type", "%s %q: invalid %s %q", in, name, kind, s)
+			return nil, false
+		}
+		d.rules(f.Tags, in, name, v)
+		values = append(values, v)
+	}
+	if isSlice {
+		return values, true
+	}
+	return values[0], true
+}
+
+// json decodes the JSON value v, which is of type x and, for a field,
+// has the tags tags. The value is named path in the body.
+func (d *decoder) json(v interface{}, x ast.Expr, tags tag.Tags, path string) interface{} {
+	if v == nil {
+		return nil // null leaves the zero value
+	}
+	x = d.info.Indirect(x)
+	switch t := x.(type) {
+	case *ast.ArrayType:
+		if elt, ok := t.Elt.(*ast.Ident); ok && (elt.Name == "byte" || elt.Name == "uint8") {
+			if _, ok := v.(string); !ok {
+				d.errorf(tag.JSON, path, "type", "json %q: expected a base64 string", path)
+			}
+			return v
+		}
+		list, ok := v.([]interface{})
+		if !ok {
+			d.errorf(tag.JSON, path, "type", "json %q: expected an array", path)
+			return nil
+		}
+		res := make([]interface{}, len(list))
+		for i, e := range list {
+			res[i] = d.json(e, t.Elt, tags, fmt.Sprintf("%s[%d]", path, i))
+		}
+		return res
+	case *ast.MapType:
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			d.errorf(tag.JSON, path, "type", "json %q: expected an object", path)
+			return nil
+		}
+		res := make(map[string]interface{}, len(m))
+		for k, e := range m {
+			res[k] = d.json(e, t.Value, nil, path+"."+k)
+		}
+		return res
+	case *ast.StructType:
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			d.errorf(tag.JSON, path, "type", "json %q: expected an object", path)
+			return nil
+		}
+		fields, err := d.info.Fields(t)
+		if err != nil {
+			d.errorf(tag.JSON, path, "type", "json %q: %v", path, err)
+			return nil
+		}
+		res := make(map[string]interface{})
+		for _, f := range fields {
+			in, name := binding(f)
+			if in != tag.JSON {
+				continue
+			}
+			if e, ok := m[name]; ok {
+				res[name] = d.json(e, f.Type, f.Tags, path+"."+name)
+			} else if def, ok := d.missing(f, in, path+"."+name); ok {
+				res[name] = def
+			}
+		}
+		return res
+	}
+
+	kind := types.Basic(d.info.UnderlyingName(x))
+	var val interface{}
+	switch kind {
+	case types.Bool:
+		if _, ok := v.(bool); ok {
+			val = v
+		}
+	case types.Int, types.Uint, types.Float:
+		if n, ok := v.(json.Number); ok {
+			if x, err := kind.Parse(n.String()); err == nil {
+				val = x
+			}
+		}
+	case types.String:
+		if _, ok := v.(string); ok {
+			val = v
+		}
+	default:
+		return v // any, time.Time and the like
+	}
+	if val == nil {
+		d.errorf(tag.JSON, path, "type", "json %q: expected %s", path, kind)
+		return nil
+	}
+	d.rules(tags, tag.JSON, path, val)
+	return val
+}
+
+// rules checks the scalar value v of the field named name in the request
+// part in against the options and range of its tags.
+func (d *decoder) rules(tags tag.Tags, in, name string, v interface{}) {
+	text := fmt.Sprint(v)
+	for _, t := range tags {
+		if opts, ok := t.Enum(); ok {
+			found := false
+			for _, o := range opts {
+				found = found || o == text
+			}
+			if !found {
+				d.errorf(in, name, "options", "%s %q: %s is not one of %s", in, name, text, strings.Join(opts, ", "))
+			}
+		}
+		if r, ok := t.Range(); ok {
+			if n, err := strconv.ParseFloat(text, 64); err == nil && !r.Contains(n) {
+				v, _ := t.Option("range")
+				d.errorf(in, name, "range", "%s %q: %s is out of range %s", in, name, text, v)
+			}
+		}
+	}
+}
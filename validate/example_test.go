@@ -0,0 +1,54 @@
+package validate_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/zeromicro/api-ast/parser"
+	"github.com/zeromicro/api-ast/token"
+	"github.com/zeromicro/api-ast/validate"
+)
+
+func ExampleValidator_Middleware() {
+	const src = `
+type ListReq {
+	Team   string ` + "`path:\"team\"`" + `
+	Page   int    ` + "`form:\"page,default=1\"`" + `
+	Size   int    ` + "`form:\"size,range=[1:100]\"`" + `
+	Status string ` + "`form:\"status,options=active|banned,optional\"`" + `
+}
+
+service user-api {
+	@handler listUsers
+	get /teams/:team/users (ListReq)
+}
+`
+	f, err := parser.ParseFile(token.NewFileSet(), "user.api", src, 0)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	v, err := validate.New(f)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	h := v.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		values := validate.Values(r.Context())
+		fmt.Fprintf(w, "team=%v page=%v size=%v\n", values["Team"], values["Page"], values["Size"])
+	}))
+
+	for _, url := range []string{
+		"/teams/dev/users?size=20",
+		"/teams/dev/users?size=500&status=gone",
+	} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", url, nil))
+		fmt.Printf("%d %s", w.Code, w.Body)
+	}
+
+	// Output:
+	// 200 team=dev page=1 size=20
+	// 400 {"errors":[{"in":"form","field":"size","rule":"range","message":"form \"size\": 500 is out of range [1:100]"},{"in":"form","field":"status","rule":"options","message":"form \"status\": gone is not one of active, banned"}]}
+}
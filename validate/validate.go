@@ -0,0 +1,176 @@
+// Package validate checks HTTP requests against the request types of
+// the routes declared in api files, so that the rules of a request are
+// stated once, in its tags, instead of again in handler code.
+//
+// A request is matched to a route by its method and path, where a path
+// parameter such as :id matches any path segment. Its values are then
+// decoded the way go-zero binds them: fields tagged path, form and
+// header are read from the path parameters, the query string and form
+// body, and the headers, and all other fields from the JSON body. Every
+// value is checked against the type of its field and the rules of its
+// tags:
+//
+//	optional, omitempty  the value may be missing
+//	default=value        the value may be missing and defaults to value
+//	options=a|b|c        the value must be one of the listed ones
+//	range=[min:max]      the number must lie in the range; a parenthesis
+//	                     instead of a bracket excludes the bound
+//
+// All other values are required. The rules apply to the fields of
+// nested structs in the JSON body too.
+package validate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/zeromicro/api-ast/ast"
+	"github.com/zeromicro/api-ast/types"
+)
+
+// A Config controls the validation.
+type Config struct {
+	// ErrorHandler writes the response to a request that failed
+	// validation; default: WriteError.
+	ErrorHandler func(w http.ResponseWriter, r *http.Request, err *Error)
+}
+
+// A Validator validates the requests of the routes of an API.
+type Validator struct {
+	cfg    *Config
+	info   *types.Info
+	routes []*types.Route
+}
+
+// New returns a validator for the routes declared in files, using the
+// default configuration.
+func New(files ...*ast.File) (*Validator, error) {
+	return (&Config{}).New(files...)
+}
+
+// New returns a validator for the routes declared in files.
+func (cfg *Config) New(files ...*ast.File) (*Validator, error) {
+	info, err := types.NewInfo(files...)
+	if err != nil {
+		return nil, fmt.Errorf("validate: %v", err)
+	}
+	v := &Validator{cfg: cfg, info: info}
+	for _, r := range types.Routes(files...) {
+		if id, ok := r.Req.(*ast.Ident); ok && info.Lookup(id.Name) == nil {
+			return nil, fmt.Errorf("validate: %s: undeclared type %s", r, id.Name)
+		}
+		v.routes = append(v.routes, r)
+	}
+	return v, nil
+}
+
+// match returns the route of the request req and its path parameters,
+// or nil.
+func (v *Validator) match(req *http.Request) (*types.Route, map[string]string) {
+	for _, r := range v.routes {
+		if r.Method != req.Method {
+			continue
+		}
+		if params, ok := r.Match(req.URL.Path); ok {
+			return r, params
+		}
+	}
+	return nil, nil
+}
+
+// Middleware returns a handler that validates the requests of the
+// declared routes and passes the valid ones to next, with their values
+// in the request context; see Values. Requests of other routes are
+// passed to next unchanged.
+func (v *Validator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		values, err := v.Validate(r)
+		switch err := err.(type) {
+		case nil:
+		case *Error:
+			if v.cfg.ErrorHandler != nil {
+				v.cfg.ErrorHandler(w, r, err)
+			} else {
+				WriteError(w, err)
+			}
+			return
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if values != nil {
+			r = r.WithContext(context.WithValue(r.Context(), valuesKey{}, values))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+type valuesKey struct{}
+
+// Values returns the values of the request validated by the middleware,
+// keyed by Go field name, or nil if the request was not validated.
+//
+// Scalars are bool, int64, uint64, float64 or string values, slices are
+// []interface{} values and structs and maps are map[string]interface{}
+// values keyed by JSON name. Missing values with a default are included.
+func Values(ctx context.Context) map[string]interface{} {
+	m, _ := ctx.Value(valuesKey{}).(map[string]interface{})
+	return m
+}
+
+// Validate validates the request req against the request type of its
+// route and returns its values, as described for Values. If the request
+// is invalid, the error is an *Error. If no route matches req, or the
+// route has no request type, Validate returns nil, nil. The body of req
+// can be read again afterwards.
+func (v *Validator) Validate(req *http.Request) (map[string]interface{}, error) {
+	r, params := v.match(req)
+	if r == nil || r.Req == nil {
+		return nil, nil
+	}
+	d := &decoder{info: v.info}
+	values, err := d.request(req, r.Req, params)
+	if err != nil {
+		return nil, err
+	}
+	if len(d.errors) > 0 {
+		return nil, &Error{Errors: d.errors}
+	}
+	return values, nil
+}
+
+// A FieldError describes why a value of a request is invalid.
+type FieldError struct {
+	In      string `json:"in"`              // "path", "form", "header", "json" or "body" for the whole body
+	Field   string `json:"field,omitempty"` // name of the value, such as "user.tags[0]"
+	Rule    string `json:"rule"`            // "required", "type", "options", "range" or "syntax"
+	Message string `json:"message"`
+}
+
+func (e *FieldError) Error() string { return e.Message }
+
+// An Error is the list of problems of an invalid request.
+type Error struct {
+	Errors []*FieldError `json:"errors"`
+}
+
+func (e *Error) Error() string {
+	var msgs []string
+	for _, fe := range e.Errors {
+		msgs = append(msgs, fe.Message)
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// WriteError writes err as a JSON object with the list of problems and
+// the status 400 Bad Request.
+func WriteError(w http.ResponseWriter, err *Error) {
+	data, _ := json.Marshal(err)
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusBadRequest)
+	w.Write(append(data, '\n'))
+}